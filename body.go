@@ -0,0 +1,177 @@
+package procroute
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// errBodyTooLarge is returned by bodyReader, if the request body exceeds the maximum body size
+var errBodyTooLarge = errors.New("request body too large")
+
+// MaxBodySize defines an optional interface that limits the size of the request body for a single route.
+// It overrides the maximum body size of the route set and the route machine.
+type MaxBodySize interface {
+	// MaxBodySize returns the maximum size of the request body in bytes. A size less than or equal to zero disables the limit.
+	//
+	// Example:
+	//  func (m *MyType) MaxBodySize() int64 {
+	//  	return 10 << 20 // 10 MiB
+	//  }
+	MaxBodySize() int64
+}
+
+// SetMaxBodySize provides a method that limits the size of request bodies within the route set.
+// Requests exceeding the limit are answered with 413 Request Entity Too Large.
+// A size of zero inherits the maximum body size of the route machine, a negative size disables the limit.
+func (rs *RouteSet) SetMaxBodySize(size int64) *RouteSet {
+	rs.maxBodySize = size
+	return rs
+}
+
+// withMaxBodySize provides a method that sets the maximum body size of the route machine, which is used if the route set does not define its own
+func (rs *RouteSet) withMaxBodySize(size int64) *RouteSet {
+	rs.defaultMaxBodySize = size
+	return rs
+}
+
+// maxBodySizeFor returns the maximum body size of the route controller, the route set or the route machine, in that order
+func (rs *RouteSet) maxBodySizeFor(routeController interface{}) int64 {
+	if m, ok := routeController.(MaxBodySize); ok {
+		return m.MaxBodySize()
+	}
+	if rs.maxBodySize != 0 {
+		return rs.maxBodySize
+	}
+	return rs.defaultMaxBodySize
+}
+
+// readBody reads the request body up to the maximum body size of the route controller.
+// Requests whose method does not carry a body are not read at all and nil is returned.
+func (rs *RouteSet) readBody(routeController interface{}, r *http.Request) ([]byte, *HttpError) {
+	if !bodyExpected(r.Method) {
+		return nil, nil
+	}
+
+	limit := rs.maxBodySizeFor(routeController)
+	if limit <= 0 {
+		bts, err := io.ReadAll(r.Body)
+		if httpErr := decompressionError(r); httpErr != nil {
+			return nil, httpErr
+		}
+		if err != nil {
+			return nil, &HttpError{
+				Status:    http.StatusInternalServerError,
+				ErrorCode: "",
+				Message:   err.Error(),
+			}
+		}
+		return bts, nil
+	}
+
+	// reject requests that announce a larger body without reading it
+	if r.ContentLength > limit {
+		return nil, bodyTooLarge(limit)
+	}
+
+	// read one byte more than allowed to detect bodies exceeding the limit
+	bts, err := io.ReadAll(io.LimitReader(r.Body, limit+1))
+	if httpErr := decompressionError(r); httpErr != nil {
+		return nil, httpErr
+	}
+	if err != nil {
+		return nil, &HttpError{
+			Status:    http.StatusInternalServerError,
+			ErrorCode: "",
+			Message:   err.Error(),
+		}
+	}
+	if int64(len(bts)) > limit {
+		return nil, bodyTooLarge(limit)
+	}
+	return bts, nil
+}
+
+// decodeBody decodes the request body by the stream parser while it is read, up to the maximum body size of the route controller.
+// Empty bodies are not decoded and nil is returned, like readBody returns no data for them.
+func (rs *RouteSet) decodeBody(parser StreamParser, routeController interface{}, r *http.Request) (interface{}, *HttpError) {
+	limit := rs.maxBodySizeFor(routeController)
+	if limit > 0 && r.ContentLength > limit {
+		return nil, bodyTooLarge(limit)
+	}
+
+	body := &bodyReader{r: r.Body, limit: limit}
+	buffered := bufio.NewReader(body)
+	if _, err := buffered.Peek(1); err == io.EOF {
+		return nil, nil
+	} else if httpErr := decompressionError(r); httpErr != nil {
+		return nil, httpErr
+	} else if err != nil && !body.exceeded {
+		return nil, &HttpError{
+			Status:    http.StatusInternalServerError,
+			ErrorCode: "",
+			Message:   err.Error(),
+		}
+	}
+
+	ptr, value := decodeTarget(requestType(routeController, r.Method))
+	err := parser.NewDecoder(buffered).Decode(ptr)
+	switch httpErr := decompressionError(r); {
+	case httpErr != nil:
+		return nil, httpErr
+	case body.exceeded:
+		return nil, bodyTooLarge(limit)
+	case err != nil:
+		// the body is malformed or does not fit the expected type, which is a client error
+		return nil, BadRequest(err.Error()).WithCause(err)
+	}
+	return value(), nil
+}
+
+// bodyReader reads the request body and fails with errBodyTooLarge, once more bytes than the limit have been read.
+// A limit less than or equal to zero disables the limit.
+type bodyReader struct {
+	r        io.Reader
+	limit    int64
+	read     int64
+	exceeded bool
+}
+
+// Read reads from the request body until the limit is exceeded
+func (b *bodyReader) Read(p []byte) (int, error) {
+	if b.exceeded {
+		return 0, errBodyTooLarge
+	}
+
+	// read at most one byte more than allowed to detect bodies exceeding the limit
+	if b.limit > 0 && int64(len(p)) > b.limit-b.read+1 {
+		p = p[:b.limit-b.read+1]
+	}
+	n, err := b.r.Read(p)
+	b.read += int64(n)
+	if b.limit > 0 && b.read > b.limit {
+		b.exceeded = true
+		return n, errBodyTooLarge
+	}
+	return n, err
+}
+
+// bodyExpected reports whether requests with the passed method carry a body
+func bodyExpected(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodConnect:
+		return false
+	}
+	return true
+}
+
+// bodyTooLarge returns the error sent to the client, if the request body exceeds the maximum body size
+func bodyTooLarge(limit int64) *HttpError {
+	return &HttpError{
+		Status:    http.StatusRequestEntityTooLarge,
+		ErrorCode: "",
+		Message:   fmt.Sprintf("request body exceeds the maximum size of %d bytes", limit),
+	}
+}
@@ -0,0 +1,194 @@
+package procroute
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// unreadableBody fails the test, if the body is read
+type unreadableBody struct {
+	t *testing.T
+}
+
+func (u *unreadableBody) Read(p []byte) (int, error) {
+	u.t.Errorf("body was read")
+	return 0, io.EOF
+}
+
+func TestRouteSet_maxBodySize(t *testing.T) {
+	body := `{"name":"0123456789"}` // 21 bytes
+
+	tests := []struct {
+		name          string
+		machineSize   int64
+		setSize       int64
+		route         interface{}
+		contentLength int64
+		wantStatus    int
+	}{
+		{
+			name:       "unlimited",
+			route:      &postExample{},
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:        "machine_limit_exceeded",
+			machineSize: 20,
+			route:       &postExample{},
+			wantStatus:  http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:        "machine_limit_reached",
+			machineSize: 21,
+			route:       &postExample{},
+			wantStatus:  http.StatusCreated,
+		},
+		{
+			name:        "set_limit_overrides_machine_limit",
+			machineSize: 20,
+			setSize:     100,
+			route:       &postExample{},
+			wantStatus:  http.StatusCreated,
+		},
+		{
+			name:        "negative_set_limit_disables_machine_limit",
+			machineSize: 20,
+			setSize:     -1,
+			route:       &postExample{},
+			wantStatus:  http.StatusCreated,
+		},
+		{
+			name:       "route_limit_overrides_set_limit",
+			setSize:    100,
+			route:      &maxBodySizeExample{size: 10},
+			wantStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:       "route_disables_limit",
+			setSize:    10,
+			route:      &maxBodySizeExample{size: 0},
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:          "announced_size_exceeded",
+			setSize:       10,
+			route:         &postExample{},
+			contentLength: 1000,
+			wantStatus:    http.StatusRequestEntityTooLarge,
+		},
+	}
+	for _, tt := range tests {
+		for _, parser := range []Parser{&exampleParser{}, &exampleStreamParser{}} {
+			t.Run(fmt.Sprintf("%s/%T", tt.name, parser), func(t *testing.T) {
+				rm := NewRouteMachine("", 0, "/api", &exampleLogger{})
+				if err := rm.AddRouteSet(NewRouteSet("/sample", parser).SetMaxBodySize(tt.setSize).AddRoutes(tt.route)); err != nil {
+					t.Fatalf("RouteMachine.AddRouteSet() error = %v", err)
+				}
+				// the limit of the route machine must be applied to route sets added before
+				rm.SetMaxBodySize(tt.machineSize)
+
+				r := httptest.NewRequest("POST", "/api/sample", strings.NewReader(body))
+				if tt.contentLength != 0 {
+					r.ContentLength = tt.contentLength
+					r.Body = io.NopCloser(&unreadableBody{t: t})
+				}
+
+				w := httptest.NewRecorder()
+				rm.ServeHTTP(w, r)
+
+				if w.Code != tt.wantStatus {
+					t.Errorf("status = %v, want %v", w.Code, tt.wantStatus)
+				}
+				if tt.wantStatus == http.StatusRequestEntityTooLarge && w.Header().Get("Content-Type") != "application/json" {
+					t.Errorf("Content-Type = %v, want application/json", w.Header().Get("Content-Type"))
+				}
+			})
+		}
+	}
+}
+
+func TestRouteSet_readBody(t *testing.T) {
+	rs := NewRouteSet("/api", &exampleParser{}).SetMaxBodySize(10)
+	for _, method := range []string{"GET", "HEAD", "OPTIONS", "TRACE", "CONNECT"} {
+		t.Run(method, func(t *testing.T) {
+			r := httptest.NewRequest(method, "/api", nil)
+			r.Body = io.NopCloser(&unreadableBody{t: t})
+
+			bts, err := rs.readBody(&postExample{}, r)
+			if err != nil || bts != nil {
+				t.Errorf("RouteSet.readBody() = %v, %v, want nil, nil", bts, err)
+			}
+		})
+	}
+}
+
+func TestRouteSet_decodeBody(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		limit      int64
+		wantData   interface{}
+		wantStatus int
+	}{
+		{
+			name:     "decoded",
+			body:     `{"name":"abc"}`,
+			limit:    14,
+			wantData: map[string]interface{}{"name": "abc"},
+		},
+		{
+			name:     "empty_body",
+			body:     ``,
+			limit:    14,
+			wantData: nil,
+		},
+		{
+			name:       "limit_exceeded",
+			body:       `{"name":"abcdef"}`,
+			limit:      14,
+			wantStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:     "unlimited",
+			body:     `{"name":"abcdef"}`,
+			wantData: map[string]interface{}{"name": "abcdef"},
+		},
+		{
+			name:       "malformed",
+			body:       `{"name":`,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := &exampleStreamParser{}
+			rs := NewRouteSet("/api", parser).SetMaxBodySize(tt.limit)
+
+			// the length of the body is unknown, so that the limit is enforced while the body is decoded
+			r := httptest.NewRequest("POST", "/api", io.MultiReader(strings.NewReader(tt.body)))
+			r.ContentLength = -1
+
+			data, err := rs.decodeBody(parser, &postExample{}, r)
+			if err != nil {
+				if err.Status != tt.wantStatus {
+					t.Fatalf("RouteSet.decodeBody() error = %v, want status %v", err, tt.wantStatus)
+				}
+				return
+			}
+			if tt.wantStatus != 0 {
+				t.Fatalf("RouteSet.decodeBody() error = nil, want status %v", tt.wantStatus)
+			}
+			if !reflect.DeepEqual(data, tt.wantData) {
+				t.Errorf("RouteSet.decodeBody() = %v, want %v", data, tt.wantData)
+			}
+			if parser.decoders > 1 {
+				t.Errorf("decoders = %v, want at most 1", parser.decoders)
+			}
+		})
+	}
+}
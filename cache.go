@@ -0,0 +1,471 @@
+package procroute
+
+import (
+	"bytes"
+	"container/list"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Cache provides the interface that must be implemented to store the responses of get and get all routes.
+// Implementations must be safe for concurrent use. NewMemoryCache returns an in-memory implementation, other implementations may use external stores.
+type Cache interface {
+	// Get returns the response stored for the key. The bool is false, if there is no response or it is expired.
+	//
+	// Example:
+	//  func (c *RedisCache) Get(key string) (*procroute.CachedResponse, bool) {
+	//  	bts, err := c.client.Get(context.Background(), key).Bytes()
+	//  	if err != nil {
+	//  		return nil, false
+	//  	}
+	//  	resp := &procroute.CachedResponse{}
+	//  	return resp, gob.NewDecoder(bytes.NewReader(bts)).Decode(resp) == nil
+	//  }
+	Get(key string) (*CachedResponse, bool)
+	// Set stores the response for the key, it expires after the ttl.
+	//
+	// Example:
+	//  func (c *RedisCache) Set(key string, resp *procroute.CachedResponse, ttl time.Duration) {
+	//  	buf := &bytes.Buffer{}
+	//  	if err := gob.NewEncoder(buf).Encode(resp); err == nil {
+	//  		c.client.Set(context.Background(), key, buf.Bytes(), ttl)
+	//  	}
+	//  }
+	Set(key string, resp *CachedResponse, ttl time.Duration)
+	// Delete removes the response stored for the key. It is called for each response of a route set, once they are invalidated by a modification.
+	//
+	// Example:
+	//  func (c *RedisCache) Delete(key string) {
+	//  	c.client.Del(context.Background(), key)
+	//  }
+	Delete(key string)
+}
+
+// CacheTTL defines an optional interface that defines how long the responses of a single get or get all route are cached.
+// It overrides the ttl passed to SetCache, a ttl less than or equal to zero disables caching for the route.
+type CacheTTL interface {
+	// CacheTTL returns the time the responses of the route are cached.
+	//
+	// Example:
+	//  func (m *MyType) CacheTTL() time.Duration {
+	//  	return 5 * time.Minute
+	//  }
+	CacheTTL() time.Duration
+}
+
+// CachedResponse represents a response stored in the Cache
+type CachedResponse struct {
+	// Status is the http status code of the response
+	Status int
+	// Header contains the response headers
+	Header http.Header
+	// Body is the encoded response body
+	Body []byte
+	// Created is the time the response was created, which is used to calculate the Age header
+	Created time.Time
+}
+
+// minKeysPruned is the number of keys a responseCache remembers at least, before expired keys are pruned
+const minKeysPruned = 64
+
+// responseCache is the cache of a route set. It remembers the keys of the stored responses, so that they can be deleted once a successful modification invalidates them.
+// The generation is incremented by each invalidation, so that responses created before an invalidation are not stored afterwards.
+type responseCache struct {
+	// generation must be the first field to be aligned for atomic operations on 32 bit platforms
+	generation uint64
+	cache      Cache
+	ttl        time.Duration
+
+	mu sync.Mutex
+	// keys contains the keys of the stored responses and their expiry time.
+	// Expired keys are pruned once the number of keys reaches pruneAt, which is twice the number of keys left by the last pruning.
+	keys    map[string]time.Time
+	pruneAt int
+}
+
+// store stores the response for the key, unless the cache has been invalidated since the generation was loaded
+func (c *responseCache) store(generation uint64, key string, resp *CachedResponse, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if atomic.LoadUint64(&c.generation) != generation {
+		return
+	}
+	c.cache.Set(key, resp, ttl)
+
+	now := time.Now()
+	if len(c.keys) >= c.pruneAt {
+		for k, expires := range c.keys {
+			if now.After(expires) {
+				delete(c.keys, k)
+			}
+		}
+		c.pruneAt = 2 * len(c.keys)
+		if c.pruneAt < minKeysPruned {
+			c.pruneAt = minKeysPruned
+		}
+	}
+	c.keys[key] = now.Add(ttl)
+}
+
+// invalidate deletes the stored responses and starts a new generation
+func (c *responseCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	atomic.AddUint64(&c.generation, 1)
+	for key := range c.keys {
+		c.cache.Delete(key)
+	}
+	c.keys = map[string]time.Time{}
+}
+
+// SetCache provides a method that caches the responses of the get and get all routes of the route set for the ttl.
+// A ttl less than or equal to zero only caches routes implementing the CacheTTL interface, a nil cache disables caching.
+// Successful post, update, delete and patch requests invalidate the responses cached by the route set.
+// Requests with an Authorization or a Cookie header are neither served from nor stored in the cache, since their responses may depend on the client.
+func (rs *RouteSet) SetCache(cache Cache, ttl time.Duration) *RouteSet {
+	if cache == nil {
+		rs.cache = nil
+		return rs
+	}
+	rs.cache = &responseCache{cache: cache, ttl: ttl, keys: map[string]time.Time{}, pruneAt: minKeysPruned}
+	return rs
+}
+
+// cacheTTLFor returns the time the responses of the route controller are cached. Streaming routes are never cached.
+func (rs *RouteSet) cacheTTLFor(routeController interface{}) time.Duration {
+	if rs.cache == nil {
+		return 0
+	}
+	if _, ok := routeController.(GetAllRouteStream); ok {
+		if _, isPage := routeController.(GetPageRoute); !isPage {
+			return 0
+		}
+	}
+	if c, ok := routeController.(CacheTTL); ok {
+		return c.CacheTTL()
+	}
+	return rs.cache.ttl
+}
+
+// withCache wraps the handler of a get route, so that its responses are served from the cache of the route set.
+// The handler is called without the conditional headers of the request, so that the full response can be stored.
+// The conditional headers are evaluated against the stored response instead.
+func (rs *RouteSet) withCache(routeController interface{}, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ttl := rs.cacheTTLFor(routeController)
+		directives := cacheDirectives(r.Header)
+		// authenticated requests may receive responses that must not be shared with other clients
+		if ttl <= 0 || directives.noStore || r.Header.Get("Authorization") != "" || r.Header.Get("Cookie") != "" {
+			handler(w, r)
+			return
+		}
+
+		parser, err := rs.encoderFor(r)
+		if err != nil {
+			handler(w, r)
+			return
+		}
+
+		cache := rs.cache
+		generation := atomic.LoadUint64(&cache.generation)
+		key := fmt.Sprintf("%s?%s;%s", r.URL.Path, r.URL.Query().Encode(), parser.MimeType())
+		if cached, ok := cache.cache.Get(key); ok && !directives.noCache && (directives.maxAge < 0 || cacheAge(cached) <= directives.maxAge) {
+			rs.writeCached(w, r, cached, true)
+			return
+		}
+		if directives.onlyIfCached {
+			rs.writeError(w, r, NewHttpError(http.StatusGatewayTimeout, "the response is not cached"))
+			return
+		}
+
+		unconditional := r.Clone(r.Context())
+		for _, header := range []string{"If-Match", "If-None-Match", "If-Modified-Since", "If-Unmodified-Since"} {
+			unconditional.Header.Del(header)
+		}
+		recorder := &responseRecorder{header: http.Header{}}
+		handler(recorder, unconditional)
+
+		resp := &CachedResponse{Status: recorder.status, Header: recorder.header, Body: recorder.body.Bytes(), Created: time.Now()}
+		if resp.Status == 0 {
+			resp.Status = http.StatusOK
+		}
+		if cacheable(resp) {
+			if resp.Header.Get("Cache-Control") == "" {
+				resp.Header.Set("Cache-Control", "max-age="+strconv.Itoa(int(ttl/time.Second)))
+			}
+			cache.store(generation, key, resp, ttl)
+		}
+		rs.writeCached(w, r, resp, false)
+	}
+}
+
+// withInvalidation wraps the handler of a modifying route, so that successful requests invalidate the responses cached by the route set
+func (rs *RouteSet) withInvalidation(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sw := &statusResponseWriter{ResponseWriter: w}
+		handler(sw, r)
+
+		if cache := rs.cache; cache != nil && sw.status >= 200 && sw.status < 400 {
+			cache.invalidate()
+		}
+	}
+}
+
+// writeCached writes the recorded response and the Age header of responses served from the cache.
+// Successful responses are evaluated against the conditional headers of the request.
+func (rs *RouteSet) writeCached(w http.ResponseWriter, r *http.Request, resp *CachedResponse, fromCache bool) {
+	for key, values := range resp.Header {
+		w.Header()[key] = append([]string(nil), values...)
+	}
+	if resp.Status != http.StatusOK {
+		w.WriteHeader(resp.Status)
+		w.Write(resp.Body)
+		return
+	}
+
+	if fromCache {
+		w.Header().Set("Age", strconv.Itoa(int(cacheAge(resp)/time.Second)))
+	}
+	// responses without validators are not conditional
+	etag := resp.Header.Get("ETag")
+	lastModified, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+	if etag == "" && lastModified.IsZero() {
+		w.WriteHeader(resp.Status)
+		w.Write(resp.Body)
+		return
+	}
+
+	switch evaluatePreconditions(r, etag, lastModified) {
+	case http.StatusNotModified:
+		w.Header().Del("Content-Type")
+		w.Header().Del("Content-Length")
+		w.WriteHeader(http.StatusNotModified)
+		return
+	case http.StatusPreconditionFailed:
+		for key := range resp.Header {
+			w.Header().Del(key)
+		}
+		rs.writeError(w, r, PreconditionFailed("the preconditions of the request are not met"))
+		return
+	}
+
+	w.WriteHeader(resp.Status)
+	w.Write(resp.Body)
+}
+
+// cacheable reports whether the response may be stored. Only successful responses without cookies, which do not forbid storing them, are cached.
+// Responses varying by other request headers than Accept and Accept-Encoding are not cached, since the key does not contain them.
+func cacheable(resp *CachedResponse) bool {
+	if resp.Status != http.StatusOK || len(resp.Header.Values("Set-Cookie")) > 0 {
+		return false
+	}
+	// the key contains the negotiated content type, the content coding is applied outside of the cache
+	for _, value := range resp.Header.Values("Vary") {
+		for _, field := range strings.Split(value, ",") {
+			switch strings.ToLower(strings.TrimSpace(field)) {
+			case "", "accept", "accept-encoding":
+			default:
+				return false
+			}
+		}
+	}
+	for _, directive := range strings.Split(strings.ToLower(resp.Header.Get("Cache-Control")), ",") {
+		switch strings.TrimSpace(directive) {
+		case "no-store", "no-cache", "private":
+			return false
+		}
+	}
+	return true
+}
+
+// cacheAge returns the time since the response was created
+func cacheAge(resp *CachedResponse) time.Duration {
+	age := time.Since(resp.Created)
+	if age < 0 {
+		return 0
+	}
+	return age
+}
+
+// requestDirectives contains the Cache-Control directives of a request that are supported by the cache of a route set
+type requestDirectives struct {
+	noStore      bool
+	noCache      bool
+	onlyIfCached bool
+	// maxAge is the maximum age of cached responses accepted by the client, negative if it is not limited
+	maxAge time.Duration
+}
+
+// cacheDirectives parses the Cache-Control and Pragma headers of the request
+func cacheDirectives(header http.Header) requestDirectives {
+	directives := requestDirectives{maxAge: -1}
+	if strings.EqualFold(strings.TrimSpace(header.Get("Pragma")), "no-cache") && header.Get("Cache-Control") == "" {
+		directives.noCache = true
+	}
+
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		switch strings.ToLower(name) {
+		case "no-store":
+			directives.noStore = true
+		case "no-cache":
+			directives.noCache = true
+		case "only-if-cached":
+			directives.onlyIfCached = true
+		case "max-age":
+			if seconds, err := strconv.Atoi(strings.Trim(value, `"`)); err == nil && seconds >= 0 {
+				directives.maxAge = time.Duration(seconds) * time.Second
+			}
+		}
+	}
+	return directives
+}
+
+// responseRecorder records the response of a handler, so that it can be stored in the cache
+type responseRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+// Header returns the recorded response headers
+func (rr *responseRecorder) Header() http.Header {
+	return rr.header
+}
+
+// WriteHeader records the status of the response
+func (rr *responseRecorder) WriteHeader(status int) {
+	if rr.status == 0 {
+		rr.status = status
+	}
+}
+
+// Write records the body of the response
+func (rr *responseRecorder) Write(b []byte) (int, error) {
+	if rr.status == 0 {
+		rr.status = http.StatusOK
+	}
+	return rr.body.Write(b)
+}
+
+// statusResponseWriter records the status written to the response writer
+type statusResponseWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader records the status and writes it to the response writer
+func (s *statusResponseWriter) WriteHeader(status int) {
+	if s.status == 0 {
+		s.status = status
+	}
+	s.ResponseWriter.WriteHeader(status)
+}
+
+// Write writes the body to the response writer, the status defaults to 200 OK
+func (s *statusResponseWriter) Write(b []byte) (int, error) {
+	if s.status == 0 {
+		s.status = http.StatusOK
+	}
+	return s.ResponseWriter.Write(b)
+}
+
+// MemoryCache is an in-memory Cache, which evicts the least recently used response, once the maximum number of responses is exceeded
+type MemoryCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	entries    map[string]*list.Element
+	// order contains the entries in the order of their use, the most recently used first
+	order *list.List
+}
+
+// memoryEntry represents a response stored in the MemoryCache
+type memoryEntry struct {
+	key     string
+	resp    *CachedResponse
+	expires time.Time
+}
+
+// NewMemoryCache returns an in-memory Cache, which stores up to maxEntries responses. A maxEntries less than or equal to zero does not limit the number of responses.
+//
+// Example:
+//  rs := procroute.NewRouteSet("/example", &parsers.JsonParser{}).SetCache(procroute.NewMemoryCache(1000), time.Minute)
+func NewMemoryCache(maxEntries int) *MemoryCache {
+	return &MemoryCache{
+		maxEntries: maxEntries,
+		entries:    map[string]*list.Element{},
+		order:      list.New(),
+	}
+}
+
+// Get returns the response stored for the key, unless it is expired
+func (m *MemoryCache) Get(key string) (*CachedResponse, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	element, ok := m.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := element.Value.(*memoryEntry)
+	if time.Now().After(entry.expires) {
+		m.remove(element)
+		return nil, false
+	}
+	m.order.MoveToFront(element)
+	return entry.resp, true
+}
+
+// Set stores the response for the key and evicts expired responses, as well as the least recently used responses, if the maximum number of responses is exceeded
+func (m *MemoryCache) Set(key string, resp *CachedResponse, ttl time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	// expired responses that are not requested anymore are evicted, once they are the least recently used ones
+	for back := m.order.Back(); back != nil && now.After(back.Value.(*memoryEntry).expires); back = m.order.Back() {
+		m.remove(back)
+	}
+
+	entry := &memoryEntry{key: key, resp: resp, expires: now.Add(ttl)}
+	if element, ok := m.entries[key]; ok {
+		element.Value = entry
+		m.order.MoveToFront(element)
+		return
+	}
+	m.entries[key] = m.order.PushFront(entry)
+
+	for m.maxEntries > 0 && m.order.Len() > m.maxEntries {
+		m.remove(m.order.Back())
+	}
+}
+
+// Delete removes the response stored for the key
+func (m *MemoryCache) Delete(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if element, ok := m.entries[key]; ok {
+		m.remove(element)
+	}
+}
+
+// Len returns the number of stored responses, including expired responses that have not been evicted yet
+func (m *MemoryCache) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.order.Len()
+}
+
+// remove removes the entry from the cache
+func (m *MemoryCache) remove(element *list.Element) {
+	m.order.Remove(element)
+	delete(m.entries, element.Value.(*memoryEntry).key)
+}
@@ -0,0 +1,259 @@
+package procroute
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRouteSet_cache(t *testing.T) {
+	type request struct {
+		method     string
+		target     string
+		headers    map[string]string
+		wantStatus int
+		wantCached bool
+	}
+	get := func(target string, headers map[string]string, wantCached bool) request {
+		return request{method: "GET", target: target, headers: headers, wantStatus: http.StatusOK, wantCached: wantCached}
+	}
+
+	tests := []struct {
+		name      string
+		route     interface{}
+		requests  []request
+		wantCalls int
+	}{
+		{
+			name:      "cached",
+			route:     &cacheExample{},
+			requests:  []request{get("/api/sample", nil, false), get("/api/sample", nil, true)},
+			wantCalls: 1,
+		},
+		{
+			name:  "keyed_by_path_query_and_content_type",
+			route: &cacheExample{},
+			requests: []request{
+				get("/api/sample?a=1&b=2", nil, false),
+				get("/api/sample?b=2&a=1", nil, true),
+				get("/api/sample?a=2", nil, false),
+				get("/api/sample?a=1&b=2", map[string]string{"Accept": "application/xml"}, false),
+			},
+			wantCalls: 3,
+		},
+		{
+			name:  "invalidated_by_update",
+			route: &cacheExample{},
+			requests: []request{
+				get("/api/sample", nil, false),
+				{method: "PUT", target: "/api/sample", wantStatus: http.StatusOK},
+				get("/api/sample", nil, false),
+			},
+			wantCalls: 2,
+		},
+		{
+			name:  "not_invalidated_by_failed_update",
+			route: &cacheExample{updateErr: Conflict("conflict")},
+			requests: []request{
+				get("/api/sample", nil, false),
+				{method: "PUT", target: "/api/sample", wantStatus: http.StatusConflict},
+				get("/api/sample", nil, true),
+			},
+			wantCalls: 1,
+		},
+		{
+			name:  "request_directives",
+			route: &cacheExample{},
+			requests: []request{
+				get("/api/sample", map[string]string{"Cache-Control": "no-store"}, false),
+				get("/api/sample", nil, false),
+				get("/api/sample", map[string]string{"Cache-Control": "no-cache"}, false),
+				get("/api/sample", map[string]string{"Pragma": "no-cache"}, false),
+				get("/api/sample", map[string]string{"Cache-Control": "max-age=0"}, false),
+				get("/api/sample", map[string]string{"Cache-Control": "max-age=60"}, true),
+			},
+			wantCalls: 5,
+		},
+		{
+			name:  "only_if_cached",
+			route: &cacheExample{},
+			requests: []request{
+				{method: "GET", target: "/api/sample", headers: map[string]string{"Cache-Control": "only-if-cached"}, wantStatus: http.StatusGatewayTimeout},
+				get("/api/sample", nil, false),
+				get("/api/sample", map[string]string{"Cache-Control": "only-if-cached"}, true),
+			},
+			wantCalls: 1,
+		},
+		{
+			name:  "authorization",
+			route: &cacheExample{},
+			requests: []request{
+				get("/api/sample", map[string]string{"Authorization": "Bearer a"}, false),
+				get("/api/sample", map[string]string{"Authorization": "Bearer a"}, false),
+			},
+			wantCalls: 2,
+		},
+		{
+			name:  "conditional_request",
+			route: &cacheExample{etag: "v1"},
+			requests: []request{
+				{method: "GET", target: "/api/sample", headers: map[string]string{"If-None-Match": `"v1"`}, wantStatus: http.StatusNotModified, wantCached: false},
+				{method: "GET", target: "/api/sample", headers: map[string]string{"If-None-Match": `"v1"`}, wantStatus: http.StatusNotModified, wantCached: true},
+				get("/api/sample", nil, true),
+			},
+			wantCalls: 1,
+		},
+		{
+			name:  "head",
+			route: &cacheExample{},
+			requests: []request{
+				get("/api/sample", nil, false),
+				{method: "HEAD", target: "/api/sample", wantStatus: http.StatusOK, wantCached: true},
+			},
+			wantCalls: 1,
+		},
+		{
+			name:  "cookie",
+			route: &cacheExample{},
+			requests: []request{
+				get("/api/sample", nil, false),
+				get("/api/sample", map[string]string{"Cookie": "session=a"}, false),
+				get("/api/sample", map[string]string{"Cookie": "session=b"}, false),
+			},
+			wantCalls: 3,
+		},
+		{
+			name:  "not_cached_varying_by_other_headers",
+			route: &languageExample{},
+			requests: []request{
+				get("/api/sample", map[string]string{"Accept-Language": "de"}, false),
+				get("/api/sample", map[string]string{"Accept-Language": "en"}, false),
+				get("/api/sample", map[string]string{"Accept-Language": "de"}, false),
+			},
+			wantCalls: 3,
+		},
+		{
+			name:      "disabled_by_route",
+			route:     &uncachedExample{},
+			requests:  []request{get("/api/sample", nil, false), get("/api/sample", nil, false)},
+			wantCalls: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rm := NewRouteMachine("", 0, "/api", &exampleLogger{})
+			rs := NewRouteSet("/sample", &exampleParser{}).AddParsers(&exampleXmlParser{}).SetCache(NewMemoryCache(10), time.Minute).AddRoutes(tt.route)
+			if err := rm.AddRouteSet(rs); err != nil {
+				t.Fatalf("RouteMachine.AddRouteSet() error = %v", err)
+			}
+
+			for i, req := range tt.requests {
+				r := httptest.NewRequest(req.method, req.target, strings.NewReader(`{}`))
+				for key, value := range req.headers {
+					r.Header.Set(key, value)
+				}
+				w := httptest.NewRecorder()
+				rm.ServeHTTP(w, r)
+
+				if w.Code != req.wantStatus {
+					t.Fatalf("request %d: status = %v, want %v, body = %s", i, w.Code, req.wantStatus, w.Body.String())
+				}
+				if cached := w.Header().Get("Age") != ""; cached != req.wantCached {
+					t.Errorf("request %d: cached = %v, want %v", i, cached, req.wantCached)
+				}
+				if got := w.Header().Get("Cache-Control"); req.wantCached && got != "max-age=60" {
+					t.Errorf("request %d: Cache-Control = %v, want max-age=60", i, got)
+				}
+				if req.method == "HEAD" && (w.Body.Len() != 0 || w.Header().Get("Content-Length") == "") {
+					t.Errorf("request %d: body = %s, Content-Length = %v", i, w.Body.String(), w.Header().Get("Content-Length"))
+				}
+			}
+
+			calls := 0
+			switch route := tt.route.(type) {
+			case *cacheExample:
+				calls = route.calls
+			case *uncachedExample:
+				calls = route.calls
+			case *languageExample:
+				calls = route.calls
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("calls = %v, want %v", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestMemoryCache(t *testing.T) {
+	cache := NewMemoryCache(2)
+	a, b, c := &CachedResponse{Body: []byte("a")}, &CachedResponse{Body: []byte("b")}, &CachedResponse{Body: []byte("c")}
+
+	cache.Set("a", a, time.Minute)
+	cache.Set("b", b, time.Minute)
+	// a is used more recently than b, so b is evicted
+	if got, ok := cache.Get("a"); !ok || got != a {
+		t.Fatalf("MemoryCache.Get(a) = %v, %v, want %v, true", got, ok, a)
+	}
+	cache.Set("c", c, time.Minute)
+
+	if _, ok := cache.Get("b"); ok {
+		t.Errorf("MemoryCache.Get(b) ok = true, want evicted")
+	}
+	if got, ok := cache.Get("c"); !ok || got != c {
+		t.Errorf("MemoryCache.Get(c) = %v, %v, want %v, true", got, ok, c)
+	}
+	if cache.Len() != 2 {
+		t.Errorf("MemoryCache.Len() = %v, want 2", cache.Len())
+	}
+
+	cache.Set("a", a, -time.Second)
+	if _, ok := cache.Get("a"); ok {
+		t.Errorf("MemoryCache.Get(a) ok = true, want expired")
+	}
+	if cache.Len() != 1 {
+		t.Errorf("MemoryCache.Len() = %v, want 1", cache.Len())
+	}
+}
+
+func TestRouteSet_cache_bounded(t *testing.T) {
+	cache := NewMemoryCache(0)
+	rm := NewRouteMachine("", 0, "/api", &exampleLogger{})
+	if err := rm.AddRouteSet(NewRouteSet("/sample", &exampleParser{}).SetCache(cache, time.Minute).AddRoutes(&cacheExample{})); err != nil {
+		t.Fatalf("RouteMachine.AddRouteSet() error = %v", err)
+	}
+
+	for i := 0; i < 100; i++ {
+		for _, method := range []string{"GET", "PUT"} {
+			w := httptest.NewRecorder()
+			rm.ServeHTTP(w, httptest.NewRequest(method, fmt.Sprintf("/api/sample?i=%d", i%3), strings.NewReader(`{}`)))
+			if w.Code != http.StatusOK {
+				t.Fatalf("%s status = %v, want %v", method, w.Code, http.StatusOK)
+			}
+		}
+		// each update deletes the responses cached before
+		if cache.Len() != 0 {
+			t.Fatalf("MemoryCache.Len() = %v after %d updates, want 0", cache.Len(), i+1)
+		}
+	}
+}
+
+func TestMemoryCache_expired(t *testing.T) {
+	cache := NewMemoryCache(0)
+	for i := 0; i < 100; i++ {
+		cache.Set(fmt.Sprint(i), &CachedResponse{}, -time.Second)
+	}
+	cache.Set("live", &CachedResponse{}, time.Minute)
+
+	// expired responses are evicted by Set, even if they are never requested again
+	if cache.Len() != 1 {
+		t.Errorf("MemoryCache.Len() = %v, want 1", cache.Len())
+	}
+	cache.Delete("live")
+	if _, ok := cache.Get("live"); ok || cache.Len() != 0 {
+		t.Errorf("MemoryCache.Get(live) ok = %v, Len() = %v, want deleted", ok, cache.Len())
+	}
+}
@@ -0,0 +1,500 @@
+package procroute
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultCompressionMinSize is the minimum size in bytes of response bodies compressed by NewCompression
+	DefaultCompressionMinSize = 1024
+	// DefaultMaxDecompressedSize is the maximum size in bytes of request bodies decompressed by NewCompression
+	DefaultMaxDecompressedSize = 10 << 20
+)
+
+// ContentEncoder returns a writer that compresses the data written to w by a content coding, e.g. gzip.
+// The writer is closed once the response is complete. If it implements Flush() error, it is flushed whenever the response is flushed.
+type ContentEncoder func(w io.Writer) (io.WriteCloser, error)
+
+// ContentDecoder returns a reader that decompresses the data read from r, which is compressed by a content coding.
+type ContentDecoder func(r io.Reader) (io.ReadCloser, error)
+
+// Compression compresses responses by the content coding the Accept-Encoding header of the request prefers
+// and decompresses request bodies by the content codings of the Content-Encoding header, before they are passed to the parser.
+// Entity tags of compressed responses are suffixed by the coding, e.g. "v1-gzip" for "v1", so that each representation has its own tag.
+// The suffix is removed from the If-Match and If-None-Match headers of requests, before they are compared with the entity tags of the routes.
+type Compression struct {
+	minSize             int
+	maxDecompressedSize int64
+	excludedTypes       []string
+
+	// codings contains the registered content codings in the order of preference
+	codings  []string
+	encoders map[string]ContentEncoder
+	decoders map[string]ContentDecoder
+}
+
+// NewCompression returns a compression supporting the gzip and deflate content codings.
+// It compresses responses of at least DefaultCompressionMinSize bytes, except images, audio, video and archives, which are compressed already,
+// and limits decompressed request bodies to DefaultMaxDecompressedSize bytes.
+func NewCompression() *Compression {
+	c := &Compression{
+		minSize:             DefaultCompressionMinSize,
+		maxDecompressedSize: DefaultMaxDecompressedSize,
+		excludedTypes:       []string{"image/*", "audio/*", "video/*", "application/zip", "application/gzip", "application/x-gzip"},
+		encoders:            map[string]ContentEncoder{},
+		decoders:            map[string]ContentDecoder{},
+	}
+	c.RegisterEncoding("gzip", func(w io.Writer) (io.WriteCloser, error) {
+		return gzip.NewWriter(w), nil
+	}, func(r io.Reader) (io.ReadCloser, error) {
+		return gzip.NewReader(r)
+	})
+	// the deflate content coding is the zlib format of RFC 1950
+	c.RegisterEncoding("deflate", func(w io.Writer) (io.WriteCloser, error) {
+		return zlib.NewWriter(w), nil
+	}, func(r io.Reader) (io.ReadCloser, error) {
+		return zlib.NewReader(r)
+	})
+	return c
+}
+
+// RegisterEncoding provides a method that registers the encoder and the decoder of a content coding.
+// Registering a coding again replaces it, but keeps its preference. A nil encoder or decoder disables the coding for responses or request bodies.
+// If the Accept-Encoding header accepts multiple codings with the same quality, the coding registered first is preferred.
+//
+// Example:
+//  compression := procroute.NewCompression().RegisterEncoding("gzip", func(w io.Writer) (io.WriteCloser, error) {
+//  	return gzip.NewWriterLevel(w, gzip.BestSpeed)
+//  }, func(r io.Reader) (io.ReadCloser, error) {
+//  	return gzip.NewReader(r)
+//  })
+func (c *Compression) RegisterEncoding(coding string, encoder ContentEncoder, decoder ContentDecoder) *Compression {
+	coding = strings.ToLower(strings.TrimSpace(coding))
+	if _, ok := c.encoders[coding]; !ok {
+		c.codings = append(c.codings, coding)
+	}
+	c.encoders[coding], c.decoders[coding] = encoder, decoder
+	return c
+}
+
+// SetMinSize provides a method that sets the minimum size in bytes of compressed response bodies, smaller bodies are sent uncompressed.
+func (c *Compression) SetMinSize(size int) *Compression {
+	c.minSize = size
+	return c
+}
+
+// SetMaxDecompressedSize provides a method that limits the size of decompressed request bodies, so that small compressed bodies can not exhaust the memory.
+// Requests exceeding the limit are answered with 413 Request Entity Too Large. A size less than or equal to zero disables the limit.
+func (c *Compression) SetMaxDecompressedSize(size int64) *Compression {
+	c.maxDecompressedSize = size
+	return c
+}
+
+// ExcludeTypes provides a method that excludes responses of the passed media types from the compression.
+// Media ranges like "image/*" exclude all subtypes of the type.
+func (c *Compression) ExcludeTypes(mediaTypes ...string) *Compression {
+	for _, mt := range mediaTypes {
+		c.excludedTypes = append(c.excludedTypes, strings.ToLower(strings.TrimSpace(mt)))
+	}
+	return c
+}
+
+// SetCompression provides a method that compresses the responses and decompresses the request bodies of the route set.
+// A nil compression inherits the compression of the route machine.
+//
+// Example:
+//  rs := procroute.NewRouteSet("/api", &parsers.JsonParser{}).
+//  	SetCompression(procroute.NewCompression().SetMinSize(512).ExcludeTypes("application/pdf"))
+func (rs *RouteSet) SetCompression(compression *Compression) *RouteSet {
+	rs.compression = compression
+	return rs
+}
+
+// withCompression provides a method that sets the compression of the route machine, which is used if the route set does not define its own
+func (rs *RouteSet) withCompression(compression *Compression) *RouteSet {
+	rs.defaultCompression = compression
+	return rs
+}
+
+// SetCompression provides a method that compresses the responses and decompresses the request bodies of all route sets that do not define their own compression.
+func (rm *RouteMachine) SetCompression(compression *Compression) *RouteMachine {
+	rm.compression = compression
+	for _, routeSet := range rm.routeSets {
+		routeSet.withCompression(compression)
+	}
+	return rm
+}
+
+// compressionFor returns the compression of the route set or the route machine, or nil if neither is set
+func (rs *RouteSet) compressionFor() *Compression {
+	if rs.compression != nil {
+		return rs.compression
+	}
+	return rs.defaultCompression
+}
+
+// withContentEncoding wraps the handler, so that compressed request bodies are decompressed and responses are compressed
+func (rs *RouteSet) withContentEncoding(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		compression := rs.compressionFor()
+		if compression == nil {
+			handler(w, r)
+			return
+		}
+
+		if httpErr := compression.decompress(r); httpErr != nil {
+			if httpErr.Status == http.StatusUnsupportedMediaType {
+				w.Header().Set("Accept-Encoding", strings.Join(compression.decodings(), ", "))
+			}
+			rs.writeError(w, r, httpErr)
+			return
+		}
+
+		compression.stripCodingETags(r.Header, "If-Match")
+		etagCoding := compression.stripCodingETags(r.Header, "If-None-Match")
+
+		cw := &compressResponseWriter{
+			ResponseWriter: w,
+			compression:    compression,
+			coding:         compression.encodingFor(r),
+			etagCoding:     etagCoding,
+			head:           r.Method == http.MethodHead,
+		}
+		handler(cw, r)
+		if err := cw.close(); err != nil {
+			rs.logger.Debug("failed to compress %s %s: %v", r.Method, r.URL.Path, err)
+		}
+	}
+}
+
+// encodingFor returns the content coding the Accept-Encoding header of the request prefers, or an empty string if the response must not be compressed
+func (c *Compression) encodingFor(r *http.Request) string {
+	qualities, wildcard := map[string]float64{}, 0.0
+	for _, part := range strings.Split(strings.Join(r.Header.Values("Accept-Encoding"), ","), ",") {
+		params := strings.Split(part, ";")
+		coding := strings.ToLower(strings.TrimSpace(params[0]))
+		if coding == "" {
+			continue
+		}
+
+		quality := 1.0
+		for _, param := range params[1:] {
+			if name, value, ok := strings.Cut(param, "="); ok && strings.EqualFold(strings.TrimSpace(name), "q") {
+				if parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+					quality = parsed
+				}
+			}
+		}
+		if coding == "*" {
+			wildcard = quality
+			continue
+		}
+		qualities[coding] = quality
+	}
+
+	best, bestQuality := "", 0.0
+	for _, coding := range c.codings {
+		if c.encoders[coding] == nil {
+			continue
+		}
+		quality, ok := qualities[coding]
+		if !ok {
+			quality = wildcard
+		}
+		if quality > bestQuality {
+			best, bestQuality = coding, quality
+		}
+	}
+	return best
+}
+
+// stripCodingETags removes the content coding suffixes from the entity tags of the conditional request header.
+// It returns the coding of the last suffix removed, or an empty string if there was none.
+func (c *Compression) stripCodingETags(header http.Header, name string) string {
+	value := header.Get(name)
+	if value == "" || strings.TrimSpace(value) == "*" {
+		return ""
+	}
+
+	stripped := ""
+	tags := parseETags(value)
+	for i, tag := range tags {
+		for _, coding := range c.codings {
+			if suffix := "-" + coding + `"`; strings.HasSuffix(tag, suffix) {
+				tags[i], stripped = strings.TrimSuffix(tag, suffix)+`"`, coding
+				break
+			}
+		}
+	}
+	if stripped != "" {
+		header.Set(name, strings.Join(tags, ", "))
+	}
+	return stripped
+}
+
+// codingETag returns the entity tag of the representation compressed by the coding
+func codingETag(etag, coding string) string {
+	if !strings.HasSuffix(etag, `"`) {
+		return etag
+	}
+	return strings.TrimSuffix(etag, `"`) + "-" + coding + `"`
+}
+
+// decodings returns the content codings supported for request bodies
+func (c *Compression) decodings() []string {
+	codings := []string{}
+	for _, coding := range c.codings {
+		if c.decoders[coding] != nil {
+			codings = append(codings, coding)
+		}
+	}
+	return codings
+}
+
+// excluded reports whether responses of the content type must not be compressed
+func (c *Compression) excluded(contentType string) bool {
+	if contentType == "" {
+		return false
+	}
+	mt := mediaType(contentType)
+	for _, excluded := range c.excludedTypes {
+		if mediaRangeMatches(excluded, mt) {
+			return true
+		}
+	}
+	return false
+}
+
+// decompress replaces the request body by a reader that decompresses it by the content codings of the Content-Encoding header.
+// Codings that are not supported are answered with 415 Unsupported Media Type, bodies that are not compressed by the coding with 400 Bad Request.
+func (c *Compression) decompress(r *http.Request) *HttpError {
+	codings := []string{}
+	for _, value := range r.Header.Values("Content-Encoding") {
+		for _, coding := range strings.Split(value, ",") {
+			coding = strings.ToLower(strings.TrimSpace(coding))
+			if coding == "" || coding == "identity" {
+				continue
+			}
+			if c.decoders[coding] == nil {
+				return &HttpError{
+					Status:    http.StatusUnsupportedMediaType,
+					ErrorCode: "",
+					Message:   "unsupported content encoding: " + coding + ", supported: " + strings.Join(c.decodings(), ", "),
+				}
+			}
+			codings = append(codings, coding)
+		}
+	}
+	if len(codings) == 0 || r.Body == nil || r.Body == http.NoBody {
+		return nil
+	}
+
+	body := &decompressedBody{body: r.Body}
+	reader := io.Reader(r.Body)
+	// the codings are listed in the order they were applied, so they are removed in reverse order
+	for i := len(codings) - 1; i >= 0; i-- {
+		decoder, err := c.decoders[codings[i]](reader)
+		if err != nil {
+			body.Close()
+			return BadRequest(fmt.Sprintf("the request body is not compressed by %s: %v", codings[i], err)).WithCause(err)
+		}
+		body.decoders = append(body.decoders, decoder)
+		reader = decoder
+	}
+	body.reader = &bodyReader{r: reader, limit: c.maxDecompressedSize}
+
+	// the decompressed body is passed on as if it was sent uncompressed
+	r.Header.Del("Content-Encoding")
+	r.Header.Del("Content-Length")
+	r.Body, r.ContentLength = body, -1
+	return nil
+}
+
+// decompressedBody reads the decompressed request body and remembers why reading it failed
+type decompressedBody struct {
+	body     io.ReadCloser
+	decoders []io.ReadCloser
+	reader   *bodyReader
+	invalid  error
+}
+
+// Read reads the decompressed request body up to the maximum decompressed size
+func (d *decompressedBody) Read(p []byte) (int, error) {
+	n, err := d.reader.Read(p)
+	if err != nil && err != io.EOF && !d.reader.exceeded {
+		d.invalid = err
+	}
+	return n, err
+}
+
+// Close closes the decoders and the request body
+func (d *decompressedBody) Close() error {
+	for _, decoder := range d.decoders {
+		decoder.Close()
+	}
+	return d.body.Close()
+}
+
+// decompressionError returns the error sent to the client, if reading the decompressed request body failed, or nil if the body was not decompressed
+func decompressionError(r *http.Request) *HttpError {
+	body, ok := r.Body.(*decompressedBody)
+	switch {
+	case !ok:
+		return nil
+	case body.reader.exceeded:
+		return &HttpError{
+			Status:    http.StatusRequestEntityTooLarge,
+			ErrorCode: "",
+			Message:   fmt.Sprintf("decompressed request body exceeds the maximum size of %d bytes", body.reader.limit),
+		}
+	case body.invalid != nil:
+		return BadRequest("invalid compressed request body: " + body.invalid.Error()).WithCause(body.invalid)
+	}
+	return nil
+}
+
+// compressResponseWriter buffers the beginning of the response body, until it is known whether the body is large enough to be compressed.
+// Responses are compressed, if the body reaches the minimum size or is flushed, and its content type is not excluded.
+type compressResponseWriter struct {
+	http.ResponseWriter
+	compression *Compression
+	// coding is the negotiated content coding, which is empty if the client does not accept any
+	coding string
+	// etagCoding is the coding of the entity tags of the If-None-Match header, which is kept in the entity tag of 304 Not Modified responses
+	etagCoding string
+	// head is set for HEAD requests, which receive the headers of the compressed response without a body
+	head bool
+
+	status  int
+	buf     []byte
+	started bool
+	encoder io.WriteCloser
+}
+
+// WriteHeader delays writing the status until it is known whether the response is compressed
+func (c *compressResponseWriter) WriteHeader(status int) {
+	switch {
+	case c.started:
+		c.ResponseWriter.WriteHeader(status)
+	case status >= 100 && status < 200:
+		// informational responses do not end the headers of the final response
+		c.ResponseWriter.WriteHeader(status)
+	case c.status == 0:
+		c.status = status
+		if !bodyAllowed(status) {
+			c.start(false)
+		}
+	}
+}
+
+// Write buffers the body until it reaches the minimum size, afterwards it is written compressed or uncompressed
+func (c *compressResponseWriter) Write(b []byte) (int, error) {
+	if c.started {
+		if c.encoder != nil {
+			return c.encoder.Write(b)
+		}
+		return c.ResponseWriter.Write(b)
+	}
+
+	c.buf = append(c.buf, b...)
+	if len(c.buf) < c.compression.minSize {
+		return len(b), nil
+	}
+	return len(b), c.start(true)
+}
+
+// Flush starts the response regardless of its size, since a flushed response is streamed, and flushes the encoder and the underlying response writer
+func (c *compressResponseWriter) Flush() {
+	if !c.started {
+		c.start(len(c.buf) > 0 || c.Header().Get("Content-Type") != "")
+	}
+	if flusher, ok := c.encoder.(interface{ Flush() error }); ok {
+		flusher.Flush()
+	}
+	if flusher, ok := c.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap returns the underlying response writer, which is used by http.ResponseController
+func (c *compressResponseWriter) Unwrap() http.ResponseWriter {
+	return c.ResponseWriter
+}
+
+// start writes the headers and the buffered body. The response is compressed, if compress is set and the response is eligible.
+func (c *compressResponseWriter) start(compress bool) error {
+	c.started = true
+	if c.status == 0 {
+		c.status = http.StatusOK
+	}
+
+	header := c.Header()
+	if etag := header.Get("ETag"); etag != "" && c.status == http.StatusNotModified && c.etagCoding != "" {
+		header.Set("ETag", codingETag(etag, c.etagCoding))
+	}
+	if compress && bodyAllowed(c.status) && header.Get("Content-Encoding") == "" && !c.compression.excluded(header.Get("Content-Type")) {
+		addVary(header, "Accept-Encoding")
+		if c.coding != "" {
+			var encoder io.WriteCloser
+			var err error
+			if !c.head {
+				encoder, err = c.compression.encoders[c.coding](c.ResponseWriter)
+			}
+			if err == nil {
+				// the content type can not be detected from the compressed body
+				if _, ok := header["Content-Type"]; !ok && len(c.buf) > 0 {
+					header.Set("Content-Type", http.DetectContentType(c.buf))
+				}
+				if etag := header.Get("ETag"); etag != "" {
+					header.Set("ETag", codingETag(etag, c.coding))
+				}
+				header.Set("Content-Encoding", c.coding)
+				header.Del("Content-Length")
+				c.encoder = encoder
+			}
+		}
+	}
+	c.ResponseWriter.WriteHeader(c.status)
+
+	if len(c.buf) == 0 {
+		return nil
+	}
+	buf := c.buf
+	c.buf = nil
+	_, err := c.Write(buf)
+	return err
+}
+
+// compressible reports whether the body reaches the minimum size
+func (c *compressResponseWriter) compressible() bool {
+	size := len(c.buf)
+	if c.head {
+		// get routes discard the body of HEAD requests, but send the Content-Length of the uncompressed body
+		if length, err := strconv.Atoi(c.Header().Get("Content-Length")); err == nil {
+			size = length
+		} else if size == 0 {
+			// bodies of unknown length are streamed, so they are compressed like flushed responses
+			return c.Header().Get("Content-Type") != ""
+		}
+	}
+	return size > 0 && size >= c.compression.minSize
+}
+
+// close starts the response, if the body has not reached the minimum size, and completes the compressed body
+func (c *compressResponseWriter) close() error {
+	if !c.started {
+		if err := c.start(c.compressible()); err != nil {
+			return err
+		}
+	}
+	if c.encoder != nil {
+		return c.encoder.Close()
+	}
+	return nil
+}
@@ -0,0 +1,434 @@
+package procroute
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// compress compresses the data by the content coding
+func compress(t *testing.T, coding string, data []byte) []byte {
+	t.Helper()
+
+	buf := &bytes.Buffer{}
+	var w io.WriteCloser
+	switch coding {
+	case "gzip":
+		w = gzip.NewWriter(buf)
+	case "deflate":
+		w = zlib.NewWriter(buf)
+	default:
+		return data
+	}
+	if _, err := w.Write(data); err != nil {
+		t.Fatalf("failed to compress: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("failed to compress: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// decompress decompresses the data by the content coding
+func decompress(t *testing.T, coding string, data []byte) []byte {
+	t.Helper()
+
+	var r io.Reader
+	var err error
+	switch coding {
+	case "gzip":
+		r, err = gzip.NewReader(bytes.NewReader(data))
+	case "deflate":
+		r, err = zlib.NewReader(bytes.NewReader(data))
+	default:
+		return data
+	}
+	if err != nil {
+		t.Fatalf("failed to decompress: %v", err)
+	}
+	bts, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("failed to decompress: %v", err)
+	}
+	return bts
+}
+
+func TestRouteSet_compression(t *testing.T) {
+	large := strings.Repeat("a", DefaultCompressionMinSize)
+
+	tests := []struct {
+		name               string
+		compression        *Compression
+		machineCompression *Compression
+		method             string
+		acceptEncoding     string
+		body               string
+		wantEncoding       string
+		wantVary           bool
+	}{
+		{
+			name:           "gzip",
+			compression:    NewCompression(),
+			acceptEncoding: "gzip, deflate",
+			body:           large,
+			wantEncoding:   "gzip",
+			wantVary:       true,
+		},
+		{
+			name:           "deflate_preferred_by_quality",
+			compression:    NewCompression(),
+			acceptEncoding: "gzip;q=0.5, deflate",
+			body:           large,
+			wantEncoding:   "deflate",
+			wantVary:       true,
+		},
+		{
+			name:           "wildcard",
+			compression:    NewCompression(),
+			acceptEncoding: "*",
+			body:           large,
+			wantEncoding:   "gzip",
+			wantVary:       true,
+		},
+		{
+			name:           "not_accepted",
+			compression:    NewCompression(),
+			acceptEncoding: "gzip;q=0, br",
+			body:           large,
+			wantVary:       true,
+		},
+		{
+			name:        "no_accept_encoding",
+			compression: NewCompression(),
+			body:        large,
+			wantVary:    true,
+		},
+		{
+			name:           "below_min_size",
+			compression:    NewCompression(),
+			acceptEncoding: "gzip",
+			body:           "small",
+		},
+		{
+			name:           "min_size",
+			compression:    NewCompression().SetMinSize(1),
+			acceptEncoding: "gzip",
+			body:           "small",
+			wantEncoding:   "gzip",
+			wantVary:       true,
+		},
+		{
+			name:           "excluded_type",
+			compression:    NewCompression().ExcludeTypes("application/*"),
+			acceptEncoding: "gzip",
+			body:           large,
+		},
+		{
+			name:           "head",
+			compression:    NewCompression(),
+			method:         "HEAD",
+			acceptEncoding: "gzip",
+			body:           large,
+			wantEncoding:   "gzip",
+			wantVary:       true,
+		},
+		{
+			name:           "head_below_min_size",
+			compression:    NewCompression(),
+			method:         "HEAD",
+			acceptEncoding: "gzip",
+			body:           "small",
+		},
+		{
+			name:               "route_machine",
+			machineCompression: NewCompression(),
+			acceptEncoding:     "gzip",
+			body:               large,
+			wantEncoding:       "gzip",
+			wantVary:           true,
+		},
+		{
+			name:           "disabled",
+			acceptEncoding: "gzip",
+			body:           large,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rm := NewRouteMachine("", 0, "/api", &exampleLogger{}).SetCompression(tt.machineCompression)
+			rs := NewRouteSet("/sample", &exampleParser{}).SetCompression(tt.compression)
+			Get(rs, "", func(ctx context.Context, _ struct{}) (string, error) {
+				return tt.body, nil
+			})
+			if err := rm.AddRouteSet(rs); err != nil {
+				t.Fatalf("RouteMachine.AddRouteSet() error = %v", err)
+			}
+
+			method := tt.method
+			if method == "" {
+				method = "GET"
+			}
+			r := httptest.NewRequest(method, "/api/sample", nil)
+			if tt.acceptEncoding != "" {
+				r.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			w := httptest.NewRecorder()
+			rm.ServeHTTP(w, r)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %v, want %v, body = %s", w.Code, http.StatusOK, w.Body.String())
+			}
+			if got := w.Header().Get("Content-Encoding"); got != tt.wantEncoding {
+				t.Errorf("Content-Encoding = %q, want %q", got, tt.wantEncoding)
+			}
+			if got := strings.Contains(strings.Join(w.Header().Values("Vary"), ","), "Accept-Encoding"); got != tt.wantVary {
+				t.Errorf("Vary = %v, want Accept-Encoding %v", w.Header().Values("Vary"), tt.wantVary)
+			}
+			if got := w.Header().Get("Content-Type"); got != "application/json" {
+				t.Errorf("Content-Type = %q, want application/json", got)
+			}
+			if method == "HEAD" {
+				// the Content-Length of the uncompressed body does not apply to the compressed one
+				if got := w.Header().Get("Content-Length"); (got == "") != (tt.wantEncoding != "") {
+					t.Errorf("Content-Length = %q, want it only for uncompressed responses", got)
+				}
+				if w.Body.Len() != 0 {
+					t.Errorf("body = %s, want empty body", w.Body.String())
+				}
+				return
+			}
+
+			var got string
+			if err := json.Unmarshal(decompress(t, tt.wantEncoding, w.Body.Bytes()), &got); err != nil {
+				t.Fatalf("failed to decode the response: %v", err)
+			}
+			if got != tt.body {
+				t.Errorf("body = %q, want %q", got, tt.body)
+			}
+		})
+	}
+}
+
+func TestRouteSet_compression_stream(t *testing.T) {
+	rm := NewRouteMachine("", 0, "/api", &exampleLogger{})
+	rs := NewRouteSet("/sample", &exampleParser{}).SetCompression(NewCompression()).AddRoutes(&streamExample{items: []interface{}{1, 2}})
+	if err := rm.AddRouteSet(rs); err != nil {
+		t.Fatalf("RouteMachine.AddRouteSet() error = %v", err)
+	}
+
+	r := httptest.NewRequest("GET", "/api/sample", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	rm.ServeHTTP(w, r)
+
+	// streamed responses are compressed regardless of the minimum size, since their size is not known in advance
+	if got := w.Header().Get("Content-Encoding"); got != "gzip" {
+		t.Fatalf("Content-Encoding = %q, want gzip", got)
+	}
+	if !w.Flushed {
+		t.Errorf("response was not flushed")
+	}
+	if got := string(decompress(t, "gzip", w.Body.Bytes())); got != "[1,2]" {
+		t.Errorf("body = %s, want [1,2]", got)
+	}
+}
+
+func TestRouteSet_compression_etag(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		acceptEncoding string
+		headers        map[string]string
+		wantStatus     int
+		wantETag       string
+		wantCalled     bool
+	}{
+		{
+			name:           "compressed",
+			method:         "GET",
+			acceptEncoding: "gzip",
+			wantStatus:     http.StatusOK,
+			wantETag:       `"v1-gzip"`,
+		},
+		{
+			name:           "head_compressed",
+			method:         "HEAD",
+			acceptEncoding: "gzip",
+			wantStatus:     http.StatusOK,
+			wantETag:       `"v1-gzip"`,
+		},
+		{
+			name:       "uncompressed",
+			method:     "GET",
+			wantStatus: http.StatusOK,
+			wantETag:   `"v1"`,
+		},
+		{
+			name:           "if_none_match_compressed",
+			method:         "GET",
+			acceptEncoding: "gzip",
+			headers:        map[string]string{"If-None-Match": `"v1-gzip"`},
+			wantStatus:     http.StatusNotModified,
+			wantETag:       `"v1-gzip"`,
+		},
+		{
+			name:           "if_none_match_other_coding",
+			method:         "GET",
+			acceptEncoding: "deflate",
+			headers:        map[string]string{"If-None-Match": `"v0-gzip", "v1-deflate"`},
+			wantStatus:     http.StatusNotModified,
+			wantETag:       `"v1-deflate"`,
+		},
+		{
+			name:       "if_match_compressed",
+			method:     "PUT",
+			headers:    map[string]string{"If-Match": `"v1-gzip"`},
+			wantStatus: http.StatusOK,
+			wantCalled: true,
+		},
+		{
+			name:       "if_match_outdated",
+			method:     "PUT",
+			headers:    map[string]string{"If-Match": `"v0-gzip"`},
+			wantStatus: http.StatusPreconditionFailed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			route := &conditionalExample{data: data{Name: strings.Repeat("a", 100)}, etag: "v1"}
+			rm := NewRouteMachine("", 0, "/api", &exampleLogger{})
+			if err := rm.AddRouteSet(NewRouteSet("/sample", &exampleParser{}).SetCompression(NewCompression().SetMinSize(1)).AddRoutes(route)); err != nil {
+				t.Fatalf("RouteMachine.AddRouteSet() error = %v", err)
+			}
+
+			r := httptest.NewRequest(tt.method, "/api/sample", strings.NewReader(`{}`))
+			if tt.acceptEncoding != "" {
+				r.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			for key, value := range tt.headers {
+				r.Header.Set(key, value)
+			}
+			w := httptest.NewRecorder()
+			rm.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %v, want %v, body = %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if got := w.Header().Get("ETag"); tt.wantETag != "" && got != tt.wantETag {
+				t.Errorf("ETag = %v, want %v", got, tt.wantETag)
+			}
+			if route.called != tt.wantCalled {
+				t.Errorf("called = %v, want %v", route.called, tt.wantCalled)
+			}
+		})
+	}
+}
+
+func TestRouteSet_decompression(t *testing.T) {
+	body := []byte(`{"name":"` + strings.Repeat("a", 100) + `"}`)
+
+	tests := []struct {
+		name               string
+		compression        *Compression
+		contentEncoding    string
+		body               []byte
+		wantStatus         int
+		wantAcceptEncoding string
+	}{
+		{
+			name:            "gzip",
+			compression:     NewCompression(),
+			contentEncoding: "gzip",
+			body:            compress(t, "gzip", body),
+			wantStatus:      http.StatusCreated,
+		},
+		{
+			name:            "deflate",
+			compression:     NewCompression(),
+			contentEncoding: "deflate",
+			body:            compress(t, "deflate", body),
+			wantStatus:      http.StatusCreated,
+		},
+		{
+			name:            "stacked",
+			compression:     NewCompression(),
+			contentEncoding: "deflate, gzip",
+			body:            compress(t, "gzip", compress(t, "deflate", body)),
+			wantStatus:      http.StatusCreated,
+		},
+		{
+			name:            "identity",
+			compression:     NewCompression(),
+			contentEncoding: "identity",
+			body:            body,
+			wantStatus:      http.StatusCreated,
+		},
+		{
+			name:               "unsupported",
+			compression:        NewCompression(),
+			contentEncoding:    "br",
+			body:               body,
+			wantStatus:         http.StatusUnsupportedMediaType,
+			wantAcceptEncoding: "gzip, deflate",
+		},
+		{
+			name:            "invalid",
+			compression:     NewCompression(),
+			contentEncoding: "gzip",
+			body:            body,
+			wantStatus:      http.StatusBadRequest,
+		},
+		{
+			name:            "corrupted",
+			compression:     NewCompression(),
+			contentEncoding: "gzip",
+			body:            append(compress(t, "gzip", body)[:20:20], 0xff, 0xff, 0xff),
+			wantStatus:      http.StatusBadRequest,
+		},
+		{
+			name:            "exceeds_decompressed_size",
+			compression:     NewCompression().SetMaxDecompressedSize(64),
+			contentEncoding: "gzip",
+			body:            compress(t, "gzip", body),
+			wantStatus:      http.StatusRequestEntityTooLarge,
+		},
+	}
+	for _, tt := range tests {
+		for name, parser := range map[string]Parser{"buffered": &exampleParser{}, "streamed": &exampleStreamParser{}} {
+			t.Run(tt.name+"/"+name, func(t *testing.T) {
+				rm := NewRouteMachine("", 0, "/api", &exampleLogger{})
+				rs := NewRouteSet("/sample", parser).SetCompression(tt.compression)
+				var received map[string]string
+				Post(rs, "", func(ctx context.Context, req map[string]string) (struct{}, error) {
+					received = req
+					return struct{}{}, nil
+				})
+				if err := rm.AddRouteSet(rs); err != nil {
+					t.Fatalf("RouteMachine.AddRouteSet() error = %v", err)
+				}
+
+				r := httptest.NewRequest("POST", "/api/sample", bytes.NewReader(tt.body))
+				r.Header.Set("Content-Encoding", tt.contentEncoding)
+				w := httptest.NewRecorder()
+				rm.ServeHTTP(w, r)
+
+				if w.Code != tt.wantStatus {
+					t.Fatalf("status = %v, want %v, body = %s", w.Code, tt.wantStatus, w.Body.String())
+				}
+				if got := w.Header().Get("Accept-Encoding"); got != tt.wantAcceptEncoding {
+					t.Errorf("Accept-Encoding = %q, want %q", got, tt.wantAcceptEncoding)
+				}
+				if tt.wantStatus == http.StatusCreated && received["name"] != strings.Repeat("a", 100) {
+					t.Errorf("received = %v, want the decompressed body", received)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,243 @@
+package procroute
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ETagger provides an optional interface for values returned by get routes and for route controllers, which defines the entity tag of a resource.
+// Get and get all routes send it as ETag header and answer matching If-None-Match headers with 304 Not Modified.
+// Update, delete and patch routes compare it with the If-Match header of the request before the route is called and answer mismatches with 412 Precondition Failed.
+// Returned values are preferred over the route controller, which can use the url params to identify the resource.
+type ETagger interface {
+	// ETag returns the entity tag of the resource. Tags without quotes are quoted, tags starting with W/ are weak.
+	// An empty tag means that the resource has no entity tag.
+	//
+	// Example:
+	//  func (m *MyModel) ETag() string {
+	//  	return strconv.Itoa(m.Version)
+	//  }
+	ETag() string
+}
+
+// LastModifier provides an optional interface for values returned by get routes and for route controllers, which defines the modification time of a resource.
+// It is sent as Last-Modified header and compared with the If-Modified-Since and If-Unmodified-Since headers like the entity tag of the ETagger interface.
+type LastModifier interface {
+	// LastModified returns the time the resource was modified last. The zero time means that the modification time is unknown.
+	//
+	// Example:
+	//  func (m *MyModel) LastModified() time.Time {
+	//  	return m.UpdatedAt
+	//  }
+	LastModified() time.Time
+}
+
+// SetWeakETags provides a method that enables weak entity tags derived from a hash of the encoded response body.
+// They are sent by get and get all routes, if neither the returned value nor the route controller implement the ETagger interface.
+func (rs *RouteSet) SetWeakETags(enabled bool) *RouteSet {
+	rs.weakETags = enabled
+	return rs
+}
+
+// SetPreconditionRequired provides a method that requires update, delete and patch requests to send an If-Match or If-Unmodified-Since header,
+// if the route controller defines an entity tag or a modification time by the ETagger or LastModifier interface. Requests without them are answered with 428 Precondition Required.
+func (rs *RouteSet) SetPreconditionRequired(required bool) *RouteSet {
+	rs.preconditionRequired = required
+	return rs
+}
+
+// encodedBody is a response body that has already been encoded by the parser, e.g. to derive a weak entity tag from it
+type encodedBody struct {
+	parser Parser
+	bts    []byte
+}
+
+// validators returns the entity tag and the modification time of the first value implementing the ETagger and LastModifier interface
+func validators(values ...interface{}) (string, time.Time) {
+	var etag string
+	var lastModified time.Time
+	for _, value := range values {
+		if e, ok := value.(ETagger); ok && etag == "" {
+			etag = formatETag(e.ETag())
+		}
+		if l, ok := value.(LastModifier); ok && lastModified.IsZero() {
+			lastModified = l.LastModified()
+		}
+	}
+	return etag, lastModified
+}
+
+// formatETag quotes the entity tag, unless it is already quoted
+func formatETag(etag string) string {
+	if etag == "" || strings.HasPrefix(etag, `"`) || strings.HasPrefix(etag, `W/"`) {
+		return etag
+	}
+	return `"` + etag + `"`
+}
+
+// weakETag returns a weak entity tag derived from the hash of the encoded body
+func weakETag(bts []byte) string {
+	sum := sha256.Sum256(bts)
+	return fmt.Sprintf(`W/"%x"`, sum[:16])
+}
+
+// writeValidated writes the selected data like writeData, but sends the ETag and Last-Modified headers of the data or the route controller
+// and answers requests whose preconditions are not met with 304 Not Modified or 412 Precondition Failed.
+// The data is the value returned by the route, the selected data is written and may be reduced to the fields selected by the request.
+func (rs *RouteSet) writeValidated(w http.ResponseWriter, r *http.Request, rt interface{}, data interface{}, selected interface{}) {
+	// the projection of the selected fields does not implement the ETagger and LastModifier interface of the returned value
+	source := data
+	if resp, ok := asResponse(data); ok {
+		source = resp.Body
+	}
+	etag, lastModified := validators(source, rt)
+
+	resp, isResponse := asResponse(selected)
+	body, headers := selected, http.Header{}
+	if isResponse {
+		body, headers = resp.Body, resp.Headers
+	}
+	if etag == "" {
+		etag = headers.Get("ETag")
+	}
+	if etag == "" && rs.weakETags && body != nil {
+		parser, err := rs.encoderFor(r)
+		if err != nil {
+			rs.writeError(w, r, err)
+			return
+		}
+		bts, err := rs.marshal(parser, body)
+		if err != nil {
+			rs.writeError(w, r, err)
+			return
+		}
+
+		// the encoded body is written as it is, so that it is not encoded twice
+		etag, selected = weakETag(bts), &encodedBody{parser: parser, bts: bts}
+		if isResponse {
+			encoded := *resp
+			encoded.Body, selected = selected, &encoded
+		}
+	}
+
+	// responses without validators are not conditional
+	if etag == "" && lastModified.IsZero() {
+		rs.writeData(w, r, http.StatusOK, selected)
+		return
+	}
+
+	if etag != "" {
+		w.Header().Set("ETag", etag)
+	}
+	if !lastModified.IsZero() {
+		w.Header().Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	switch status := evaluatePreconditions(r, etag, lastModified); status {
+	case http.StatusNotModified:
+		varyAccept(w)
+		applyHeaders(w, headers)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	case http.StatusPreconditionFailed:
+		rs.writeError(w, r, PreconditionFailed("the preconditions of the request are not met"))
+		return
+	}
+
+	rs.writeData(w, r, http.StatusOK, selected)
+}
+
+// checkPreconditions compares the If-Match and If-Unmodified-Since headers of update, delete and patch requests with the entity tag
+// and the modification time of the passed values. Requests are not checked, if none of the values defines an entity tag or a modification time.
+func (rs *RouteSet) checkPreconditions(r *http.Request, values ...interface{}) *HttpError {
+	etag, lastModified := validators(values...)
+	if etag == "" && lastModified.IsZero() {
+		return nil
+	}
+
+	if rs.preconditionRequired && r.Header.Get("If-Match") == "" && r.Header.Get("If-Unmodified-Since") == "" {
+		return PreconditionRequired("the request must be conditional, send an If-Match or If-Unmodified-Since header")
+	}
+	if evaluatePreconditions(r, etag, lastModified) != 0 {
+		return PreconditionFailed("the preconditions of the request are not met")
+	}
+	return nil
+}
+
+// evaluatePreconditions evaluates the conditional headers of the request in the order of RFC 7232 against the current entity tag and modification time.
+// It returns 304 Not Modified or 412 Precondition Failed, if a precondition is not met, otherwise zero.
+func evaluatePreconditions(r *http.Request, etag string, lastModified time.Time) int {
+	// http dates have a resolution of seconds
+	lastModified = lastModified.Truncate(time.Second)
+	safe := r.Method == http.MethodGet || r.Method == http.MethodHead
+
+	if ifMatch := r.Header.Get("If-Match"); ifMatch != "" {
+		if !matchETag(ifMatch, etag, false) {
+			return http.StatusPreconditionFailed
+		}
+	} else if since, err := http.ParseTime(r.Header.Get("If-Unmodified-Since")); err == nil && !lastModified.IsZero() {
+		if lastModified.After(since) {
+			return http.StatusPreconditionFailed
+		}
+	}
+
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		if matchETag(ifNoneMatch, etag, true) {
+			if safe {
+				return http.StatusNotModified
+			}
+			return http.StatusPreconditionFailed
+		}
+	} else if since, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err == nil && safe && !lastModified.IsZero() {
+		if !lastModified.After(since) {
+			return http.StatusNotModified
+		}
+	}
+	return 0
+}
+
+// matchETag reports whether the list of entity tags of a conditional header matches the entity tag.
+// The weak comparison ignores the weakness of the tags, the strong comparison never matches weak tags.
+func matchETag(header, etag string, weak bool) bool {
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+	if etag == "" || (!weak && strings.HasPrefix(etag, "W/")) {
+		return false
+	}
+
+	opaque := strings.TrimPrefix(etag, "W/")
+	for _, tag := range parseETags(header) {
+		if !weak && strings.HasPrefix(tag, "W/") {
+			continue
+		}
+		if strings.TrimPrefix(tag, "W/") == opaque {
+			return true
+		}
+	}
+	return false
+}
+
+// parseETags splits the comma separated list of entity tags of a conditional header.
+// Entity tags may contain commas, so the list is split outside of quotes only.
+func parseETags(header string) []string {
+	tags := []string{}
+	for header != "" {
+		header = strings.TrimLeft(header, " \t,")
+		start := strings.IndexByte(header, '"')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(header[start+1:], '"')
+		if end < 0 {
+			break
+		}
+		end += start + 2
+		tags = append(tags, header[:end])
+		header = header[end:]
+	}
+	return tags
+}
@@ -0,0 +1,293 @@
+package procroute
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRouteSet_conditionalGet(t *testing.T) {
+	modified := time.Date(2022, 3, 4, 5, 6, 7, 0, time.UTC)
+	model := conditionalModel{Name: "sample", version: "v2", modified: modified}
+
+	tests := []struct {
+		name             string
+		route            *conditionalExample
+		weakETags        bool
+		query            string
+		headers          map[string]string
+		wantStatus       int
+		wantETag         string
+		wantLastModified string
+	}{
+		{
+			name:             "validators_of_value",
+			route:            &conditionalExample{data: model, etag: "controller"},
+			wantStatus:       http.StatusOK,
+			wantETag:         `"v2"`,
+			wantLastModified: "Fri, 04 Mar 2022 05:06:07 GMT",
+		},
+		{
+			name:       "validators_of_controller",
+			route:      &conditionalExample{data: data{Name: "sample"}, etag: `W/"controller"`},
+			wantStatus: http.StatusOK,
+			wantETag:   `W/"controller"`,
+		},
+		{
+			name:             "if_none_match",
+			route:            &conditionalExample{data: model},
+			headers:          map[string]string{"If-None-Match": `"v1", W/"v2"`},
+			wantStatus:       http.StatusNotModified,
+			wantETag:         `"v2"`,
+			wantLastModified: "Fri, 04 Mar 2022 05:06:07 GMT",
+		},
+		{
+			name:       "if_none_match_mismatch",
+			route:      &conditionalExample{data: model},
+			headers:    map[string]string{"If-None-Match": `"v1"`, "If-Modified-Since": "Fri, 04 Mar 2022 05:06:07 GMT"},
+			wantStatus: http.StatusOK,
+			wantETag:   `"v2"`,
+		},
+		{
+			name:       "if_modified_since_not_modified",
+			route:      &conditionalExample{data: model},
+			headers:    map[string]string{"If-Modified-Since": "Fri, 04 Mar 2022 05:06:07 GMT"},
+			wantStatus: http.StatusNotModified,
+			wantETag:   `"v2"`,
+		},
+		{
+			name:       "if_modified_since_modified",
+			route:      &conditionalExample{data: model},
+			headers:    map[string]string{"If-Modified-Since": "Fri, 04 Mar 2022 05:06:06 GMT"},
+			wantStatus: http.StatusOK,
+			wantETag:   `"v2"`,
+		},
+		{
+			name:             "selected_fields",
+			route:            &conditionalExample{data: model},
+			query:            "?fields=name",
+			wantStatus:       http.StatusOK,
+			wantETag:         `"v2"`,
+			wantLastModified: "Fri, 04 Mar 2022 05:06:07 GMT",
+		},
+		{
+			name:       "selected_fields_if_none_match",
+			route:      &conditionalExample{data: &Response{Body: model}},
+			query:      "?fields=name",
+			headers:    map[string]string{"If-None-Match": `"v2"`},
+			wantStatus: http.StatusNotModified,
+			wantETag:   `"v2"`,
+		},
+		{
+			name:       "if_match_mismatch",
+			route:      &conditionalExample{data: model},
+			headers:    map[string]string{"If-Match": `"v1"`},
+			wantStatus: http.StatusPreconditionFailed,
+			wantETag:   `"v2"`,
+		},
+		{
+			name:       "without_validators",
+			route:      &conditionalExample{data: data{Name: "sample"}},
+			headers:    map[string]string{"If-None-Match": `*`},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "weak_etag",
+			route:      &conditionalExample{data: data{Name: "sample"}},
+			weakETags:  true,
+			wantStatus: http.StatusOK,
+			wantETag:   weakETag([]byte(`{"Name":"sample","Value":0}`)),
+		},
+		{
+			name:       "weak_etag_not_modified",
+			route:      &conditionalExample{data: &Response{Body: data{Name: "sample"}}},
+			weakETags:  true,
+			headers:    map[string]string{"If-None-Match": weakETag([]byte(`{"Name":"sample","Value":0}`))},
+			wantStatus: http.StatusNotModified,
+			wantETag:   weakETag([]byte(`{"Name":"sample","Value":0}`)),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rm := NewRouteMachine("", 0, "/api", &exampleLogger{})
+			if err := rm.AddRouteSet(NewRouteSet("/sample", &exampleParser{}).SetWeakETags(tt.weakETags).AddRoutes(tt.route)); err != nil {
+				t.Fatalf("RouteMachine.AddRouteSet() error = %v", err)
+			}
+
+			r := httptest.NewRequest("GET", "/api/sample"+tt.query, nil)
+			for key, value := range tt.headers {
+				r.Header.Set(key, value)
+			}
+			w := httptest.NewRecorder()
+			rm.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %v, want %v, body = %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if got := w.Header().Get("ETag"); got != tt.wantETag {
+				t.Errorf("ETag = %v, want %v", got, tt.wantETag)
+			}
+			if got := w.Header().Get("Last-Modified"); tt.wantLastModified != "" && got != tt.wantLastModified {
+				t.Errorf("Last-Modified = %v, want %v", got, tt.wantLastModified)
+			}
+			if tt.wantStatus == http.StatusNotModified && w.Body.Len() != 0 {
+				t.Errorf("body = %s, want empty body", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestRouteSet_conditionalModification(t *testing.T) {
+	modified := time.Date(2022, 3, 4, 5, 6, 7, 0, time.UTC)
+
+	tests := []struct {
+		name                 string
+		method               string
+		route                *conditionalExample
+		preconditionRequired bool
+		headers              map[string]string
+		wantStatus           int
+	}{
+		{
+			name:       "if_match",
+			method:     "PUT",
+			route:      &conditionalExample{etag: "v2"},
+			headers:    map[string]string{"If-Match": `"v1", "v2"`},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "if_match_mismatch",
+			method:     "PUT",
+			route:      &conditionalExample{etag: "v2"},
+			headers:    map[string]string{"If-Match": `"v1"`},
+			wantStatus: http.StatusPreconditionFailed,
+		},
+		{
+			name:       "if_match_weak_tag",
+			method:     "DELETE",
+			route:      &conditionalExample{etag: "v2"},
+			headers:    map[string]string{"If-Match": `W/"v2"`},
+			wantStatus: http.StatusPreconditionFailed,
+		},
+		{
+			name:       "if_match_any",
+			method:     "DELETE",
+			route:      &conditionalExample{etag: "v2"},
+			headers:    map[string]string{"If-Match": `*`},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "if_unmodified_since",
+			method:     "PUT",
+			route:      &conditionalExample{modified: modified},
+			headers:    map[string]string{"If-Unmodified-Since": "Fri, 04 Mar 2022 05:06:07 GMT"},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "if_unmodified_since_modified",
+			method:     "PUT",
+			route:      &conditionalExample{modified: modified},
+			headers:    map[string]string{"If-Unmodified-Since": "Fri, 04 Mar 2022 05:06:06 GMT"},
+			wantStatus: http.StatusPreconditionFailed,
+		},
+		{
+			name:       "if_none_match",
+			method:     "PUT",
+			route:      &conditionalExample{etag: "v2"},
+			headers:    map[string]string{"If-None-Match": `*`},
+			wantStatus: http.StatusPreconditionFailed,
+		},
+		{
+			name:       "patch_document_of_current_resource",
+			method:     "PATCH",
+			route:      &conditionalExample{data: conditionalModel{Name: "old", version: "v2"}, etag: "controller"},
+			headers:    map[string]string{"Content-Type": MergePatchMimeType, "If-Match": `"v2"`},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "patch_document_mismatch",
+			method:     "PATCH",
+			route:      &conditionalExample{data: conditionalModel{Name: "old", version: "v2"}},
+			headers:    map[string]string{"Content-Type": MergePatchMimeType, "If-Match": `"v1"`},
+			wantStatus: http.StatusPreconditionFailed,
+		},
+		{
+			name:       "patch",
+			method:     "PATCH",
+			route:      &conditionalExample{etag: "v2"},
+			headers:    map[string]string{"If-Match": `"v1"`},
+			wantStatus: http.StatusPreconditionFailed,
+		},
+		{
+			name:       "unconditional",
+			method:     "DELETE",
+			route:      &conditionalExample{etag: "v2"},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:                 "precondition_required",
+			method:               "DELETE",
+			route:                &conditionalExample{etag: "v2"},
+			preconditionRequired: true,
+			wantStatus:           http.StatusPreconditionRequired,
+		},
+		{
+			name:                 "precondition_required_without_validators",
+			method:               "PUT",
+			route:                &conditionalExample{},
+			preconditionRequired: true,
+			wantStatus:           http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rm := NewRouteMachine("", 0, "/api", &exampleLogger{})
+			if err := rm.AddRouteSet(NewRouteSet("/sample", &exampleParser{}).SetPreconditionRequired(tt.preconditionRequired).AddRoutes(tt.route)); err != nil {
+				t.Fatalf("RouteMachine.AddRouteSet() error = %v", err)
+			}
+
+			r := httptest.NewRequest(tt.method, "/api/sample", strings.NewReader(`{"name":"sample"}`))
+			for key, value := range tt.headers {
+				r.Header.Set(key, value)
+			}
+			w := httptest.NewRecorder()
+			rm.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %v, want %v, body = %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if called := tt.wantStatus == http.StatusOK; tt.route.called != called {
+				t.Errorf("route called = %v, want %v", tt.route.called, called)
+			}
+		})
+	}
+}
+
+func Test_matchETag(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		etag   string
+		weak   bool
+		want   bool
+	}{
+		{name: "any", header: "*", etag: "", want: true},
+		{name: "strong", header: `"a", "b"`, etag: `"b"`, want: true},
+		{name: "comma_in_tag", header: `"a,b"`, etag: `"a,b"`, want: true},
+		{name: "strong_mismatch", header: `"a"`, etag: `"b"`, want: false},
+		{name: "strong_weak_header", header: `W/"a"`, etag: `"a"`, want: false},
+		{name: "strong_weak_etag", header: `"a"`, etag: `W/"a"`, want: false},
+		{name: "weak", header: `W/"a"`, etag: `"a"`, weak: true, want: true},
+		{name: "weak_both", header: `W/"a"`, etag: `W/"a"`, weak: true, want: true},
+		{name: "no_etag", header: `"a"`, etag: "", weak: true, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchETag(tt.header, tt.etag, tt.weak); got != tt.want {
+				t.Errorf("matchETag() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package procroute
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/gorilla/mux"
+)
+
+// contextKey defines the type of the keys procroute uses to store request values within the context
+type contextKey int
+
+const (
+	urlParamsContextKey contextKey = iota
+	queryParamsContextKey
+	requestMetaContextKey
+)
+
+// requestContext returns the context passed to context aware routes.
+// It extends the request context by the url params, query params and metadata of the request.
+func requestContext(r *http.Request) context.Context {
+	ctx := context.WithValue(r.Context(), urlParamsContextKey, mux.Vars(r))
+	ctx = context.WithValue(ctx, queryParamsContextKey, r.URL.Query())
+	return context.WithValue(ctx, requestMetaContextKey, newRequestMeta(r))
+}
+
+// UrlParamsFromContext returns the url params of the request the context belongs to.
+// It is the context aware alternative to the UrlParams interface.
+//
+// Example:
+//  procroute.Get(rs, "/{id}", func(ctx context.Context, _ struct{}) (*Model, error) {
+//  	id := procroute.UrlParamsFromContext(ctx)["id"]
+//  	// do something
+//  })
+func UrlParamsFromContext(ctx context.Context) map[string]string {
+	params, _ := ctx.Value(urlParamsContextKey).(map[string]string)
+	return params
+}
+
+// QueryParamsFromContext returns the query params of the request the context belongs to.
+// It is the context aware alternative to the QueryParams interface.
+func QueryParamsFromContext(ctx context.Context) url.Values {
+	params, _ := ctx.Value(queryParamsContextKey).(url.Values)
+	return params
+}
@@ -0,0 +1,135 @@
+package procroute
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+)
+
+// errorMapping translates errors matched by match into a copy of the HttpError template
+type errorMapping struct {
+	match    func(err error) bool
+	template *HttpError
+}
+
+// errorRegistry contains the error mappings in the order of registration
+type errorRegistry []errorMapping
+
+// add registers a new error mapping. A nil template is replaced by an internal server error.
+func (e *errorRegistry) add(match func(err error) bool, template *HttpError) {
+	if template == nil {
+		template = InternalServerError("")
+	}
+	*e = append(*e, errorMapping{match: match, template: template})
+}
+
+// lookup returns the HttpError of the first mapping that matches the error or nil, if there is none.
+// The returned error wraps the passed error. If the template has no message, the message of the error is used.
+func (e errorRegistry) lookup(err error) *HttpError {
+	for _, mapping := range e {
+		if !mapping.match(err) {
+			continue
+		}
+
+		httpErr := *mapping.template
+		httpErr.Errors = append([]FieldError(nil), mapping.template.Errors...)
+		return (&httpErr).WithCause(err)
+	}
+	return nil
+}
+
+// matchValue returns a function that matches errors by errors.Is
+func matchValue(target error) func(err error) bool {
+	return func(err error) bool {
+		return errors.Is(err, target)
+	}
+}
+
+// matchType returns a function that matches errors of the same type as the sample by errors.As
+func matchType(sample error) func(err error) bool {
+	typ := reflect.TypeOf(sample)
+	return func(err error) bool {
+		if typ == nil {
+			return false
+		}
+		return errors.As(err, reflect.New(typ).Interface())
+	}
+}
+
+// MapError provides a method that translates errors returned by the routes of the route set into the passed HttpError,
+// if they match the target by errors.Is. If the HttpError has no message, the message of the returned error is sent to the client.
+// Mappings of the route set are checked before the mappings of the route machine.
+//
+// Example:
+//  rs.MapError(sql.ErrNoRows, procroute.NotFound("resource not found"))
+func (rs *RouteSet) MapError(target error, httpErr *HttpError) *RouteSet {
+	rs.errorMappings.add(matchValue(target), httpErr)
+	return rs
+}
+
+// MapErrorType provides a method that translates errors returned by the routes of the route set into the passed HttpError,
+// if they have the same type as the sample, which is checked by errors.As.
+// If the HttpError has no message, the message of the returned error is sent to the client.
+//
+// Example:
+//  rs.MapErrorType(&ValidationError{}, procroute.BadRequest(""))
+func (rs *RouteSet) MapErrorType(sample error, httpErr *HttpError) *RouteSet {
+	rs.errorMappings.add(matchType(sample), httpErr)
+	return rs
+}
+
+// withErrorMappings provides a method that sets the error mappings of the route machine, which are checked after the mappings of the route set
+func (rs *RouteSet) withErrorMappings(mappings *errorRegistry) *RouteSet {
+	rs.defaultErrorMappings = mappings
+	return rs
+}
+
+// MapError provides a method that translates errors returned by the routes of all route sets into the passed HttpError,
+// if they match the target by errors.Is. If the HttpError has no message, the message of the returned error is sent to the client.
+//
+// Example:
+//  rm.MapError(ErrPermissionDenied, procroute.Forbidden("").WithErrorCode("PERMISSION_DENIED"))
+func (rm *RouteMachine) MapError(target error, httpErr *HttpError) *RouteMachine {
+	rm.errorMappings.add(matchValue(target), httpErr)
+	return rm
+}
+
+// MapErrorType provides a method that translates errors returned by the routes of all route sets into the passed HttpError,
+// if they have the same type as the sample, which is checked by errors.As.
+// If the HttpError has no message, the message of the returned error is sent to the client.
+func (rm *RouteMachine) MapErrorType(sample error, httpErr *HttpError) *RouteMachine {
+	rm.errorMappings.add(matchType(sample), httpErr)
+	return rm
+}
+
+// toHttpError converts an error returned by a route into a HttpError.
+// HttpErrors wrapped by the error are returned as is, other errors are translated by the error mappings of the route set and the route machine.
+// Errors without a mapping are logged and answered with an internal server error, which does not expose the error message to the client.
+func (rs *RouteSet) toHttpError(err error) *HttpError {
+	if err == nil {
+		return nil
+	}
+
+	var httpErr *HttpError
+	if errors.As(err, &httpErr) {
+		// a nil *HttpError wrapped into the error interface does not represent an error
+		if httpErr == nil {
+			return nil
+		}
+		return httpErr
+	}
+
+	if httpErr := rs.errorMappings.lookup(err); httpErr != nil {
+		return httpErr
+	}
+	if rs.defaultErrorMappings != nil {
+		if httpErr := rs.defaultErrorMappings.lookup(err); httpErr != nil {
+			return httpErr
+		}
+	}
+
+	if rs.logger != nil {
+		rs.logger.Error("route returned an unmapped error: %v", err)
+	}
+	return InternalServerError(http.StatusText(http.StatusInternalServerError)).WithCause(err)
+}
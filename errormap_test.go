@@ -0,0 +1,144 @@
+package procroute
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestRouteSet_toHttpError(t *testing.T) {
+	var nilHttpErr *HttpError
+	plainErr := errors.New("plain")
+
+	tests := []struct {
+		name string
+		err  error
+		want *HttpError
+	}{
+		{
+			name: "nil",
+			err:  nil,
+			want: nil,
+		},
+		{
+			name: "typed_nil",
+			err:  nilHttpErr,
+			want: nil,
+		},
+		{
+			name: "http_error",
+			err:  &HttpError{Status: http.StatusBadRequest, Message: "bad request"},
+			want: &HttpError{Status: http.StatusBadRequest, Message: "bad request"},
+		},
+		{
+			name: "wrapped_http_error",
+			err:  fmt.Errorf("wrapped: %w", &HttpError{Status: http.StatusConflict, Message: "conflict"}),
+			want: &HttpError{Status: http.StatusConflict, Message: "conflict"},
+		},
+		{
+			name: "unmapped_error_is_sanitized",
+			err:  plainErr,
+			want: &HttpError{Status: http.StatusInternalServerError, Message: "Internal Server Error", cause: plainErr},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rs := NewRouteSet("/api", &exampleParser{}).withLogger(&exampleLogger{})
+			if got := rs.toHttpError(tt.err); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("RouteSet.toHttpError() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRouteSet_errorMappings(t *testing.T) {
+	tests := []struct {
+		name          string
+		err           error
+		wantStatus    int
+		wantErrorCode string
+		wantMessage   string
+	}{
+		{
+			name:          "route_set_mapping",
+			err:           fmt.Errorf("loading example: %w", errExampleNotFound),
+			wantStatus:    http.StatusNotFound,
+			wantErrorCode: "EXAMPLE_NOT_FOUND",
+			wantMessage:   "resource not found",
+		},
+		{
+			name:        "route_set_type_mapping_uses_error_message",
+			err:         &exampleTypedError{field: "name"},
+			wantStatus:  http.StatusBadRequest,
+			wantMessage: "invalid field name",
+		},
+		{
+			name:        "machine_mapping",
+			err:         http.ErrNoCookie,
+			wantStatus:  http.StatusUnauthorized,
+			wantMessage: "login required",
+		},
+		{
+			name:        "route_set_mapping_takes_precedence",
+			err:         http.ErrBodyNotAllowed,
+			wantStatus:  http.StatusUnprocessableEntity,
+			wantMessage: "route set",
+		},
+		{
+			name:        "unmapped_error",
+			err:         errors.New("connection refused by 10.0.0.1"),
+			wantStatus:  http.StatusInternalServerError,
+			wantMessage: "Internal Server Error",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rm := NewRouteMachine("", 0, "/api", &exampleLogger{})
+			rs := NewRouteSet("/sample", &exampleParser{}).
+				MapError(errExampleNotFound, NotFound("resource not found").WithErrorCode("EXAMPLE_NOT_FOUND")).
+				MapErrorType(&exampleTypedError{}, BadRequest("")).
+				MapError(http.ErrBodyNotAllowed, UnprocessableEntity("route set")).
+				AddRoutes(&errorExample{err: tt.err})
+			if err := rm.AddRouteSet(rs); err != nil {
+				t.Fatalf("RouteMachine.AddRouteSet() error = %v", err)
+			}
+			// mappings of the route machine must apply to route sets added before
+			rm.MapError(http.ErrNoCookie, Unauthorized("login required")).
+				MapError(http.ErrBodyNotAllowed, Conflict("route machine"))
+
+			w := httptest.NewRecorder()
+			rm.ServeHTTP(w, httptest.NewRequest("GET", "/api/sample", nil))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %v, want %v", w.Code, tt.wantStatus)
+			}
+
+			got := HttpError{}
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatalf("json.Unmarshal() error = %v", err)
+			}
+			if got.ErrorCode != tt.wantErrorCode || got.Message != tt.wantMessage {
+				t.Errorf("error = %+v, want error code %q and message %q", got, tt.wantErrorCode, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestErrorRegistry_lookupCopiesTemplate(t *testing.T) {
+	template := UnprocessableEntity("").WithFieldErrors(FieldError{Field: "name"})
+	registry := errorRegistry{}
+	registry.add(matchValue(errExampleNotFound), template)
+
+	got := registry.lookup(errExampleNotFound)
+	got.Errors[0].Field = "changed"
+	if template.Message != "" || template.Errors[0].Field != "name" || template.Unwrap() != nil {
+		t.Errorf("template was modified: %+v", template)
+	}
+	if !errors.Is(got, errExampleNotFound) {
+		t.Errorf("HttpError does not wrap the error")
+	}
+}
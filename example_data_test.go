@@ -1,10 +1,16 @@
 package procroute
 
 import (
+	"context"
 	"encoding/json"
+	"encoding/xml"
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"net/url"
+	"sync"
+	"time"
 )
 
 type exampleParser struct{}
@@ -35,6 +41,36 @@ func (e *exampleParserError) MimeType() string {
 	return ""
 }
 
+type exampleXmlParser struct{}
+
+func (e *exampleXmlParser) Unmarshal(data []byte, v interface{}) error {
+	return xml.Unmarshal(data, v)
+}
+
+func (e *exampleXmlParser) Marshal(v interface{}) ([]byte, error) {
+	return xml.Marshal(v)
+}
+
+func (e *exampleXmlParser) MimeType() string {
+	return "application/xml"
+}
+
+type exampleStreamParser struct {
+	exampleParser
+	decoders int
+	encoders int
+}
+
+func (e *exampleStreamParser) NewDecoder(r io.Reader) Decoder {
+	e.decoders++
+	return json.NewDecoder(r)
+}
+
+func (e *exampleStreamParser) NewEncoder(w io.Writer) Encoder {
+	e.encoders++
+	return json.NewEncoder(w)
+}
+
 type exampleLogger struct{}
 
 func (e *exampleLogger) Trace(format string, v ...interface{}) {}
@@ -257,3 +293,535 @@ func (e *exampleMiddleware) Middleware(h http.Handler) http.Handler {
 func (e *exampleMiddleware) WithLogger(lggbl Loggable) {
 	e.logger = lggbl
 }
+
+type funcMiddleware struct {
+	fn func(next http.Handler) http.Handler
+}
+
+func (f *funcMiddleware) Middleware(h http.Handler) http.Handler {
+	return f.fn(h)
+}
+
+type urlParamsExample struct {
+	urlParams   map[string]string
+	queryParams url.Values
+	logger      Loggable
+}
+
+func (u *urlParamsExample) Get(requestData interface{}) (interface{}, *HttpError) {
+	id := u.urlParams["id"]
+	// give concurrent requests the chance to overwrite the url params
+	time.Sleep(time.Millisecond)
+	if id != u.urlParams["id"] || id != u.queryParams.Get("id") {
+		return nil, &HttpError{Status: http.StatusConflict, Message: "url params have been overwritten"}
+	}
+	return id, nil
+}
+
+func (u *urlParamsExample) GetRoutePath() string {
+	return "/{id}"
+}
+
+func (u *urlParamsExample) SetUrlParams(val map[string]string) {
+	u.urlParams = val
+}
+
+func (u *urlParamsExample) SetQueryParams(args url.Values) {
+	u.queryParams = args
+}
+
+func (u *urlParamsExample) WithLogger(lggbl Loggable) {
+	u.logger = lggbl
+}
+
+type testContextKey string
+
+type contextExample struct {
+	err error
+}
+
+func (c *contextExample) Get(requestData interface{}) (interface{}, *HttpError) {
+	return "get without context", nil
+}
+
+func (c *contextExample) GetWithContext(ctx context.Context, requestData interface{}) (interface{}, error) {
+	return ctx.Value(testContextKey("value")), c.err
+}
+
+func (c *contextExample) GetRoutePath() string {
+	return "/{id}"
+}
+
+func (c *contextExample) GetAllWithContext(ctx context.Context, requestData interface{}) ([]interface{}, error) {
+	return []interface{}{ctx.Value(testContextKey("value"))}, c.err
+}
+
+func (c *contextExample) PostWithContext(ctx context.Context, requestData interface{}) error {
+	return c.err
+}
+
+func (c *contextExample) UpdateWithContext(ctx context.Context, requestData interface{}) error {
+	return c.err
+}
+
+func (c *contextExample) UpdateRoutePath() string {
+	return "/{id}"
+}
+
+func (c *contextExample) DeleteWithContext(ctx context.Context, requestData interface{}) error {
+	return c.err
+}
+
+func (c *contextExample) DeleteRoutePath() string {
+	return "/{id}"
+}
+
+type typerExample struct{}
+
+func (t *typerExample) Type() interface{} {
+	return map[string]interface{}{}
+}
+
+func (t *typerExample) PostType() interface{} {
+	return &data{}
+}
+
+func (t *typerExample) UpdateType() interface{} {
+	return data{}
+}
+
+type requestMetaExample struct {
+	meta *RequestMeta
+}
+
+func (m *requestMetaExample) SetRequestMeta(meta *RequestMeta) {
+	m.meta = meta
+}
+
+type responseExample struct {
+	resp *Response
+	err  error
+}
+
+func (re *responseExample) Get(requestData interface{}) (interface{}, *HttpError) {
+	return re.resp, nil
+}
+
+func (re *responseExample) GetRoutePath() string {
+	return "/{id}"
+}
+
+func (re *responseExample) GetAllWithResponse(ctx context.Context, requestData interface{}) (*Response, error) {
+	return re.resp, re.err
+}
+
+func (re *responseExample) PostWithResponse(ctx context.Context, requestData interface{}) (*Response, error) {
+	return re.resp, re.err
+}
+
+func (re *responseExample) UpdateWithResponse(ctx context.Context, requestData interface{}) (*Response, error) {
+	return re.resp, re.err
+}
+
+func (re *responseExample) UpdateRoutePath() string {
+	return "/{id}"
+}
+
+func (re *responseExample) DeleteWithResponse(ctx context.Context, requestData interface{}) (*Response, error) {
+	return re.resp, re.err
+}
+
+func (re *responseExample) DeleteRoutePath() string {
+	return "/{id}"
+}
+
+type patchExample struct {
+	current interface{}
+	patched interface{}
+	typ     interface{}
+}
+
+func (p *patchExample) GetWithContext(ctx context.Context, requestData interface{}) (interface{}, error) {
+	if p.current == nil {
+		return nil, &HttpError{Status: http.StatusNotFound, Message: "not found"}
+	}
+	return p.current, nil
+}
+
+func (p *patchExample) Patch(requestData interface{}) *HttpError {
+	p.patched = requestData
+	return nil
+}
+
+func (p *patchExample) PatchType() interface{} {
+	return p.typ
+}
+
+type patchOnlyExample struct{}
+
+func (p *patchOnlyExample) PatchWithContext(ctx context.Context, requestData interface{}) error {
+	return nil
+}
+
+type negotiationExample struct {
+	called bool
+}
+
+func (n *negotiationExample) Type() interface{} {
+	return &data{}
+}
+
+func (n *negotiationExample) PostWithContext(ctx context.Context, requestData interface{}) error {
+	n.called = true
+	return nil
+}
+
+func (n *negotiationExample) UpdateWithResponse(ctx context.Context, requestData interface{}) (*Response, error) {
+	n.called = true
+	return &Response{Body: requestData}, nil
+}
+
+type maxBodySizeExample struct {
+	size int64
+}
+
+func (m *maxBodySizeExample) Post(requestData interface{}) *HttpError {
+	return nil
+}
+
+func (m *maxBodySizeExample) MaxBodySize() int64 {
+	return m.size
+}
+
+var errExampleNotFound = errors.New("example not found")
+
+type exampleTypedError struct {
+	field string
+}
+
+func (e *exampleTypedError) Error() string {
+	return "invalid field " + e.field
+}
+
+type errorExample struct {
+	err error
+}
+
+func (e *errorExample) GetWithContext(ctx context.Context, requestData interface{}) (interface{}, error) {
+	return nil, e.err
+}
+
+type recordingLogger struct {
+	exampleLogger
+	mu     sync.Mutex
+	errors []string
+}
+
+func (r *recordingLogger) Error(format string, v ...interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.errors = append(r.errors, fmt.Sprintf(format, v...))
+}
+
+type panicExample struct {
+	value interface{}
+}
+
+func (p *panicExample) Get(requestData interface{}) (interface{}, *HttpError) {
+	panic(p.value)
+}
+
+func (p *panicExample) Raw(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusAccepted)
+	panic(p.value)
+}
+
+func (p *panicExample) HttpMethods() []string {
+	return []string{http.MethodPost}
+}
+
+type validationAddress struct {
+	Street string `json:"street" validate:"required"`
+	Zip    string `json:"zip" validate:"omitempty,len=5,regexp=^[0-9]+$"`
+}
+
+type validationBase struct {
+	ID int `json:"id" validate:"min=1"`
+}
+
+type validationModel struct {
+	validationBase
+	Name    string              `json:"name" validate:"required,min=3,max=10"`
+	Email   string              `json:"email" validate:"omitempty,email"`
+	Color   string              `json:"color" validate:"oneof=red green"`
+	Address *validationAddress  `json:"address"`
+	Items   []validationAddress `json:"items" validate:"max=2"`
+}
+
+func (v *validationModel) Validate() error {
+	if v.Name == "invalid" {
+		return ValidationErrors{{Field: "name", Code: "reserved", Message: "is reserved"}}
+	}
+	return nil
+}
+
+type validationExample struct {
+	err    error
+	called bool
+}
+
+func (v *validationExample) Type() interface{} {
+	return &validationModel{}
+}
+
+func (v *validationExample) Post(requestData interface{}) *HttpError {
+	v.called = true
+	return nil
+}
+
+func (v *validationExample) ValidateRequest(requestData interface{}) error {
+	return v.err
+}
+
+type pageExample struct {
+	total        int
+	unknownTotal bool
+	cursor       bool
+	page         Page
+}
+
+func (p *pageExample) GetPage(ctx context.Context, page Page, requestData interface{}) (*PageResult, error) {
+	p.page = page
+	if page.Cursor == "invalid" {
+		return nil, BadRequest("invalid cursor")
+	}
+
+	start := page.Offset()
+	if p.cursor && page.Cursor != "" {
+		fmt.Sscanf(page.Cursor, "%d", &start)
+	}
+
+	result := &PageResult{}
+	for i := start; i < start+page.Limit && i < p.total; i++ {
+		result.Items = append(result.Items, i)
+	}
+	if !p.cursor && !p.unknownTotal {
+		total := int64(p.total)
+		result.Total = &total
+	}
+	if p.cursor {
+		if start+page.Limit < p.total {
+			result.NextCursor = fmt.Sprint(start + page.Limit)
+		}
+		if start > 0 {
+			result.PrevCursor = fmt.Sprint(start - page.Limit)
+		}
+	}
+	return result, nil
+}
+
+type queryOwner struct {
+	Name string `json:"name"`
+}
+
+type queryTimestamps struct {
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type queryModel struct {
+	queryTimestamps
+	Status string      `json:"status"`
+	Age    int         `json:"age"`
+	Done   bool        `json:"done"`
+	Owner  *queryOwner `json:"owner"`
+	Labels []string    `json:"labels"`
+}
+
+type queryExample struct {
+	query *Query
+	items []queryModel
+}
+
+func (q *queryExample) Type() interface{} {
+	return data{}
+}
+
+func (q *queryExample) QueryType() interface{} {
+	return queryModel{}
+}
+
+func (q *queryExample) SetQuery(query *Query) {
+	q.query = query
+}
+
+func (q *queryExample) GetAll(requestData interface{}) ([]interface{}, *HttpError) {
+	items := []interface{}{}
+	for _, item := range ApplyQuery(q.query, q.items) {
+		items = append(items, item.Status)
+	}
+	return items, nil
+}
+
+type Timings struct {
+	ID        uint      `json:"id,omitempty" xml:"identifier"`
+	CreatedAt time.Time `json:"createdAt,omitempty"`
+}
+
+type fieldsetOwner struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+type fieldsetModel struct {
+	XMLName xml.Name `json:"-" xml:"model"`
+	Name    string   `json:"name,omitempty" xml:"title"`
+	URL     string   `json:"url,omitempty"`
+	Secret  string   `json:"-" xml:"-"`
+	Timings
+	Owner  *fieldsetOwner         `json:"owner,omitempty"`
+	Owners []fieldsetOwner        `json:"owners,omitempty"`
+	Extra  map[string]interface{} `json:"extra,omitempty"`
+}
+
+type fieldsetExample struct {
+	data interface{}
+}
+
+func (f *fieldsetExample) Get(requestData interface{}) (interface{}, *HttpError) {
+	return f.data, nil
+}
+
+func (f *fieldsetExample) GetAll(requestData interface{}) ([]interface{}, *HttpError) {
+	return []interface{}{f.data, f.data}, nil
+}
+
+type streamIterator struct {
+	items  []interface{}
+	err    error
+	index  int
+	closed bool
+}
+
+func (s *streamIterator) Next() bool {
+	if s.index >= len(s.items) {
+		return false
+	}
+	s.index++
+	return true
+}
+
+func (s *streamIterator) Item() interface{} {
+	return s.items[s.index-1]
+}
+
+func (s *streamIterator) Err() error {
+	return s.err
+}
+
+func (s *streamIterator) Close() error {
+	s.closed = true
+	return nil
+}
+
+type streamExample struct {
+	items    []interface{}
+	err      error
+	iterErr  error
+	iterator *streamIterator
+}
+
+func (s *streamExample) GetAllStream(ctx context.Context, requestData interface{}) (ItemIterator, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	s.iterator = &streamIterator{items: s.items, err: s.iterErr}
+	return s.iterator, nil
+}
+
+type conditionalModel struct {
+	Name     string `json:"name"`
+	version  string
+	modified time.Time
+}
+
+func (c conditionalModel) ETag() string {
+	return c.version
+}
+
+func (c conditionalModel) LastModified() time.Time {
+	return c.modified
+}
+
+type conditionalExample struct {
+	data     interface{}
+	etag     string
+	modified time.Time
+	called   bool
+}
+
+func (c *conditionalExample) Get(requestData interface{}) (interface{}, *HttpError) {
+	return c.data, nil
+}
+
+func (c *conditionalExample) Update(requestData interface{}) *HttpError {
+	c.called = true
+	return nil
+}
+
+func (c *conditionalExample) Delete(requestData interface{}) *HttpError {
+	c.called = true
+	return nil
+}
+
+func (c *conditionalExample) Patch(requestData interface{}) *HttpError {
+	c.called = true
+	return nil
+}
+
+func (c *conditionalExample) ETag() string {
+	return c.etag
+}
+
+func (c *conditionalExample) LastModified() time.Time {
+	return c.modified
+}
+
+type cacheExample struct {
+	calls     int
+	etag      string
+	updateErr *HttpError
+}
+
+func (c *cacheExample) Get(requestData interface{}) (interface{}, *HttpError) {
+	c.calls++
+	return data{Name: "sample", Value: c.calls}, nil
+}
+
+func (c *cacheExample) Update(requestData interface{}) *HttpError {
+	return c.updateErr
+}
+
+func (c *cacheExample) ETag() string {
+	return c.etag
+}
+
+type uncachedExample struct {
+	cacheExample
+}
+
+func (u *uncachedExample) CacheTTL() time.Duration {
+	return 0
+}
+
+type languageExample struct {
+	calls int
+}
+
+func (l *languageExample) GetWithContext(ctx context.Context, requestData interface{}) (interface{}, error) {
+	l.calls++
+	greeting := "hello"
+	if RequestMetaFromContext(ctx).Header.Get("Accept-Language") == "de" {
+		greeting = "hallo"
+	}
+	return &Response{Headers: http.Header{"Vary": []string{"Accept-Language"}}, Body: greeting}, nil
+}
@@ -28,6 +28,11 @@ type Example struct {
 	logger      procroute.Loggable
 }
 
+// Type implements the Typer interface
+func (e *Example) Type() interface{} {
+	return MyType{}
+}
+
 // Get implements the GetRoute interface
 func (e *Example) Get(requestData interface{}) (interface{}, *procroute.HttpError) {
 	e.logger.Info("received get request with data: %+#v", requestData)
@@ -111,6 +116,17 @@ func (e *Example) UpdateRoutePath() string {
 	return "/{id}"
 }
 
+// Patch implements the PatchRoute interface
+func (e *Example) Patch(requestData interface{}) *procroute.HttpError {
+	e.logger.Info("received patch request with data: %+#v", requestData)
+	return nil
+}
+
+// PatchRoutePath implements the PatchRouteRoutePath interface
+func (e *Example) PatchRoutePath() string {
+	return "/{id}"
+}
+
 // Delete implements the DeleteRoute interface
 func (e *Example) Delete(requestData interface{}) *procroute.HttpError {
 	e.logger.Info("received delete request with data: %+#v", requestData)
@@ -0,0 +1,490 @@
+package procroute
+
+import (
+	"encoding"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+var (
+	ErrInvalidFieldSelection = errors.New("invalid field selection")
+)
+
+var (
+	interfaceType     = reflect.TypeOf((*interface{})(nil)).Elem()
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	xmlMarshalerType  = reflect.TypeOf((*xml.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// FieldNamer defines an optional interface of parsers, whose encoded field names are not defined by the json struct tag.
+// It is used to resolve the fields selected by the fields query parameter. If a parser does not implement the interface,
+// fields are named by the xml struct tag for XML parsers and by the json struct tag for all other parsers.
+type FieldNamer interface {
+	// FieldName returns the name the struct field is encoded with. The bool is false, if the field is not encoded.
+	// An empty name of an embedded struct means that its fields are promoted to the embedding struct.
+	//
+	// Example:
+	//  func (p *YamlParser) FieldName(field reflect.StructField) (string, bool) {
+	//  	name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+	//  	if name == "-" || !field.IsExported() {
+	//  		return "", false
+	//  	}
+	//  	if name == "" {
+	//  		name = strings.ToLower(field.Name)
+	//  	}
+	//  	return name, true
+	//  }
+	FieldName(field reflect.StructField) (string, bool)
+}
+
+// fieldSelection represents the fields selected by the fields query parameter as tree.
+// A nil selection selects the value as it is.
+type fieldSelection map[string]fieldSelection
+
+// projection describes the type of a value reduced to the selected fields and how the selected fields are copied into it
+type projection struct {
+	typ  reflect.Type
+	copy func(dst, src reflect.Value) error
+}
+
+// projector reduces values to the selected fields, the fields are named by the namer of the negotiated parser
+type projector struct {
+	namer func(sf reflect.StructField) (string, bool)
+}
+
+// selectFields reduces the data returned by a get or get all route to the fields selected by the fields query parameter, e.g. "name,url,timings.id".
+// Nested fields are separated by dots, embedded structs can be addressed by their field name or skipped. Unknown fields are answered with 400 Bad Request.
+func (rs *RouteSet) selectFields(r *http.Request, data interface{}) (interface{}, *HttpError) {
+	// the response is not acceptable, which is reported when the data is written
+	parser, httpErr := rs.encoderFor(r)
+	if httpErr != nil {
+		return data, nil
+	}
+
+	selectFields, httpErr := rs.fieldSelector(r, parser)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+	if selectFields == nil || data == nil {
+		return data, nil
+	}
+
+	if resp, ok := asResponse(data); ok {
+		projected := *resp
+		body, err := selectFields(resp.Body)
+		if err != nil {
+			return nil, BadRequest(err.Error()).WithCause(err)
+		}
+		projected.Body = body
+		return &projected, nil
+	}
+
+	// the items of get all routes may be of different types, so they are projected one by one
+	if items, ok := data.([]interface{}); ok {
+		projected := make([]interface{}, 0, len(items))
+		for _, item := range items {
+			value, err := selectFields(item)
+			if err != nil {
+				return nil, BadRequest(err.Error()).WithCause(err)
+			}
+			projected = append(projected, value)
+		}
+		return projected, nil
+	}
+
+	projected, err := selectFields(data)
+	if err != nil {
+		return nil, BadRequest(err.Error()).WithCause(err)
+	}
+	return projected, nil
+}
+
+// fieldSelector returns a function that reduces values to the fields selected by the fields query parameter, named like the parser encodes them.
+// The returned function is nil, if the request does not select fields.
+func (rs *RouteSet) fieldSelector(r *http.Request, parser Parser) (func(value interface{}) (interface{}, error), *HttpError) {
+	fields := r.URL.Query().Get("fields")
+	if fields == "" {
+		return nil, nil
+	}
+
+	selection, err := parseFieldSelection(fields)
+	if err != nil {
+		return nil, BadRequest(err.Error()).WithCause(err)
+	}
+
+	p := &projector{namer: fieldNamer(parser)}
+	return func(value interface{}) (interface{}, error) {
+		return p.project(value, selection)
+	}, nil
+}
+
+// parseFieldSelection parses the comma separated field paths into a tree
+func parseFieldSelection(fields string) (fieldSelection, error) {
+	selection := fieldSelection{}
+	for _, path := range strings.Split(fields, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+
+		names := strings.Split(path, ".")
+		node := selection
+		for i, name := range names {
+			if name == "" {
+				return nil, fmt.Errorf("%w: invalid field %q", ErrInvalidFieldSelection, path)
+			}
+			// selecting the field itself overrides the selection of its nested fields
+			if i == len(names)-1 {
+				node[name] = nil
+				break
+			}
+
+			child, exists := node[name]
+			if exists && child == nil {
+				// the whole field is already selected
+				break
+			}
+			if !exists {
+				child = fieldSelection{}
+				node[name] = child
+			}
+			node = child
+		}
+	}
+	return selection, nil
+}
+
+// fieldNamer returns the function that names struct fields like the parser encodes them
+func fieldNamer(parser Parser) func(sf reflect.StructField) (string, bool) {
+	if namer, ok := parser.(FieldNamer); ok {
+		return namer.FieldName
+	}
+
+	tag := "json"
+	if problemTypeOf(parser) == ProblemXmlMimeType {
+		tag = "xml"
+	}
+	return func(sf reflect.StructField) (string, bool) {
+		value := sf.Tag.Get(tag)
+		if value == "-" {
+			return "", false
+		}
+
+		name := strings.Split(value, ",")[0]
+		if sf.Anonymous && name == "" && isProjectableStruct(sf.Type) {
+			return "", true
+		}
+		if !sf.IsExported() {
+			return "", false
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		return name, true
+	}
+}
+
+// project returns a copy of the value reduced to the selected fields
+func (p *projector) project(value interface{}, selection fieldSelection) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	v := reflect.ValueOf(value)
+	proj, err := p.projectType(v.Type(), selection, "")
+	if err != nil {
+		return nil, err
+	}
+
+	dst := reflect.New(proj.typ).Elem()
+	if err := proj.copy(dst, v); err != nil {
+		return nil, err
+	}
+	return dst.Interface(), nil
+}
+
+// projectType returns the projection of the type to the selected fields
+func (p *projector) projectType(t reflect.Type, selection fieldSelection, path string) (*projection, error) {
+	if selection == nil {
+		return &projection{typ: t, copy: func(dst, src reflect.Value) error {
+			dst.Set(src)
+			return nil
+		}}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		elem, err := p.projectType(t.Elem(), selection, path)
+		if err != nil {
+			return nil, err
+		}
+		return &projection{typ: reflect.PtrTo(elem.typ), copy: func(dst, src reflect.Value) error {
+			if src.IsNil() {
+				return nil
+			}
+			dst.Set(reflect.New(elem.typ))
+			return elem.copy(dst.Elem(), src.Elem())
+		}}, nil
+	case reflect.Slice, reflect.Array:
+		elem, err := p.projectType(t.Elem(), selection, path)
+		if err != nil {
+			return nil, err
+		}
+		typ := reflect.SliceOf(elem.typ)
+		if t.Kind() == reflect.Array {
+			typ = reflect.ArrayOf(t.Len(), elem.typ)
+		}
+		return &projection{typ: typ, copy: func(dst, src reflect.Value) error {
+			if src.Kind() == reflect.Slice {
+				if src.IsNil() {
+					return nil
+				}
+				dst.Set(reflect.MakeSlice(typ, src.Len(), src.Len()))
+			}
+			for i := 0; i < src.Len(); i++ {
+				if err := elem.copy(dst.Index(i), src.Index(i)); err != nil {
+					return err
+				}
+			}
+			return nil
+		}}, nil
+	case reflect.Interface:
+		// the dynamic type is only known at runtime and the projection does not implement the methods of the interface
+		return &projection{typ: interfaceType, copy: func(dst, src reflect.Value) error {
+			if src.IsNil() {
+				return nil
+			}
+			elem, err := p.projectType(src.Elem().Type(), selection, path)
+			if err != nil {
+				return err
+			}
+			value := reflect.New(elem.typ).Elem()
+			if err := elem.copy(value, src.Elem()); err != nil {
+				return err
+			}
+			dst.Set(value)
+			return nil
+		}}, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			break
+		}
+		// maps have no schema, so selected keys that do not exist are skipped
+		typ := reflect.MapOf(t.Key(), interfaceType)
+		return &projection{typ: typ, copy: func(dst, src reflect.Value) error {
+			if src.IsNil() {
+				return nil
+			}
+			dst.Set(reflect.MakeMap(typ))
+			for name, child := range selection {
+				value := src.MapIndex(reflect.ValueOf(name).Convert(t.Key()))
+				if !value.IsValid() {
+					continue
+				}
+				elem, err := p.projectType(interfaceType, child, joinFieldPath(path, name))
+				if err != nil {
+					return err
+				}
+				projected := reflect.New(interfaceType).Elem()
+				if err := elem.copy(projected, value); err != nil {
+					return err
+				}
+				dst.SetMapIndex(reflect.ValueOf(name).Convert(t.Key()), projected)
+			}
+			return nil
+		}}, nil
+	case reflect.Struct:
+		if isProjectableStruct(t) {
+			return p.projectStruct(t, selection, path)
+		}
+	}
+
+	if path == "" {
+		return nil, fmt.Errorf("%w: %s has no fields", ErrInvalidFieldSelection, t)
+	}
+	return nil, fmt.Errorf("%w: field %s has no fields", ErrInvalidFieldSelection, path)
+}
+
+// projectableField describes an encoded field of a struct including the fields promoted by embedded structs
+type projectableField struct {
+	sf    reflect.StructField
+	name  string
+	index []int
+	// embedded contains the names of the embedded structs the field is promoted by
+	embedded []string
+}
+
+// projectStruct returns a struct type that only contains the selected fields, the fields of embedded structs are promoted into it
+func (p *projector) projectStruct(t reflect.Type, selection fieldSelection, path string) (*projection, error) {
+	fields := p.structFields(t, nil, nil)
+
+	selected := map[int]fieldSelection{}
+	if err := p.selectStructFields(fields, nil, selection, path, selected); err != nil {
+		return nil, err
+	}
+
+	structFields, projections, indices, names := []reflect.StructField{}, []*projection{}, [][]int{}, map[string]bool{}
+	for i, f := range fields {
+		child, ok := selected[i]
+		// the XMLName field defines the name of the root element and is always kept
+		if !ok && f.sf.Name != "XMLName" {
+			continue
+		}
+		if names[f.sf.Name] {
+			continue
+		}
+		names[f.sf.Name] = true
+
+		proj, err := p.projectType(f.sf.Type, child, joinFieldPath(path, f.name))
+		if err != nil {
+			return nil, err
+		}
+		structFields = append(structFields, reflect.StructField{Name: f.sf.Name, Type: proj.typ, Tag: f.sf.Tag})
+		projections, indices = append(projections, proj), append(indices, f.index)
+	}
+
+	typ := reflect.StructOf(structFields)
+	return &projection{typ: typ, copy: func(dst, src reflect.Value) error {
+		for i, proj := range projections {
+			// fields promoted by nil pointers to embedded structs are left empty
+			field, err := src.FieldByIndexErr(indices[i])
+			if err != nil {
+				continue
+			}
+			if err := proj.copy(dst.Field(i), field); err != nil {
+				return err
+			}
+		}
+		return nil
+	}}, nil
+}
+
+// structFields returns the encoded fields of the struct, fields of embedded structs are promoted
+func (p *projector) structFields(t reflect.Type, index []int, embedded []string) []projectableField {
+	fields := []projectableField{}
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name, ok := p.namer(sf)
+		if !ok {
+			continue
+		}
+
+		fieldIndex := append(append([]int{}, index...), i)
+		if name == "" && sf.Anonymous {
+			// fields promoted by unexported embedded structs can not be copied
+			if !sf.IsExported() {
+				continue
+			}
+			et := sf.Type
+			if et.Kind() == reflect.Ptr {
+				et = et.Elem()
+			}
+			fields = append(fields, p.structFields(et, fieldIndex, append(append([]string{}, embedded...), sf.Name))...)
+			continue
+		}
+		fields = append(fields, projectableField{sf: sf, name: name, index: fieldIndex, embedded: embedded})
+	}
+	return fields
+}
+
+// selectStructFields resolves the selection against the fields promoted by the embedded structs of the passed prefix.
+// The selection of each field is stored by the index of the field.
+func (p *projector) selectStructFields(fields []projectableField, prefix []string, selection fieldSelection, path string, selected map[int]fieldSelection) error {
+	for name, child := range selection {
+		// fields of the embedding struct shadow the fields promoted by embedded structs
+		match := -1
+		for i, f := range fields {
+			if !hasPrefix(f.embedded, prefix) || (match >= 0 && len(f.embedded) >= len(fields[match].embedded)) {
+				continue
+			}
+			// untagged fields are matched case insensitive like their encoded name is by most parsers
+			if f.name == name || (f.name == f.sf.Name && strings.EqualFold(f.name, name)) {
+				match = i
+			}
+		}
+		if match >= 0 {
+			existing, ok := selected[match]
+			selected[match] = mergeSelection(existing, child, ok)
+			continue
+		}
+
+		// the name may address an embedded struct, whose fields are promoted
+		var embedded []string
+		for _, f := range fields {
+			if hasPrefix(f.embedded, prefix) && len(f.embedded) > len(prefix) && strings.EqualFold(f.embedded[len(prefix)], name) {
+				embedded = append(append([]string{}, prefix...), f.embedded[len(prefix)])
+				break
+			}
+		}
+		if embedded == nil {
+			return fmt.Errorf("%w: unknown field %s", ErrInvalidFieldSelection, joinFieldPath(path, name))
+		}
+
+		if child == nil {
+			for i, f := range fields {
+				if hasPrefix(f.embedded, embedded) {
+					selected[i] = nil
+				}
+			}
+			continue
+		}
+		if err := p.selectStructFields(fields, embedded, child, joinFieldPath(path, name), selected); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mergeSelection merges two selections of the same field. If one of them selects the whole field, the merged selection does as well.
+func mergeSelection(current, next fieldSelection, exists bool) fieldSelection {
+	if !exists {
+		return next
+	}
+	if current == nil || next == nil {
+		return nil
+	}
+	merged := fieldSelection{}
+	for name, child := range current {
+		merged[name] = child
+	}
+	for name, child := range next {
+		existing, ok := merged[name]
+		merged[name] = mergeSelection(existing, child, ok)
+	}
+	return merged
+}
+
+// hasPrefix reports whether the names start with the prefix
+func hasPrefix(names, prefix []string) bool {
+	if len(names) < len(prefix) {
+		return false
+	}
+	for i := range prefix {
+		if names[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// isProjectableStruct reports whether the type is a struct, whose fields are encoded, and not a value encoded by a marshaler like time.Time
+func isProjectableStruct(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	for _, marshaler := range []reflect.Type{jsonMarshalerType, xmlMarshalerType, textMarshalerType} {
+		if t.Implements(marshaler) || reflect.PtrTo(t).Implements(marshaler) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,187 @@
+package procroute
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var fieldsetSample = &fieldsetModel{
+	Name:    "sample",
+	URL:     "example.local",
+	Secret:  "secret",
+	Timings: Timings{ID: 1, CreatedAt: time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)},
+	Owner:   &fieldsetOwner{Name: "leon", Email: "leon@example.local"},
+	Owners:  []fieldsetOwner{{Name: "anna", Email: "anna@example.local"}},
+	Extra:   map[string]interface{}{"color": "red", "size": 2},
+}
+
+func TestParseFieldSelection(t *testing.T) {
+	tests := []struct {
+		name    string
+		fields  string
+		want    fieldSelection
+		wantErr bool
+	}{
+		{
+			name:   "nested",
+			fields: "name, url,timings.id,timings.createdAt",
+			want:   fieldSelection{"name": nil, "url": nil, "timings": {"id": nil, "createdAt": nil}},
+		},
+		{
+			name:   "whole_field_wins",
+			fields: "owner.name,owner,owner.email",
+			want:   fieldSelection{"owner": nil},
+		},
+		{
+			name:    "empty_segment",
+			fields:  "owner..name",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseFieldSelection(tt.fields)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseFieldSelection() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseFieldSelection() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRouteSet_selectFields(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		fields     string
+		accept     string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "without_fields",
+			fields:     "",
+			wantStatus: http.StatusOK,
+			wantBody:   `{"name":"sample","url":"example.local","id":1,"createdAt":"2022-01-02T03:04:05Z","owner":{"name":"leon","email":"leon@example.local"},"owners":[{"name":"anna","email":"anna@example.local"}],"extra":{"color":"red","size":2}}`,
+		},
+		{
+			name:       "embedded_struct_by_name",
+			fields:     "name,url,timings.id",
+			wantStatus: http.StatusOK,
+			wantBody:   `{"name":"sample","url":"example.local","id":1}`,
+		},
+		{
+			name:       "promoted_and_nested_fields",
+			fields:     "createdAt,owner.name,owners.email,extra.size,extra.missing",
+			wantStatus: http.StatusOK,
+			wantBody:   `{"createdAt":"2022-01-02T03:04:05Z","owner":{"name":"leon"},"owners":[{"email":"anna@example.local"}],"extra":{"size":2}}`,
+		},
+		{
+			name:       "whole_embedded_struct",
+			fields:     "Timings",
+			wantStatus: http.StatusOK,
+			wantBody:   `{"id":1,"createdAt":"2022-01-02T03:04:05Z"}`,
+		},
+		{
+			name:       "get_all",
+			method:     "GET_ALL",
+			fields:     "name",
+			wantStatus: http.StatusOK,
+			wantBody:   `[{"name":"sample"},{"name":"sample"}]`,
+		},
+		{
+			name:       "xml_names",
+			fields:     "title,timings.identifier",
+			accept:     "application/xml",
+			wantStatus: http.StatusOK,
+			wantBody:   `<model><title>sample</title><identifier>1</identifier></model>`,
+		},
+		{
+			name:       "json_name_with_xml_parser",
+			fields:     "name",
+			accept:     "application/xml",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "unknown_field",
+			fields:     "name,unknown",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "hidden_field",
+			fields:     "Secret",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "fields_of_scalar",
+			fields:     "name.first",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "fields_of_time",
+			fields:     "createdAt.year",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			route := &fieldsetExample{data: fieldsetSample}
+			rs := NewRouteSet("/api", &exampleParser{}).AddParsers(&exampleXmlParser{})
+
+			r := httptest.NewRequest("GET", "/api?"+url.Values{"fields": {tt.fields}}.Encode(), nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			if tt.method == "GET_ALL" {
+				rs.defineGetAllRoute(w, r, route)
+			} else {
+				rs.defineGetRoute(w, r, route)
+			}
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %v, want %v, body = %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantBody != "" && w.Body.String() != tt.wantBody {
+				t.Errorf("body = %s, want %s", w.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestRouteSet_selectFieldsOfResponse(t *testing.T) {
+	route := &fieldsetExample{data: &Response{Status: http.StatusAccepted, Body: []interface{}{fieldsetSample}}}
+	rs := NewRouteSet("/api", &exampleParser{})
+
+	w := httptest.NewRecorder()
+	rs.defineGetRoute(w, httptest.NewRequest("GET", "/api?fields=url", nil), route)
+
+	if w.Code != http.StatusAccepted || w.Body.String() != `[{"url":"example.local"}]` {
+		t.Errorf("status = %v, body = %s, want %v, %s", w.Code, w.Body.String(), http.StatusAccepted, `[{"url":"example.local"}]`)
+	}
+	if len(route.data.(*Response).Body.([]interface{})) != 1 || route.data.(*Response).Body.([]interface{})[0] != fieldsetSample {
+		t.Errorf("the returned response must not be modified")
+	}
+}
+
+func TestProjector_project(t *testing.T) {
+	p := &projector{namer: fieldNamer(&exampleParser{})}
+
+	_, err := p.project("plain", fieldSelection{"name": nil})
+	if !errors.Is(err, ErrInvalidFieldSelection) || !strings.Contains(err.Error(), "string has no fields") {
+		t.Errorf("projector.project() error = %v, want %v", err, ErrInvalidFieldSelection)
+	}
+
+	got, err := p.project(map[string]string{"a": "1", "b": "2"}, fieldSelection{"a": nil})
+	if err != nil || !reflect.DeepEqual(got, map[string]interface{}{"a": "1"}) {
+		t.Errorf("projector.project() = %v, %v, want %v", got, err, map[string]interface{}{"a": "1"})
+	}
+}
@@ -1,5 +1,5 @@
 module github.com/leonsteinhaeuser/procroute
 
-go 1.16
+go 1.18
 
 require github.com/gorilla/mux v1.8.0
@@ -1,6 +1,7 @@
 package procroute
 
 import (
+	"encoding/xml"
 	"errors"
 	"net/http"
 )
@@ -9,20 +10,233 @@ var (
 	ErrHttpResponseWriterNotSet = errors.New("http response writer is not set")
 )
 
-// HttpError represents the datatype used as error response
+const (
+	// ProblemJsonMimeType is the mime type of problem details encoded as JSON as defined by RFC 7807
+	ProblemJsonMimeType = "application/problem+json"
+	// ProblemXmlMimeType is the mime type of problem details encoded as XML as defined by RFC 7807
+	ProblemXmlMimeType = "application/problem+xml"
+)
+
+// HttpError represents the datatype used as error response.
+// It implements the error interface and may wrap the error that caused it, which is not sent to the client.
+//
+// Example:
+//  user, err := m.store.Get(ctx, id)
+//  if err != nil {
+//  	return nil, procroute.NotFound("user not found").WithCause(err)
+//  }
 type HttpError struct {
 	Status    int
 	ErrorCode string
 	Message   string
+	// Type is a URI reference that identifies the problem type
+	Type string `json:",omitempty" xml:",omitempty"`
+	// Instance is a URI reference that identifies the specific occurrence of the problem
+	Instance string `json:",omitempty" xml:",omitempty"`
+	// Errors contains the errors of single fields of the request data
+	Errors []FieldError `json:",omitempty" xml:",omitempty"`
+	// Stack contains the stack trace of a recovered panic, which is only set in development mode
+	Stack []string `json:",omitempty" xml:",omitempty"`
+
+	cause error
+}
+
+// FieldError describes an error of a single field of the request data
+type FieldError struct {
+	// Field is the path of the field, for example "address.street" or "items[2].name"
+	Field string
+	// Code is a machine readable code of the error, for example "required"
+	Code string `json:",omitempty" xml:",omitempty"`
+	// Message is the human readable description of the error
+	Message string `json:",omitempty" xml:",omitempty"`
+}
+
+// NewHttpError returns a HttpError with the passed status and message
+func NewHttpError(status int, message string) *HttpError {
+	return &HttpError{
+		Status:    status,
+		ErrorCode: "",
+		Message:   message,
+	}
+}
+
+// BadRequest returns a HttpError with the status 400 Bad Request
+func BadRequest(message string) *HttpError {
+	return NewHttpError(http.StatusBadRequest, message)
+}
+
+// Unauthorized returns a HttpError with the status 401 Unauthorized
+func Unauthorized(message string) *HttpError {
+	return NewHttpError(http.StatusUnauthorized, message)
+}
+
+// Forbidden returns a HttpError with the status 403 Forbidden
+func Forbidden(message string) *HttpError {
+	return NewHttpError(http.StatusForbidden, message)
+}
+
+// NotFound returns a HttpError with the status 404 Not Found
+func NotFound(message string) *HttpError {
+	return NewHttpError(http.StatusNotFound, message)
+}
+
+// Conflict returns a HttpError with the status 409 Conflict
+func Conflict(message string) *HttpError {
+	return NewHttpError(http.StatusConflict, message)
+}
+
+// PreconditionFailed returns a HttpError with the status 412 Precondition Failed
+func PreconditionFailed(message string) *HttpError {
+	return NewHttpError(http.StatusPreconditionFailed, message)
+}
+
+// UnprocessableEntity returns a HttpError with the status 422 Unprocessable Entity
+func UnprocessableEntity(message string) *HttpError {
+	return NewHttpError(http.StatusUnprocessableEntity, message)
+}
+
+// PreconditionRequired returns a HttpError with the status 428 Precondition Required
+func PreconditionRequired(message string) *HttpError {
+	return NewHttpError(http.StatusPreconditionRequired, message)
+}
+
+// InternalServerError returns a HttpError with the status 500 Internal Server Error
+func InternalServerError(message string) *HttpError {
+	return NewHttpError(http.StatusInternalServerError, message)
+}
+
+// WithCause provides a method that sets the error that caused the HttpError. The cause is not sent to the client.
+// If the HttpError has no message, the message of the cause is used.
+func (h *HttpError) WithCause(err error) *HttpError {
+	h.cause = err
+	if h.Message == "" && err != nil {
+		h.Message = err.Error()
+	}
+	return h
+}
+
+// WithErrorCode provides a method that sets the application specific error code
+func (h *HttpError) WithErrorCode(code string) *HttpError {
+	h.ErrorCode = code
+	return h
 }
 
-// write marshals the error message and sends it back to the client
+// WithType provides a method that sets the URI reference that identifies the problem type
+func (h *HttpError) WithType(typ string) *HttpError {
+	h.Type = typ
+	return h
+}
+
+// WithInstance provides a method that sets the URI reference that identifies the specific occurrence of the problem
+func (h *HttpError) WithInstance(instance string) *HttpError {
+	h.Instance = instance
+	return h
+}
+
+// WithFieldErrors provides a method that adds errors of single fields of the request data
+func (h *HttpError) WithFieldErrors(errs ...FieldError) *HttpError {
+	h.Errors = append(h.Errors, errs...)
+	return h
+}
+
+// Error implements the error interface, so that a HttpError can be returned by context aware routes
+func (h *HttpError) Error() string {
+	if h.Message == "" {
+		return http.StatusText(h.Status)
+	}
+	return h.Message
+}
+
+// Unwrap returns the error that caused the HttpError
+func (h *HttpError) Unwrap() error {
+	return h.cause
+}
+
+// Is reports whether the target is a HttpError with the same status.
+// If the target defines an error code, the error codes must match as well.
+//
+// Example:
+//  if errors.Is(err, procroute.NotFound("")) {
+//  	// handle not found errors
+//  }
+func (h *HttpError) Is(target error) bool {
+	t, ok := target.(*HttpError)
+	if !ok || t == nil {
+		return false
+	}
+	return t.Status == h.Status && (t.ErrorCode == "" || t.ErrorCode == h.ErrorCode)
+}
+
+// problemDetails represents a HttpError as problem details object as defined by RFC 7807
+type problemDetails struct {
+	XMLName  xml.Name              `json:"-" xml:"urn:ietf:rfc:7807 problem"`
+	Type     string                `json:"type,omitempty" xml:"type,omitempty"`
+	Title    string                `json:"title,omitempty" xml:"title,omitempty"`
+	Status   int                   `json:"status" xml:"status"`
+	Detail   string                `json:"detail,omitempty" xml:"detail,omitempty"`
+	Instance string                `json:"instance,omitempty" xml:"instance,omitempty"`
+	Code     string                `json:"code,omitempty" xml:"code,omitempty"`
+	Errors   []problemFieldDetails `json:"errors,omitempty" xml:"-"`
+	Stack    []string              `json:"stack,omitempty" xml:"-"`
+
+	// encoding/xml writes the parent element of empty slices, so the lists are wrapped into pointers for XML
+	XmlErrors *problemXmlErrors `json:"-" xml:"errors,omitempty"`
+	XmlStack  *problemXmlStack  `json:"-" xml:"stack,omitempty"`
+}
+
+// problemXmlErrors represents the field errors of problem details encoded as XML
+type problemXmlErrors struct {
+	Errors []problemFieldDetails `xml:"error"`
+}
+
+// problemXmlStack represents the stack trace of problem details encoded as XML
+type problemXmlStack struct {
+	Frames []string `xml:"frame"`
+}
+
+// problemFieldDetails represents a FieldError as member of problem details
+type problemFieldDetails struct {
+	Field   string `json:"field" xml:"field"`
+	Code    string `json:"code,omitempty" xml:"code,omitempty"`
+	Message string `json:"message,omitempty" xml:"message,omitempty"`
+}
+
+// problem returns the problem details of the error
+func (h *HttpError) problem() *problemDetails {
+	problem := &problemDetails{
+		Type:     h.Type,
+		Title:    http.StatusText(h.Status),
+		Status:   h.Status,
+		Detail:   h.Message,
+		Instance: h.Instance,
+		Code:     h.ErrorCode,
+		Stack:    h.Stack,
+	}
+	for _, fieldErr := range h.Errors {
+		problem.Errors = append(problem.Errors, problemFieldDetails(fieldErr))
+	}
+	if len(problem.Errors) > 0 {
+		problem.XmlErrors = &problemXmlErrors{Errors: problem.Errors}
+	}
+	if len(problem.Stack) > 0 {
+		problem.XmlStack = &problemXmlStack{Frames: problem.Stack}
+	}
+	return problem
+}
+
+// write marshals the error message and sends it back to the client.
+// If the content type is a problem details type, the error is encoded as problem details object.
 func (h *HttpError) write(contentType string, parser Parser, w http.ResponseWriter) error {
 	if w == nil {
 		return ErrHttpResponseWriterNotSet
 	}
 
-	bts, err := parser.Marshal(h)
+	var body interface{} = h
+	if contentType == ProblemJsonMimeType || contentType == ProblemXmlMimeType {
+		body = h.problem()
+	}
+
+	bts, err := parser.Marshal(body)
 	if err != nil {
 		return err
 	}
@@ -1,6 +1,8 @@
 package procroute
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -65,3 +67,167 @@ func TestHttpError_write(t *testing.T) {
 		})
 	}
 }
+
+func TestHttpError_Error(t *testing.T) {
+	tests := []struct {
+		name string
+		err  *HttpError
+		want string
+	}{
+		{
+			name: "with_message",
+			err:  &HttpError{Status: http.StatusNotFound, Message: "user not found"},
+			want: "user not found",
+		},
+		{
+			name: "without_message",
+			err:  &HttpError{Status: http.StatusNotFound},
+			want: "Not Found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.err.Error(); got != tt.want {
+				t.Errorf("HttpError.Error() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHttpError_IsAndUnwrap(t *testing.T) {
+	cause := errors.New("record not found")
+	err := fmt.Errorf("loading user: %w", NotFound("user not found").WithCause(cause).WithErrorCode("USER_NOT_FOUND"))
+
+	tests := []struct {
+		name   string
+		target error
+		want   bool
+	}{
+		{
+			name:   "same_status",
+			target: NotFound(""),
+			want:   true,
+		},
+		{
+			name:   "same_status_and_error_code",
+			target: NotFound("").WithErrorCode("USER_NOT_FOUND"),
+			want:   true,
+		},
+		{
+			name:   "different_error_code",
+			target: NotFound("").WithErrorCode("GROUP_NOT_FOUND"),
+			want:   false,
+		},
+		{
+			name:   "different_status",
+			target: Conflict(""),
+			want:   false,
+		},
+		{
+			name:   "cause",
+			target: cause,
+			want:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := errors.Is(err, tt.target); got != tt.want {
+				t.Errorf("errors.Is() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	var httpErr *HttpError
+	if !errors.As(err, &httpErr) || httpErr.Status != http.StatusNotFound || httpErr.Message != "user not found" {
+		t.Errorf("errors.As() = %+v, want the not found error", httpErr)
+	}
+}
+
+func TestHttpError_constructors(t *testing.T) {
+	tests := []struct {
+		name string
+		err  *HttpError
+		want int
+	}{
+		{name: "bad_request", err: BadRequest("msg"), want: http.StatusBadRequest},
+		{name: "unauthorized", err: Unauthorized("msg"), want: http.StatusUnauthorized},
+		{name: "forbidden", err: Forbidden("msg"), want: http.StatusForbidden},
+		{name: "not_found", err: NotFound("msg"), want: http.StatusNotFound},
+		{name: "conflict", err: Conflict("msg"), want: http.StatusConflict},
+		{name: "precondition_failed", err: PreconditionFailed("msg"), want: http.StatusPreconditionFailed},
+		{name: "unprocessable_entity", err: UnprocessableEntity("msg"), want: http.StatusUnprocessableEntity},
+		{name: "precondition_required", err: PreconditionRequired("msg"), want: http.StatusPreconditionRequired},
+		{name: "internal_server_error", err: InternalServerError("msg"), want: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.err.Status != tt.want || tt.err.Message != "msg" {
+				t.Errorf("got = %+v, want status %v", tt.err, tt.want)
+			}
+		})
+	}
+
+	if got := BadRequest("").WithCause(errors.New("cause")).Message; got != "cause" {
+		t.Errorf("HttpError.WithCause() message = %v, want cause", got)
+	}
+}
+
+func TestRouteSet_writeErrorProblemDetails(t *testing.T) {
+	httpErr := UnprocessableEntity("invalid user").
+		WithErrorCode("INVALID_USER").
+		WithType("https://example.com/problems/invalid-user").
+		WithInstance("/api/users/1").
+		WithFieldErrors(FieldError{Field: "name", Code: "required", Message: "name is required"})
+
+	tests := []struct {
+		name     string
+		accept   string
+		wantType string
+		wantBody string
+	}{
+		{
+			name:     "default_format",
+			wantType: "application/json",
+			wantBody: `{"Status":422,"ErrorCode":"INVALID_USER","Message":"invalid user","Type":"https://example.com/problems/invalid-user","Instance":"/api/users/1","Errors":[{"Field":"name","Code":"required","Message":"name is required"}]}`,
+		},
+		{
+			name:     "problem_json",
+			accept:   "application/problem+json, application/json;q=0.9",
+			wantType: ProblemJsonMimeType,
+			wantBody: `{"type":"https://example.com/problems/invalid-user","title":"Unprocessable Entity","status":422,"detail":"invalid user","instance":"/api/users/1","code":"INVALID_USER","errors":[{"field":"name","code":"required","message":"name is required"}]}`,
+		},
+		{
+			name:     "problem_xml",
+			accept:   "application/problem+xml",
+			wantType: ProblemXmlMimeType,
+			wantBody: `<problem xmlns="urn:ietf:rfc:7807"><type>https://example.com/problems/invalid-user</type><title>Unprocessable Entity</title><status>422</status><detail>invalid user</detail><instance>/api/users/1</instance><code>INVALID_USER</code><errors><error><field>name</field><code>required</code><message>name is required</message></error></errors></problem>`,
+		},
+		{
+			name:     "not_acceptable_falls_back_to_default_parser",
+			accept:   "text/html",
+			wantType: "application/json",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rs := NewRouteSet("/api", &exampleParser{}).AddParsers(&exampleXmlParser{})
+			r := httptest.NewRequest("GET", "/api", nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+
+			w := httptest.NewRecorder()
+			rs.writeError(w, r, httpErr)
+
+			if w.Code != http.StatusUnprocessableEntity {
+				t.Errorf("status = %v, want %v", w.Code, http.StatusUnprocessableEntity)
+			}
+			if got := w.Header().Get("Content-Type"); got != tt.wantType {
+				t.Errorf("Content-Type = %v, want %v", got, tt.wantType)
+			}
+			if tt.wantBody != "" && w.Body.String() != tt.wantBody {
+				t.Errorf("body = %s\nwant = %s", w.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
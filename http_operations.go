@@ -1,6 +1,7 @@
 package procroute
 
 import (
+	"context"
 	"net/http"
 	"net/url"
 )
@@ -8,6 +9,7 @@ import (
 // GetRoute provides the interface that must be implemented to create a Get endpoint.
 type GetRoute interface {
 	// Get represents the method that contains the business logic for receiving a resource.
+	// The returned data may be a Response to customize the status, headers and cookies of the http response.
 	//
 	// Example
 	//  type Model struct {
@@ -29,6 +31,25 @@ type GetRoute interface {
 	Get(requestData interface{}) (interface{}, *HttpError)
 }
 
+// GetRouteWithContext provides the context aware alternative to the GetRoute interface.
+// If a route implements both interfaces, GetWithContext is preferred.
+type GetRouteWithContext interface {
+	// GetWithContext represents the method that contains the business logic for receiving a resource.
+	// The returned data may be a Response to customize the status, headers and cookies of the http response.
+	// The context is the request context, which is canceled when the client disconnects and carries the values set by middlewares.
+	// The returned error may be a *HttpError to define the response status, other errors are translated by the error mappings of MapError and MapErrorType, unmapped errors result in an internal server error without details.
+	//
+	// Example
+	//  func (m *MyType) GetWithContext(ctx context.Context, requestData interface{}) (interface{}, error) {
+	//      user, err := m.store.FindUser(ctx, m.urlParams["id"])
+	//      if err != nil {
+	//          return nil, err
+	//      }
+	//  	return user, nil
+	//  }
+	GetWithContext(ctx context.Context, requestData interface{}) (interface{}, error)
+}
+
 // GetRoutePath defines an optional child interface that is used to customize route path.
 type GetRoutePath interface {
 	// GetRoutePath represents an optional method that can be set to define a custom path for the get route.
@@ -68,6 +89,40 @@ type GetAllRoute interface {
 	GetAll(requestData interface{}) ([]interface{}, *HttpError)
 }
 
+// GetAllRouteWithContext provides the context aware alternative to the GetAllRoute interface.
+// If a route implements both interfaces, GetAllWithContext is preferred.
+type GetAllRouteWithContext interface {
+	// GetAllWithContext represents the method that contains the business logic for receiving all resources.
+	// The context is the request context, which is canceled when the client disconnects and carries the values set by middlewares.
+	// The returned error may be a *HttpError to define the response status, other errors are translated by the error mappings of MapError and MapErrorType, unmapped errors result in an internal server error without details.
+	//
+	// Example
+	//  func (m *MyType) GetAllWithContext(ctx context.Context, requestData interface{}) ([]interface{}, error) {
+	//      return m.store.ListUsers(ctx)
+	//  }
+	GetAllWithContext(ctx context.Context, requestData interface{}) ([]interface{}, error)
+}
+
+// GetAllRouteWithResponse provides an alternative to the GetAllRoute interface that returns a response envelope.
+// If a route implements multiple get all interfaces, GetAllWithResponse is preferred.
+type GetAllRouteWithResponse interface {
+	// GetAllWithResponse represents the method that contains the business logic for receiving all resources.
+	// The body of the returned response contains the resources and is encoded by the parser of the route set.
+	//
+	// Example
+	//  func (m *MyType) GetAllWithResponse(ctx context.Context, requestData interface{}) (*procroute.Response, error) {
+	//      models, err := m.store.List(ctx)
+	//      if err != nil {
+	//          return nil, err
+	//      }
+	//  	return &procroute.Response{
+	//  		Headers: http.Header{"Cache-Control": []string{"max-age=60"}},
+	//  		Body:    models,
+	//  	}, nil
+	//  }
+	GetAllWithResponse(ctx context.Context, requestData interface{}) (*Response, error)
+}
+
 // GetAllRoutePath defines an optional child interface that is used to customize route path.
 type GetAllRoutePath interface {
 	// GetAllRoutePath represents an optional method that can be set to define a custom path for the get all route.
@@ -103,6 +158,42 @@ type PostRoute interface {
 	Post(requestData interface{}) *HttpError
 }
 
+// PostRouteWithContext provides the context aware alternative to the PostRoute interface.
+// If a route implements both interfaces, PostWithContext is preferred.
+type PostRouteWithContext interface {
+	// PostWithContext represents the method that contains the business logic for creating a resource.
+	// The context is the request context, which is canceled when the client disconnects and carries the values set by middlewares.
+	// The returned error may be a *HttpError to define the response status, other errors are translated by the error mappings of MapError and MapErrorType, unmapped errors result in an internal server error without details.
+	//
+	// Example
+	//  func (m *MyType) PostWithContext(ctx context.Context, requestData interface{}) error {
+	//      return m.store.CreateUser(ctx, requestData)
+	//  }
+	PostWithContext(ctx context.Context, requestData interface{}) error
+}
+
+// PostRouteWithResponse provides an alternative to the PostRoute interface that returns a response envelope.
+// If a route implements multiple post interfaces, PostWithResponse is preferred.
+type PostRouteWithResponse interface {
+	// PostWithResponse represents the method that contains the business logic for creating a resource.
+	// The returned response defines the status, headers, cookies and body sent to the client.
+	// If the response is nil or does not define a status, 201 Created is used.
+	//
+	// Example
+	//  func (m *MyType) PostWithResponse(ctx context.Context, requestData interface{}) (*procroute.Response, error) {
+	//      model, err := m.store.Post(ctx, requestData)
+	//      if err != nil {
+	//          return nil, err
+	//      }
+	//  	return &procroute.Response{
+	//  		Status:  http.StatusCreated,
+	//  		Headers: http.Header{"Location": []string{"/api/example/" + model.ID}},
+	//  		Body:    model,
+	//  	}, nil
+	//  }
+	PostWithResponse(ctx context.Context, requestData interface{}) (*Response, error)
+}
+
 // PostRouteRoutePath defines an optional child interface that is used to customize route path.
 type PostRouteRoutePath interface {
 	// PostRoutePath represents an optional method that can be set to define a custom path for the post route.
@@ -138,6 +229,40 @@ type UpdateRoute interface {
 	Update(requestData interface{}) *HttpError
 }
 
+// UpdateRouteWithContext provides the context aware alternative to the UpdateRoute interface.
+// If a route implements both interfaces, UpdateWithContext is preferred.
+type UpdateRouteWithContext interface {
+	// UpdateWithContext represents the method that contains the business logic for updating a resource.
+	// The context is the request context, which is canceled when the client disconnects and carries the values set by middlewares.
+	// The returned error may be a *HttpError to define the response status, other errors are translated by the error mappings of MapError and MapErrorType, unmapped errors result in an internal server error without details.
+	//
+	// Example
+	//  func (m *MyType) UpdateWithContext(ctx context.Context, requestData interface{}) error {
+	//      return m.store.UpdateUser(ctx, m.urlParams["id"], requestData)
+	//  }
+	UpdateWithContext(ctx context.Context, requestData interface{}) error
+}
+
+// UpdateRouteWithResponse provides an alternative to the UpdateRoute interface that returns a response envelope.
+// If a route implements multiple update interfaces, UpdateWithResponse is preferred.
+type UpdateRouteWithResponse interface {
+	// UpdateWithResponse represents the method that contains the business logic for updating a resource.
+	// The returned response defines the status, headers, cookies and body sent to the client.
+	// If the response is nil or does not define a status, 200 OK is used.
+	//
+	// Example
+	//  func (m *MyType) UpdateWithResponse(ctx context.Context, requestData interface{}) (*procroute.Response, error) {
+	//      model, err := m.store.Update(ctx, requestData)
+	//      if err != nil {
+	//          return nil, err
+	//      }
+	//  	return &procroute.Response{
+	//  		Body: model,
+	//  	}, nil
+	//  }
+	UpdateWithResponse(ctx context.Context, requestData interface{}) (*Response, error)
+}
+
 // UpdateRouteRoutePath defines an optional child interface that is used to customize route path.
 type UpdateRouteRoutePath interface {
 	// UpdateRoutePath represents an optional method that can be set to define a custom path for the update route.
@@ -173,6 +298,39 @@ type DeleteRoute interface {
 	Delete(requestData interface{}) *HttpError
 }
 
+// DeleteRouteWithContext provides the context aware alternative to the DeleteRoute interface.
+// If a route implements both interfaces, DeleteWithContext is preferred.
+type DeleteRouteWithContext interface {
+	// DeleteWithContext represents the method that contains the business logic for deleting a resource.
+	// The context is the request context, which is canceled when the client disconnects and carries the values set by middlewares.
+	// The returned error may be a *HttpError to define the response status, other errors are translated by the error mappings of MapError and MapErrorType, unmapped errors result in an internal server error without details.
+	//
+	// Example
+	//  func (m *MyType) DeleteWithContext(ctx context.Context, requestData interface{}) error {
+	//      return m.store.DeleteUser(ctx, m.urlParams["id"])
+	//  }
+	DeleteWithContext(ctx context.Context, requestData interface{}) error
+}
+
+// DeleteRouteWithResponse provides an alternative to the DeleteRoute interface that returns a response envelope.
+// If a route implements multiple delete interfaces, DeleteWithResponse is preferred.
+type DeleteRouteWithResponse interface {
+	// DeleteWithResponse represents the method that contains the business logic for deleting a resource.
+	// The returned response defines the status, headers, cookies and body sent to the client.
+	// If the response is nil or does not define a status, 200 OK is used.
+	//
+	// Example
+	//  func (m *MyType) DeleteWithResponse(ctx context.Context, requestData interface{}) (*procroute.Response, error) {
+	//      if err := m.store.Delete(ctx, requestData); err != nil {
+	//          return nil, err
+	//      }
+	//  	return &procroute.Response{
+	//  		Status: http.StatusNoContent,
+	//  	}, nil
+	//  }
+	DeleteWithResponse(ctx context.Context, requestData interface{}) (*Response, error)
+}
+
 // DeleteRouteRoutePath defines an optional child interface that is used to customize route path.
 type DeleteRouteRoutePath interface {
 	// DeleteRoutePath represents an optional method that can be set to define a custom path for the delete route.
@@ -186,6 +344,79 @@ type DeleteRouteRoutePath interface {
 	DeleteRoutePath() string
 }
 
+// PatchRoute provides the interface that must be implemented to create a Patch endpoint.
+//
+// Besides a request body in the format of the route set parser, patch routes accept JSON Merge Patch (RFC 7396) and JSON Patch (RFC 6902) documents,
+// if the route implements one of the get route interfaces. In that case, the current resource is received by the get route,
+// the patch document is applied to it and the patched resource is passed as request data.
+type PatchRoute interface {
+	// Patch represents the method that contains the business logic for partially updating a resource.
+	//
+	// Example
+	//  type Model struct {
+	//  	Name string `json:"name,omitempty"`
+	//  	URL  string `json:"url,omitempty"`
+	//  }
+	//
+	//  type MyType struct {
+	//  	Model
+	//  }
+	//
+	//  func (m *MyType) Patch(requestData interface{}) *HttpError {
+	//      // do something
+	//      fmt.Printf("%+v\n", requestData)
+	//  	return nil
+	//  }
+	Patch(requestData interface{}) *HttpError
+}
+
+// PatchRouteWithContext provides the context aware alternative to the PatchRoute interface.
+// If a route implements both interfaces, PatchWithContext is preferred.
+type PatchRouteWithContext interface {
+	// PatchWithContext represents the method that contains the business logic for partially updating a resource.
+	// The context is the request context, which is canceled when the client disconnects and carries the values set by middlewares.
+	// The returned error may be a *HttpError to define the response status, other errors are translated by the error mappings of MapError and MapErrorType, unmapped errors result in an internal server error without details.
+	//
+	// Example
+	//  func (m *MyType) PatchWithContext(ctx context.Context, requestData interface{}) error {
+	//      return m.store.UpdateUser(ctx, m.urlParams["id"], requestData)
+	//  }
+	PatchWithContext(ctx context.Context, requestData interface{}) error
+}
+
+// PatchRouteWithResponse provides an alternative to the PatchRoute interface that returns a response envelope.
+// If a route implements multiple patch interfaces, PatchWithResponse is preferred.
+type PatchRouteWithResponse interface {
+	// PatchWithResponse represents the method that contains the business logic for partially updating a resource.
+	// The returned response defines the status, headers, cookies and body sent to the client.
+	// If the response is nil or does not define a status, 200 OK is used.
+	//
+	// Example
+	//  func (m *MyType) PatchWithResponse(ctx context.Context, requestData interface{}) (*procroute.Response, error) {
+	//      model, err := m.store.Update(ctx, m.urlParams["id"], requestData)
+	//      if err != nil {
+	//          return nil, err
+	//      }
+	//  	return &procroute.Response{
+	//  		Body: model,
+	//  	}, nil
+	//  }
+	PatchWithResponse(ctx context.Context, requestData interface{}) (*Response, error)
+}
+
+// PatchRouteRoutePath defines an optional child interface that is used to customize route path.
+type PatchRouteRoutePath interface {
+	// PatchRoutePath represents an optional method that can be set to define a custom path for the patch route.
+	//
+	// Example:
+	//  type MyType struct {}
+	//
+	//  func (m *MyType) PatchRoutePath() string {
+	//  	return "/{id}"
+	//  }
+	PatchRoutePath() string
+}
+
 // RawRoute provides the interface that must be implemented to create a Raw endpoint.
 type RawRoute interface {
 	// Raw represents the method that does nothing for you.
@@ -243,3 +474,18 @@ type QueryParams interface {
 	//  }
 	SetQueryParams(args url.Values)
 }
+
+// RequestMetadata represents an interface that must be implemented if the route works with request headers, cookies or connection details.
+type RequestMetadata interface {
+	// SetRequestMeta represents a method to pass the metadata of the request.
+	//
+	// Example:
+	//  type MyType struct {
+	//  	meta *RequestMeta
+	//  }
+	//
+	//  func (m *MyType) SetRequestMeta(meta *RequestMeta) {
+	//  	m.meta = meta
+	//  }
+	SetRequestMeta(meta *RequestMeta)
+}
@@ -0,0 +1,241 @@
+package procroute
+
+import (
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// acceptRange represents a single media range of the Accept header
+type acceptRange struct {
+	mediaType string
+	quality   float64
+}
+
+// AddParsers provides a method that registers additional parsers within the route set.
+// The parser used to decode the request body is chosen by the Content-Type header, the parser used to encode the response by the Accept header.
+// The parser passed to NewRouteSet is the default parser, which is used if the request does not specify the headers.
+func (rs *RouteSet) AddParsers(parsers ...Parser) *RouteSet {
+	rs.parsers = append(rs.parsers, parsers...)
+	return rs
+}
+
+// allParsers returns the default parser followed by the additional parsers of the route set
+func (rs *RouteSet) allParsers() []Parser {
+	parsers := make([]Parser, 0, len(rs.parsers)+1)
+	if rs.parser != nil {
+		parsers = append(parsers, rs.parser)
+	}
+	for _, parser := range rs.parsers {
+		if parser != nil {
+			parsers = append(parsers, parser)
+		}
+	}
+	return parsers
+}
+
+// mimeTypes returns the mime types of all parsers of the route set
+func (rs *RouteSet) mimeTypes() []string {
+	mimeTypes := []string{}
+	for _, parser := range rs.allParsers() {
+		mimeTypes = append(mimeTypes, parser.MimeType())
+	}
+	return mimeTypes
+}
+
+// mediaTypes returns the media types of all parsers of the route set without parameters
+func (rs *RouteSet) mediaTypes() []string {
+	mediaTypes := []string{}
+	for _, parser := range rs.allParsers() {
+		mediaTypes = append(mediaTypes, mediaType(parser.MimeType()))
+	}
+	return mediaTypes
+}
+
+// decoderFor returns the parser whose mime type matches the Content-Type header of the request.
+// If the request does not specify a Content-Type, the default parser is returned.
+func (rs *RouteSet) decoderFor(r *http.Request) (Parser, *HttpError) {
+	contentType := r.Header.Get("Content-Type")
+	if contentType == "" {
+		return rs.parser, nil
+	}
+
+	contentType = mediaType(contentType)
+	for _, parser := range rs.allParsers() {
+		if mediaType(parser.MimeType()) == contentType {
+			return parser, nil
+		}
+	}
+
+	return nil, &HttpError{
+		Status:    http.StatusUnsupportedMediaType,
+		ErrorCode: "",
+		Message:   "unsupported content type: " + contentType + ", supported: " + strings.Join(rs.mimeTypes(), ", "),
+	}
+}
+
+// encoderFor returns the parser that matches the Accept header of the request best.
+// If the request does not specify an Accept header, the default parser is returned.
+// If multiple parsers are accepted with the same quality, the parser registered first is preferred.
+func (rs *RouteSet) encoderFor(r *http.Request) (Parser, *HttpError) {
+	i, ok := negotiate(r, rs.mediaTypes())
+	if !ok {
+		return nil, &HttpError{
+			Status:    http.StatusNotAcceptable,
+			ErrorCode: "",
+			Message:   "none of the accepted media types is supported, supported: " + strings.Join(rs.mimeTypes(), ", "),
+		}
+	}
+	return rs.allParsers()[i], nil
+}
+
+// writeError sends the error back to the client, encoded by the parser negotiated by the Accept header.
+// Parsers of JSON and XML documents additionally offer the problem details types of RFC 7807, which are used if they are accepted best.
+// If none of the accepted media types is supported, the default parser is used.
+func (rs *RouteSet) writeError(w http.ResponseWriter, r *http.Request, httpErr *HttpError) {
+	varyAccept(w)
+
+	parsers, mediaTypes := []Parser{}, []string{}
+	for _, parser := range rs.allParsers() {
+		parsers, mediaTypes = append(parsers, parser), append(mediaTypes, mediaType(parser.MimeType()))
+		if problemType := problemTypeOf(parser); problemType != "" {
+			parsers, mediaTypes = append(parsers, parser), append(mediaTypes, problemType)
+		}
+	}
+
+	parser, contentType := rs.parser, rs.parser.MimeType()
+	if i, ok := negotiate(r, mediaTypes); ok {
+		parser, contentType = parsers[i], parsers[i].MimeType()
+		if mediaTypes[i] == ProblemJsonMimeType || mediaTypes[i] == ProblemXmlMimeType {
+			contentType = mediaTypes[i]
+		}
+	}
+
+	if err := httpErr.write(contentType, parser, w); err != nil && rs.logger != nil {
+		rs.logger.Error("failed to write error response: %v", err)
+	}
+}
+
+// problemTypeOf returns the problem details type, which can be encoded by the parser, or an empty string if there is none
+func problemTypeOf(parser Parser) string {
+	switch mt := mediaType(parser.MimeType()); {
+	case mt == "application/json" || strings.HasSuffix(mt, "+json"):
+		return ProblemJsonMimeType
+	case mt == "application/xml" || mt == "text/xml" || strings.HasSuffix(mt, "+xml"):
+		return ProblemXmlMimeType
+	}
+	return ""
+}
+
+// negotiate returns the index of the media type that matches the Accept header of the request best.
+// If the request does not specify an Accept header, the first media type is returned.
+// If multiple media types are accepted with the same quality, the first one is preferred.
+// The bool is false, if none of the media types is accepted.
+func negotiate(r *http.Request, mediaTypes []string) (int, bool) {
+	accept := strings.Join(r.Header.Values("Accept"), ",")
+	if strings.TrimSpace(accept) == "" {
+		return 0, len(mediaTypes) > 0
+	}
+
+	ranges := parseAccept(accept)
+	best, bestQuality := -1, 0.0
+	for i, mt := range mediaTypes {
+		if quality := acceptQuality(ranges, mt); quality > bestQuality {
+			best, bestQuality = i, quality
+		}
+	}
+	return best, best >= 0
+}
+
+// varyAccept adds Accept to the Vary header, since the response depends on the Accept header of the request
+func varyAccept(w http.ResponseWriter) {
+	addVary(w.Header(), "Accept")
+}
+
+// addVary adds the request header field to the Vary header, unless it is listed already
+func addVary(header http.Header, name string) {
+	for _, value := range header.Values("Vary") {
+		for _, field := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(field), name) {
+				return
+			}
+		}
+	}
+	header.Add("Vary", name)
+}
+
+// parseAccept parses the Accept header into its media ranges, sorted by descending specificity
+func parseAccept(accept string) []acceptRange {
+	ranges := []acceptRange{}
+	for _, part := range strings.Split(accept, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		mt, params, err := mime.ParseMediaType(part)
+		if err != nil {
+			// keep media ranges that are not valid media types, the quality is 1 in that case
+			mt, params = strings.ToLower(strings.TrimSpace(strings.Split(part, ";")[0])), map[string]string{}
+		}
+		if mt == "*" {
+			mt = "*/*"
+		}
+
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if parsed, err := strconv.ParseFloat(q, 64); err == nil {
+				quality = parsed
+			}
+		}
+		ranges = append(ranges, acceptRange{mediaType: mt, quality: quality})
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return specificity(ranges[i].mediaType) > specificity(ranges[j].mediaType)
+	})
+	return ranges
+}
+
+// specificity returns how specific the media range is, "type/subtype" is more specific than "type/*", which is more specific than "*/*"
+func specificity(mediaRange string) int {
+	switch {
+	case mediaRange == "*/*":
+		return 0
+	case strings.HasSuffix(mediaRange, "/*"):
+		return 1
+	}
+	return 2
+}
+
+// acceptQuality returns the quality of the most specific media range that matches the media type, or 0 if none matches
+func acceptQuality(ranges []acceptRange, mediaType string) float64 {
+	for _, ar := range ranges {
+		if mediaRangeMatches(ar.mediaType, mediaType) {
+			return ar.quality
+		}
+	}
+	return 0
+}
+
+// mediaRangeMatches checks whether the media type is covered by the media range
+func mediaRangeMatches(mediaRange, mediaType string) bool {
+	switch specificity(mediaRange) {
+	case 0:
+		return true
+	case 1:
+		return strings.HasPrefix(mediaType, strings.TrimSuffix(mediaRange, "*"))
+	}
+	return mediaRange == mediaType
+}
+
+// mediaType returns the media type of the content type header without parameters
+func mediaType(contentType string) string {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(contentType))
+	}
+	return mt
+}
@@ -0,0 +1,223 @@
+package procroute
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseAccept(t *testing.T) {
+	tests := []struct {
+		name   string
+		accept string
+		want   []acceptRange
+	}{
+		{
+			name:   "single_type",
+			accept: "application/json",
+			want:   []acceptRange{{mediaType: "application/json", quality: 1}},
+		},
+		{
+			name:   "sorted_by_specificity",
+			accept: "*/*;q=0.1, application/*;q=0.5, application/xml",
+			want: []acceptRange{
+				{mediaType: "application/xml", quality: 1},
+				{mediaType: "application/*", quality: 0.5},
+				{mediaType: "*/*", quality: 0.1},
+			},
+		},
+		{
+			name:   "invalid_quality_and_short_wildcard",
+			accept: "application/json;q=abc, *",
+			want: []acceptRange{
+				{mediaType: "application/json", quality: 1},
+				{mediaType: "*/*", quality: 1},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseAccept(tt.accept); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseAccept() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRouteSet_encoderFor(t *testing.T) {
+	rs := NewRouteSet("/api", &exampleParser{}).AddParsers(&exampleXmlParser{})
+
+	tests := []struct {
+		name       string
+		accept     string
+		wantMime   string
+		wantStatus int
+	}{
+		{
+			name:     "no_accept_header",
+			wantMime: "application/json",
+		},
+		{
+			name:     "exact_match",
+			accept:   "application/xml",
+			wantMime: "application/xml",
+		},
+		{
+			name:     "highest_quality",
+			accept:   "application/json;q=0.5, application/xml;q=0.8",
+			wantMime: "application/xml",
+		},
+		{
+			name:     "wildcard_prefers_registration_order",
+			accept:   "*/*",
+			wantMime: "application/json",
+		},
+		{
+			name:     "most_specific_range_wins",
+			accept:   "application/*;q=0.9, application/json;q=0.1",
+			wantMime: "application/xml",
+		},
+		{
+			name:       "excluded_by_zero_quality",
+			accept:     "application/json;q=0, application/xml;q=0",
+			wantStatus: http.StatusNotAcceptable,
+		},
+		{
+			name:       "not_acceptable",
+			accept:     "text/html",
+			wantStatus: http.StatusNotAcceptable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/api", nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+
+			parser, err := rs.encoderFor(r)
+			if tt.wantStatus != 0 {
+				if err == nil || err.Status != tt.wantStatus {
+					t.Fatalf("RouteSet.encoderFor() error = %+v, want status %v", err, tt.wantStatus)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("RouteSet.encoderFor() error = %v", err)
+			}
+			if parser.MimeType() != tt.wantMime {
+				t.Errorf("RouteSet.encoderFor() = %v, want %v", parser.MimeType(), tt.wantMime)
+			}
+		})
+	}
+}
+
+func TestRouteSet_contentNegotiation(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		contentType string
+		accept      string
+		body        string
+		wantStatus  int
+		wantType    string
+		wantBody    string
+		wantCalled  bool
+	}{
+		{
+			name:        "json_to_xml",
+			method:      "PUT",
+			contentType: "application/json",
+			accept:      "application/xml",
+			body:        `{"Name":"sample","Value":1}`,
+			wantStatus:  http.StatusOK,
+			wantType:    "application/xml",
+			wantBody:    `<data><Name>sample</Name><Value>1</Value></data>`,
+			wantCalled:  true,
+		},
+		{
+			name:        "xml_to_json",
+			method:      "PUT",
+			contentType: "application/xml; charset=utf-8",
+			body:        `<data><Name>sample</Name><Value>1</Value></data>`,
+			wantStatus:  http.StatusOK,
+			wantType:    "application/json",
+			wantBody:    `{"Name":"sample","Value":1}`,
+			wantCalled:  true,
+		},
+		{
+			name:        "unsupported_content_type",
+			method:      "POST",
+			contentType: "text/csv",
+			body:        `sample,1`,
+			wantStatus:  http.StatusUnsupportedMediaType,
+			wantType:    "application/json",
+		},
+		{
+			name:        "unsupported_content_type_without_body",
+			method:      "POST",
+			contentType: "text/csv",
+			wantStatus:  http.StatusCreated,
+			wantType:    "application/json",
+			wantCalled:  true,
+		},
+		{
+			name:        "not_acceptable_is_not_processed",
+			method:      "POST",
+			contentType: "application/json",
+			accept:      "text/html",
+			body:        `{"Name":"sample","Value":1}`,
+			wantStatus:  http.StatusNotAcceptable,
+			wantType:    "application/json",
+		},
+		{
+			name:        "error_encoded_by_accepted_parser",
+			method:      "PUT",
+			contentType: "application/json",
+			accept:      "application/xml",
+			body:        `{"Name":"sample","Value":"one"}`,
+			wantStatus:  http.StatusBadRequest,
+			wantType:    "application/xml",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			route := &negotiationExample{}
+			rs := NewRouteSet("/api", &exampleParser{}).AddParsers(&exampleXmlParser{})
+
+			r := httptest.NewRequest(tt.method, "/api", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				r.Header.Set("Content-Type", tt.contentType)
+			}
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+
+			w := httptest.NewRecorder()
+			switch tt.method {
+			case "POST":
+				rs.definePostRoute(w, r, route)
+			case "PUT":
+				rs.defineUpdateRoute(w, r, route)
+			}
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %v, want %v, body = %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if got := w.Header().Get("Content-Type"); got != tt.wantType {
+				t.Errorf("Content-Type = %v, want %v", got, tt.wantType)
+			}
+			if got := w.Header().Get("Vary"); got != "Accept" {
+				t.Errorf("Vary = %v, want Accept", got)
+			}
+			if tt.wantBody != "" && w.Body.String() != tt.wantBody {
+				t.Errorf("body = %s, want %s", w.Body.String(), tt.wantBody)
+			}
+			if route.called != tt.wantCalled {
+				t.Errorf("route called = %v, want %v", route.called, tt.wantCalled)
+			}
+		})
+	}
+}
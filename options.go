@@ -0,0 +1,158 @@
+package procroute
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// methodOrder defines the order of the http methods within the Allow header
+var methodOrder = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+	http.MethodOptions,
+}
+
+// handle registers the handler for the path and http methods and remembers the path, so that it can be answered for OPTIONS requests.
+// Panics of the handler are recovered and answered with an internal server error, request and response bodies are decompressed and compressed by the compression of the route set.
+func (rs *RouteSet) handle(path string, handler http.HandlerFunc, methods ...string) *mux.Route {
+	if !containsString(rs.paths, path) {
+		rs.paths = append(rs.paths, path)
+	}
+	return rs.router.HandleFunc(path, rs.withRecovery(rs.withContentEncoding(handler))).Methods(methods...)
+}
+
+// registerOptionsRoutes registers an OPTIONS route for each path of the route set, unless a raw route handles OPTIONS requests for the path itself
+func (rs *RouteSet) registerOptionsRoutes() {
+	for _, path := range rs.paths {
+		if rs.customOptionsPaths[path] {
+			continue
+		}
+
+		rs.logger.Debug("registered options route at: %s", path)
+		rs.router.HandleFunc(path, rs.defineOptionsRoute).Methods(http.MethodOptions)
+	}
+}
+
+// defineOptionsRoute answers OPTIONS requests with the methods allowed for the requested path
+func (rs *RouteSet) defineOptionsRoute(w http.ResponseWriter, r *http.Request) {
+	methods := allowedMethods(rs.router, r)
+	w.Header().Set("Allow", strings.Join(methods, ", "))
+
+	if containsString(methods, http.MethodPatch) {
+		if acceptPatch := rs.acceptPatchFor(r); acceptPatch != "" {
+			w.Header().Set("Accept-Patch", acceptPatch)
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// acceptPatchFor returns the Accept-Patch header value of the patch route of this route set that matches the request
+func (rs *RouteSet) acceptPatchFor(r *http.Request) string {
+	req := r.Clone(r.Context())
+	req.Method = http.MethodPatch
+	for route, acceptPatch := range rs.acceptPatch {
+		if route.Match(req, &mux.RouteMatch{}) {
+			return acceptPatch
+		}
+	}
+	return ""
+}
+
+// allowedMethods returns the http methods of all routes registered at the router that match the path of the request
+func allowedMethods(router *mux.Router, r *http.Request) []string {
+	allowed := map[string]bool{}
+	router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		methods, err := route.GetMethods()
+		if err != nil {
+			// routes without method matcher are not taken into account
+			return nil
+		}
+
+		for _, method := range methods {
+			if allowed[method] {
+				continue
+			}
+
+			req := r.Clone(r.Context())
+			req.Method = method
+			if route.Match(req, &mux.RouteMatch{}) {
+				allowed[method] = true
+			}
+		}
+		return nil
+	})
+
+	methods := []string{}
+	for _, method := range methodOrder {
+		if allowed[method] {
+			methods = append(methods, method)
+			delete(allowed, method)
+		}
+	}
+
+	// methods not covered by methodOrder, e.g. registered by raw routes, are appended in alphabetical order
+	others := make([]string, 0, len(allowed))
+	for method := range allowed {
+		others = append(others, method)
+	}
+	sort.Strings(others)
+	return append(methods, others...)
+}
+
+// headResponseWriter discards the body written by a get route, but keeps the headers and sets the Content-Length
+type headResponseWriter struct {
+	http.ResponseWriter
+	status int
+	length int
+	// unknownLength is set by streaming routes, which do not produce the body of HEAD requests
+	unknownLength bool
+}
+
+// WriteHeader delays writing the status until the handler is done, so that the Content-Length can be set
+func (h *headResponseWriter) WriteHeader(status int) {
+	if h.status == 0 {
+		h.status = status
+	}
+}
+
+// Write counts the bytes of the body without writing them
+func (h *headResponseWriter) Write(b []byte) (int, error) {
+	if h.status == 0 {
+		h.status = http.StatusOK
+	}
+	h.length += len(b)
+	return len(b), nil
+}
+
+// finish writes the headers, including the Content-Length of the discarded body
+func (h *headResponseWriter) finish() {
+	if h.status == 0 {
+		h.status = http.StatusOK
+	}
+	if h.Header().Get("Content-Length") == "" && !h.unknownLength && bodyAllowed(h.status) {
+		h.Header().Set("Content-Length", strconv.Itoa(h.length))
+	}
+	h.ResponseWriter.WriteHeader(h.status)
+}
+
+// withHead wraps the handler of a get route, so that HEAD requests run the handler, but do not receive the body
+func withHead(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead {
+			handler(w, r)
+			return
+		}
+
+		hw := &headResponseWriter{ResponseWriter: w}
+		handler(hw, r)
+		hw.finish()
+	}
+}
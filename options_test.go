@@ -0,0 +1,158 @@
+package procroute
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestRouteSet_optionsRoutes(t *testing.T) {
+	router := mux.NewRouter()
+	for _, rs := range []*RouteSet{
+		NewRouteSet("/api", &exampleParser{}).AddRoutes(&fullExample{}),
+		NewRouteSet("/api", &exampleParser{}).AddRoutes(&patchExample{}),
+		NewRouteSet("/api", &exampleParser{}).AddRoutes(&postExample{}),
+		NewRouteSet("/other", &exampleParser{}).AddRoutes(&postExample{err: &HttpError{Status: http.StatusInternalServerError}}),
+	} {
+		rs.withLogger(&exampleLogger{}).withRouter(router)
+		if err := rs.build(); err != nil {
+			t.Fatalf("RouteSet.build() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		name            string
+		target          string
+		wantStatus      int
+		wantAllow       string
+		wantAcceptPatch string
+	}{
+		{
+			name:       "path_shared_by_multiple_routes",
+			target:     "/api/all",
+			wantStatus: http.StatusNoContent,
+			wantAllow:  "GET, HEAD, POST, PUT, DELETE, OPTIONS",
+		},
+		{
+			name:       "path_with_url_params",
+			target:     "/api/1",
+			wantStatus: http.StatusNoContent,
+			wantAllow:  "GET, HEAD, OPTIONS",
+		},
+		{
+			name:            "path_shared_by_multiple_route_sets",
+			target:          "/api",
+			wantStatus:      http.StatusNoContent,
+			wantAllow:       "GET, HEAD, POST, PATCH, OPTIONS",
+			wantAcceptPatch: "application/json, application/merge-patch+json, application/json-patch+json",
+		},
+		{
+			name:       "raw_route_handles_options",
+			target:     "/api/raw",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "options_does_not_call_the_route",
+			target:     "/other",
+			wantStatus: http.StatusNoContent,
+			wantAllow:  "POST, OPTIONS",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("OPTIONS", tt.target, nil))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %v, want %v", w.Code, tt.wantStatus)
+			}
+			if got := w.Header().Get("Allow"); got != tt.wantAllow {
+				t.Errorf("Allow = %v, want %v", got, tt.wantAllow)
+			}
+			if got := w.Header().Get("Accept-Patch"); got != tt.wantAcceptPatch {
+				t.Errorf("Accept-Patch = %v, want %v", got, tt.wantAcceptPatch)
+			}
+		})
+	}
+}
+
+func TestRouteSet_headRoutes(t *testing.T) {
+	router := mux.NewRouter()
+	rs := NewRouteSet("/api", &exampleParser{}).AddRoutes(&responseExample{
+		resp: &Response{
+			Headers: http.Header{"X-Custom": []string{"value"}},
+			Body:    data{Name: "sample", Value: 1},
+		},
+	})
+	rs.withLogger(&exampleLogger{}).withRouter(router)
+	if err := rs.build(); err != nil {
+		t.Fatalf("RouteSet.build() error = %v", err)
+	}
+
+	for _, target := range []string{"/api", "/api/1"} {
+		t.Run(target, func(t *testing.T) {
+			get := httptest.NewRecorder()
+			router.ServeHTTP(get, httptest.NewRequest("GET", target, nil))
+
+			head := httptest.NewRecorder()
+			router.ServeHTTP(head, httptest.NewRequest("HEAD", target, nil))
+
+			if head.Code != get.Code {
+				t.Errorf("status = %v, want %v", head.Code, get.Code)
+			}
+			if head.Body.Len() != 0 {
+				t.Errorf("body = %v, want empty body", head.Body.String())
+			}
+			if got, want := head.Header().Get("Content-Length"), strconv.Itoa(get.Body.Len()); got != want {
+				t.Errorf("Content-Length = %v, want %v", got, want)
+			}
+			for _, key := range []string{"Content-Type", "X-Custom"} {
+				if got, want := head.Header().Get(key), get.Header().Get(key); got != want {
+					t.Errorf("header %s = %v, want %v", key, got, want)
+				}
+			}
+		})
+	}
+}
+
+func Test_allowedMethods(t *testing.T) {
+	router := mux.NewRouter()
+	noop := func(w http.ResponseWriter, r *http.Request) {}
+	router.HandleFunc("/api", noop).Methods("PURGE", "DELETE")
+	router.HandleFunc("/api", noop).Methods("GET", "HEAD")
+	router.HandleFunc("/api/{id}", noop).Methods("PUT")
+	router.HandleFunc("/api", noop)
+
+	tests := []struct {
+		name   string
+		target string
+		want   []string
+	}{
+		{
+			name:   "ordered_methods",
+			target: "/api",
+			want:   []string{"GET", "HEAD", "DELETE", "PURGE"},
+		},
+		{
+			name:   "url_params",
+			target: "/api/1",
+			want:   []string{"PUT"},
+		},
+		{
+			name:   "no_route",
+			target: "/unknown",
+			want:   []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := allowedMethods(router, httptest.NewRequest("OPTIONS", tt.target, nil)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("allowedMethods() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
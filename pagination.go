@@ -0,0 +1,190 @@
+package procroute
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultPageSize is the number of items of a page, if the request does not define a limit
+	DefaultPageSize = 20
+	// DefaultMaxPageSize is the maximum number of items of a page, larger limits are reduced to it
+	DefaultMaxPageSize = 100
+
+	// TotalCountHeader is the response header that contains the total number of items of a paginated route
+	TotalCountHeader = "X-Total-Count"
+)
+
+// GetPageRoute provides an alternative to the GetAllRoute interface that returns a single page of the resources.
+// The page is requested by the page and limit or the cursor and limit query parameters.
+// If a route implements multiple get all interfaces, GetPage is preferred.
+type GetPageRoute interface {
+	// GetPage represents the method that contains the business logic for receiving a page of the resources.
+	// The returned error may be a *HttpError to define the response status, other errors are translated by the error mappings of MapError and MapErrorType, unmapped errors result in an internal server error without details.
+	//
+	// Example
+	//  func (m *MyType) GetPage(ctx context.Context, page procroute.Page, requestData interface{}) (*procroute.PageResult, error) {
+	//      users, total, err := m.store.ListUsers(ctx, page.Offset(), page.Limit)
+	//      if err != nil {
+	//          return nil, err
+	//      }
+	//  	return &procroute.PageResult{Items: users, Total: &total}, nil
+	//  }
+	GetPage(ctx context.Context, page Page, requestData interface{}) (*PageResult, error)
+}
+
+// Page describes the page requested by the client
+type Page struct {
+	// Number is the requested page, starting at 1. It is 1 for cursor based requests.
+	Number int
+	// Limit is the maximum number of items of the page
+	Limit int
+	// Cursor is the opaque cursor sent by the client, which is empty for the first page and page number based requests
+	Cursor string
+}
+
+// Offset returns the number of items before the requested page
+func (p Page) Offset() int {
+	return (p.Number - 1) * p.Limit
+}
+
+// PageResult represents a page of the resources returned by a GetPageRoute
+type PageResult struct {
+	// Items are the resources of the page, which are encoded by the parser of the route set
+	Items []interface{}
+	// Total is the number of all resources, which is sent as X-Total-Count header. A nil total means the total is unknown.
+	Total *int64
+	// NextCursor is the cursor of the next page for cursor based pagination, empty if there is no next page
+	NextCursor string
+	// PrevCursor is the cursor of the previous page for cursor based pagination, empty if there is no previous page
+	PrevCursor string
+}
+
+// SetPageSize provides a method that sets the default and the maximum number of items of pages within the route set.
+// Values less than or equal to zero keep the current setting, by default DefaultPageSize and DefaultMaxPageSize are used.
+func (rs *RouteSet) SetPageSize(defaultSize, maxSize int) *RouteSet {
+	if defaultSize > 0 {
+		rs.defaultPageSize = defaultSize
+	}
+	if maxSize > 0 {
+		rs.maxPageSize = maxSize
+	}
+	return rs
+}
+
+// pageSizes returns the default and the maximum page size of the route set, the default size never exceeds the maximum size
+func (rs *RouteSet) pageSizes() (int, int) {
+	defaultSize, maxSize := rs.defaultPageSize, rs.maxPageSize
+	if defaultSize <= 0 {
+		defaultSize = DefaultPageSize
+	}
+	if maxSize <= 0 {
+		maxSize = DefaultMaxPageSize
+	}
+	if defaultSize > maxSize {
+		defaultSize = maxSize
+	}
+	return defaultSize, maxSize
+}
+
+// parsePage parses the page, limit and cursor query parameters of the request.
+// Limits exceeding the maximum page size of the route set are reduced to the maximum.
+func (rs *RouteSet) parsePage(r *http.Request) (Page, *HttpError) {
+	query := r.URL.Query()
+	defaultSize, maxSize := rs.pageSizes()
+	page := Page{Number: 1, Limit: defaultSize, Cursor: query.Get("cursor")}
+
+	if value := query.Get("page"); value != "" {
+		if page.Cursor != "" {
+			return Page{}, BadRequest("the query parameters page and cursor can not be combined")
+		}
+		number, err := strconv.Atoi(value)
+		if err != nil || number < 1 {
+			return Page{}, BadRequest("the query parameter page must be a positive integer")
+		}
+		page.Number = number
+	}
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			return Page{}, BadRequest("the query parameter limit must be a positive integer")
+		}
+		page.Limit = limit
+	}
+	if page.Limit > maxSize {
+		page.Limit = maxSize
+	}
+	// the offset of the page following the requested one must not overflow
+	if page.Number > (math.MaxInt-page.Limit)/page.Limit {
+		return Page{}, BadRequest("the query parameter page is too large")
+	}
+	return page, nil
+}
+
+// pageHeaders returns the X-Total-Count and the Link header of RFC 8288 of the page
+func pageHeaders(r *http.Request, page Page, result *PageResult) http.Header {
+	headers := http.Header{}
+	if result.Total != nil {
+		headers.Set(TotalCountHeader, strconv.FormatInt(*result.Total, 10))
+	}
+	if links := pageLinks(r.URL, page, result); len(links) > 0 {
+		headers.Set("Link", strings.Join(links, ", "))
+	}
+	return headers
+}
+
+// pageLinks returns the first, prev, next and last links of the page.
+// Cursor based pages only link the first, previous and next page, since the last page is unknown.
+func pageLinks(u *url.URL, page Page, result *PageResult) []string {
+	links := []string{}
+	link := func(rel string, params map[string]string) {
+		query := u.Query()
+		query.Del("page")
+		query.Del("cursor")
+		query.Set("limit", strconv.Itoa(page.Limit))
+		for key, value := range params {
+			query.Set(key, value)
+		}
+		links = append(links, fmt.Sprintf(`<%s?%s>; rel="%s"`, u.Path, query.Encode(), rel))
+	}
+
+	if page.Cursor != "" || result.NextCursor != "" || result.PrevCursor != "" {
+		link("first", nil)
+		if result.PrevCursor != "" {
+			link("prev", map[string]string{"cursor": result.PrevCursor})
+		}
+		if result.NextCursor != "" {
+			link("next", map[string]string{"cursor": result.NextCursor})
+		}
+		return links
+	}
+
+	link("first", map[string]string{"page": "1"})
+	if page.Number > 1 {
+		link("prev", map[string]string{"page": strconv.Itoa(page.Number - 1)})
+	}
+
+	// without a total, a full page indicates that there might be a next page
+	hasNext := len(result.Items) >= page.Limit
+	if result.Total != nil {
+		hasNext = int64(page.Number)*int64(page.Limit) < *result.Total
+	}
+	if hasNext {
+		link("next", map[string]string{"page": strconv.Itoa(page.Number + 1)})
+	}
+
+	if result.Total != nil {
+		last := (*result.Total + int64(page.Limit) - 1) / int64(page.Limit)
+		if last < 1 {
+			last = 1
+		}
+		link("last", map[string]string{"page": strconv.FormatInt(last, 10)})
+	}
+	return links
+}
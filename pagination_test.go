@@ -0,0 +1,177 @@
+package procroute
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestRouteSet_getPageRoute(t *testing.T) {
+	tests := []struct {
+		name       string
+		route      *pageExample
+		query      string
+		wantStatus int
+		wantPage   Page
+		wantBody   string
+		wantTotal  string
+		wantLink   string
+	}{
+		{
+			name:       "defaults",
+			route:      &pageExample{total: 3},
+			wantStatus: http.StatusOK,
+			wantPage:   Page{Number: 1, Limit: 2},
+			wantBody:   `[0,1]`,
+			wantTotal:  "3",
+			wantLink:   `</api/items?limit=2&page=1>; rel="first", </api/items?limit=2&page=2>; rel="next", </api/items?limit=2&page=2>; rel="last"`,
+		},
+		{
+			name:       "last_page_keeps_query",
+			route:      &pageExample{total: 5},
+			query:      "?page=3&limit=2&sort=name",
+			wantStatus: http.StatusOK,
+			wantPage:   Page{Number: 3, Limit: 2},
+			wantBody:   `[4]`,
+			wantTotal:  "5",
+			wantLink:   `</api/items?limit=2&page=1&sort=name>; rel="first", </api/items?limit=2&page=2&sort=name>; rel="prev", </api/items?limit=2&page=3&sort=name>; rel="last"`,
+		},
+		{
+			name:       "limit_reduced_to_maximum",
+			route:      &pageExample{total: 0},
+			query:      "?limit=50",
+			wantStatus: http.StatusOK,
+			wantPage:   Page{Number: 1, Limit: 5},
+			wantBody:   `[]`,
+			wantTotal:  "0",
+			wantLink:   `</api/items?limit=5&page=1>; rel="first", </api/items?limit=5&page=1>; rel="last"`,
+		},
+		{
+			name:       "unknown_total",
+			route:      &pageExample{total: 5, unknownTotal: true},
+			query:      "?page=2",
+			wantStatus: http.StatusOK,
+			wantPage:   Page{Number: 2, Limit: 2},
+			wantBody:   `[2,3]`,
+			wantLink:   `</api/items?limit=2&page=1>; rel="first", </api/items?limit=2&page=1>; rel="prev", </api/items?limit=2&page=3>; rel="next"`,
+		},
+		{
+			name:       "cursor",
+			route:      &pageExample{total: 6, cursor: true},
+			query:      "?cursor=2&limit=2",
+			wantStatus: http.StatusOK,
+			wantPage:   Page{Number: 1, Limit: 2, Cursor: "2"},
+			wantBody:   `[2,3]`,
+			wantLink:   `</api/items?limit=2>; rel="first", </api/items?cursor=0&limit=2>; rel="prev", </api/items?cursor=4&limit=2>; rel="next"`,
+		},
+		{
+			name:       "invalid_page",
+			route:      &pageExample{},
+			query:      "?page=0",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "page_overflows_offset",
+			route:      &pageExample{},
+			query:      "?page=9223372036854775807&limit=2",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "largest_page",
+			route:      &pageExample{total: 3},
+			query:      fmt.Sprintf("?page=%d&limit=1", math.MaxInt-1),
+			wantStatus: http.StatusOK,
+			wantPage:   Page{Number: math.MaxInt - 1, Limit: 1},
+			wantBody:   `[]`,
+			wantTotal:  "3",
+			wantLink:   fmt.Sprintf(`</api/items?limit=1&page=1>; rel="first", </api/items?limit=1&page=%d>; rel="prev", </api/items?limit=1&page=3>; rel="last"`, math.MaxInt-2),
+		},
+		{
+			name:       "invalid_limit",
+			route:      &pageExample{},
+			query:      "?limit=ten",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "page_and_cursor",
+			route:      &pageExample{},
+			query:      "?page=2&cursor=abc",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "route_error",
+			route:      &pageExample{cursor: true},
+			query:      "?cursor=invalid",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rm := NewRouteMachine("", 0, "/api", &exampleLogger{})
+			if err := rm.AddRouteSet(NewRouteSet("/items", &exampleParser{}).SetPageSize(2, 5).AddRoutes(tt.route)); err != nil {
+				t.Fatalf("RouteMachine.AddRouteSet() error = %v", err)
+			}
+
+			w := httptest.NewRecorder()
+			rm.ServeHTTP(w, httptest.NewRequest("GET", "/api/items"+tt.query, nil))
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %v, want %v, body = %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			if !reflect.DeepEqual(tt.route.page, tt.wantPage) {
+				t.Errorf("page = %+v, want %+v", tt.route.page, tt.wantPage)
+			}
+			if w.Body.String() != tt.wantBody {
+				t.Errorf("body = %s, want %s", w.Body.String(), tt.wantBody)
+			}
+			if got := w.Header().Get(TotalCountHeader); got != tt.wantTotal {
+				t.Errorf("%s = %v, want %v", TotalCountHeader, got, tt.wantTotal)
+			}
+			if got := w.Header().Get("Link"); got != tt.wantLink {
+				t.Errorf("Link = %v, want %v", got, tt.wantLink)
+			}
+		})
+	}
+}
+
+func TestRouteSet_pageSizes(t *testing.T) {
+	tests := []struct {
+		name        string
+		rs          *RouteSet
+		wantDefault int
+		wantMax     int
+	}{
+		{
+			name:        "defaults",
+			rs:          NewRouteSet("/api", &exampleParser{}),
+			wantDefault: DefaultPageSize,
+			wantMax:     DefaultMaxPageSize,
+		},
+		{
+			name:        "default_exceeds_maximum",
+			rs:          NewRouteSet("/api", &exampleParser{}).SetPageSize(50, 10),
+			wantDefault: 10,
+			wantMax:     10,
+		},
+		{
+			name:        "keep_current_setting",
+			rs:          NewRouteSet("/api", &exampleParser{}).SetPageSize(5, 0),
+			wantDefault: 5,
+			wantMax:     DefaultMaxPageSize,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotDefault, gotMax := tt.rs.pageSizes()
+			if gotDefault != tt.wantDefault || gotMax != tt.wantMax {
+				t.Errorf("RouteSet.pageSizes() = %v, %v, want %v, %v", gotDefault, gotMax, tt.wantDefault, tt.wantMax)
+			}
+		})
+	}
+}
@@ -1,5 +1,7 @@
 package procroute
 
+import "io"
+
 // Parser provides the interface that must be implemented to marshal and unmarshal the data sent during http request and http responses.
 type Parser interface {
 	// Unmarshal parses the encoded data and stores the result in the value pointed to by v. If v is nil or not a pointer, Unmarshal returns an error.
@@ -25,3 +27,37 @@ type Parser interface {
 	//  }
 	MimeType() string
 }
+
+// Decoder reads and decodes values from an input stream.
+// It is an alias, so that parsers can implement the StreamParser interface without importing this package.
+type Decoder = interface {
+	// Decode reads the next encoded value from the input stream and stores it in the value pointed to by v
+	Decode(v interface{}) error
+}
+
+// Encoder writes encoded values to an output stream.
+// It is an alias, so that parsers can implement the StreamParser interface without importing this package.
+type Encoder = interface {
+	// Encode writes the encoding of v to the output stream
+	Encode(v interface{}) error
+}
+
+// StreamParser provides an optional interface for parsers that decode from and encode to streams.
+// If the parser of a request implements it, the request body is decoded while it is read and responses are encoded straight into the response writer,
+// instead of buffering the whole body as byte slice. Parsers that only implement the Parser interface keep working unchanged.
+type StreamParser interface {
+	// NewDecoder returns a decoder that reads a single value from r.
+	//
+	// Example:
+	//  func (m *JsonParser) NewDecoder(r io.Reader) procroute.Decoder {
+	//  	return json.NewDecoder(r)
+	//  }
+	NewDecoder(r io.Reader) Decoder
+	// NewEncoder returns an encoder that writes a single value to w.
+	//
+	// Example:
+	//  func (m *JsonParser) NewEncoder(w io.Writer) procroute.Encoder {
+	//  	return json.NewEncoder(w)
+	//  }
+	NewEncoder(w io.Writer) Encoder
+}
@@ -0,0 +1,471 @@
+package parsers
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// maxBinaryDepth limits the nesting of encoded and decoded values to protect against cyclic values and malicious documents
+const maxBinaryDepth = 1000
+
+var (
+	ErrMaxDepthExceeded = errors.New("maximum nesting depth exceeded")
+	ErrUnexpectedEnd    = errors.New("unexpected end of data")
+	ErrInvalidData      = errors.New("invalid data")
+)
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	jsonNumberType = reflect.TypeOf(json.Number(""))
+)
+
+// binaryWriter is implemented by the binary formats to write the items of the document
+type binaryWriter interface {
+	writeNil()
+	writeBool(b bool)
+	writeInt(i int64)
+	writeUint(u uint64)
+	writeFloat32(f float32)
+	writeFloat64(f float64)
+	writeString(s string)
+	writeBytes(b []byte)
+	writeTime(t time.Time)
+	writeArrayHeader(length int)
+	writeMapHeader(length int)
+}
+
+// binaryMap is the decoded representation of a map, which keeps the order and supports keys of any type
+type binaryMap []binaryMapEntry
+
+// binaryMapEntry represents a key value pair of a decoded map
+type binaryMapEntry struct {
+	key   interface{}
+	value interface{}
+}
+
+// encodeBinary writes the value by the writer of the binary format.
+// Structs are written as maps of their fields named by the passed tag, the json tag or the field name, in that order.
+func encodeBinary(w binaryWriter, v reflect.Value, tag string, depth int) error {
+	if depth > maxBinaryDepth {
+		return ErrMaxDepthExceeded
+	}
+
+	v = indirect(v)
+	if !v.IsValid() {
+		w.writeNil()
+		return nil
+	}
+
+	switch t := v.Type(); {
+	case t == timeType:
+		w.writeTime(v.Interface().(time.Time))
+		return nil
+	case t == jsonNumberType:
+		return encodeJsonNumber(w, json.Number(v.String()))
+	case t.Kind() != reflect.String && isText(t):
+		text, err := formatValue(v)
+		if err != nil {
+			return err
+		}
+		w.writeString(text)
+		return nil
+	case (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) && t.Elem().Kind() == reflect.Uint8:
+		if t.Kind() == reflect.Slice {
+			w.writeBytes(v.Bytes())
+			return nil
+		}
+		bts := make([]byte, v.Len())
+		reflect.Copy(reflect.ValueOf(bts), v)
+		w.writeBytes(bts)
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		w.writeBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		w.writeInt(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		w.writeUint(v.Uint())
+	case reflect.Float32:
+		w.writeFloat32(float32(v.Float()))
+	case reflect.Float64:
+		w.writeFloat64(v.Float())
+	case reflect.String:
+		w.writeString(v.String())
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			w.writeNil()
+			return nil
+		}
+		w.writeArrayHeader(v.Len())
+		for i := 0; i < v.Len(); i++ {
+			if err := encodeBinary(w, v.Index(i), tag, depth+1); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if v.IsNil() {
+			w.writeNil()
+			return nil
+		}
+		// sort the keys to get a deterministic encoding
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+		})
+		w.writeMapHeader(len(keys))
+		for _, key := range keys {
+			if err := encodeBinary(w, key, tag, depth+1); err != nil {
+				return err
+			}
+			if err := encodeBinary(w, v.MapIndex(key), tag, depth+1); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		type entry struct {
+			name  string
+			value reflect.Value
+		}
+		entries := []entry{}
+		for _, f := range structFields(v.Type(), tag) {
+			fv := fieldByIndex(v, f.index, false)
+			if !fv.IsValid() || (f.omitEmpty && isEmptyValue(fv)) {
+				continue
+			}
+			entries = append(entries, entry{name: f.name, value: fv})
+		}
+		w.writeMapHeader(len(entries))
+		for _, e := range entries {
+			w.writeString(e.name)
+			if err := encodeBinary(w, e.value, tag, depth+1); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("%w: %s", ErrUnsupportedType, v.Type())
+	}
+	return nil
+}
+
+// encodeJsonNumber writes the number as integer, if possible, otherwise as float
+func encodeJsonNumber(w binaryWriter, n json.Number) error {
+	if i, err := n.Int64(); err == nil {
+		w.writeInt(i)
+		return nil
+	}
+	if u, err := strconv.ParseUint(string(n), 10, 64); err == nil {
+		w.writeUint(u)
+		return nil
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return err
+	}
+	w.writeFloat64(f)
+	return nil
+}
+
+// isEmptyValue reports whether the value is omitted by the omitempty option, following the rules of encoding/json
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
+
+// decodeBinary stores the decoded item in the value pointed to by v
+func decodeBinary(item interface{}, v interface{}, tag string) error {
+	rv, err := target(v)
+	if err != nil {
+		return err
+	}
+	return assignBinary(item, rv, tag)
+}
+
+// assignBinary stores the decoded item in the settable value.
+// Empty interfaces receive the same representation as encoding/json produces: maps with string keys, slices, float64 numbers,
+// strings and booleans. Byte strings and timestamps, which are not known to JSON, are represented as []byte and time.Time.
+func assignBinary(item interface{}, v reflect.Value, tag string) error {
+	if v.Kind() == reflect.Ptr {
+		if item == nil {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return assignBinary(item, v.Elem(), tag)
+	}
+
+	if v.Kind() == reflect.Interface {
+		if item == nil {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+		generic := genericValue(item)
+		if !reflect.TypeOf(generic).AssignableTo(v.Type()) {
+			return mismatch(item, v.Type())
+		}
+		v.Set(reflect.ValueOf(generic))
+		return nil
+	}
+
+	if item == nil {
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+
+	switch t := v.Type(); {
+	case t == timeType:
+		return assignTime(item, v)
+	case t.Kind() != reflect.String && v.CanAddr() && v.Addr().Type().Implements(textUnmarshalerType):
+		if src, ok := item.(string); ok {
+			return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(src))
+		}
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8:
+		switch src := item.(type) {
+		case []byte:
+			v.SetBytes(append([]byte{}, src...))
+			return nil
+		case string:
+			v.SetBytes([]byte(src))
+			return nil
+		}
+		// arrays of numbers are assigned element by element below
+	case t.Kind() == reflect.Array && t.Elem().Kind() == reflect.Uint8:
+		if src, ok := item.([]byte); ok {
+			if len(src) != v.Len() {
+				return fmt.Errorf("%w: %d bytes do not fit into %s", ErrInvalidData, len(src), t)
+			}
+			reflect.Copy(v, reflect.ValueOf(src))
+			return nil
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		if b, ok := item.(bool); ok {
+			v.SetBool(b)
+			return nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, ok := toInt64(item)
+		if ok && !v.OverflowInt(i) {
+			v.SetInt(i)
+			return nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u, ok := toUint64(item)
+		if ok && !v.OverflowUint(u) {
+			v.SetUint(u)
+			return nil
+		}
+	case reflect.Float32, reflect.Float64:
+		if f, ok := toFloat64(item); ok {
+			v.SetFloat(f)
+			return nil
+		}
+	case reflect.String:
+		switch src := item.(type) {
+		case string:
+			v.SetString(src)
+			return nil
+		case []byte:
+			v.SetString(string(src))
+			return nil
+		}
+	case reflect.Slice:
+		if src, ok := item.([]interface{}); ok {
+			slice := reflect.MakeSlice(v.Type(), len(src), len(src))
+			for i, elem := range src {
+				if err := assignBinary(elem, slice.Index(i), tag); err != nil {
+					return err
+				}
+			}
+			v.Set(slice)
+			return nil
+		}
+	case reflect.Array:
+		if src, ok := item.([]interface{}); ok {
+			if len(src) != v.Len() {
+				return fmt.Errorf("%w: %d elements do not fit into %s", ErrInvalidData, len(src), v.Type())
+			}
+			for i, elem := range src {
+				if err := assignBinary(elem, v.Index(i), tag); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	case reflect.Map:
+		if src, ok := item.(binaryMap); ok {
+			m := reflect.MakeMapWithSize(v.Type(), len(src))
+			for _, entry := range src {
+				key := reflect.New(v.Type().Key()).Elem()
+				if err := assignMapKey(entry.key, key, tag); err != nil {
+					return err
+				}
+				value := reflect.New(v.Type().Elem()).Elem()
+				if err := assignBinary(entry.value, value, tag); err != nil {
+					return err
+				}
+				m.SetMapIndex(key, value)
+			}
+			v.Set(m)
+			return nil
+		}
+	case reflect.Struct:
+		if src, ok := item.(binaryMap); ok {
+			fields := map[string]field{}
+			for _, f := range structFields(v.Type(), tag) {
+				fields[f.name] = f
+			}
+			for _, entry := range src {
+				name, ok := entry.key.(string)
+				if !ok {
+					continue
+				}
+				f, ok := fields[name]
+				if !ok {
+					continue
+				}
+				if err := assignBinary(entry.value, fieldByIndex(v, f.index, true), tag); err != nil {
+					return fmt.Errorf("field %q: %w", name, err)
+				}
+			}
+			return nil
+		}
+	}
+	return mismatch(item, v.Type())
+}
+
+// assignMapKey stores the decoded key in the settable map key. Keys of other types than the map key type are converted from their text representation.
+func assignMapKey(item interface{}, v reflect.Value, tag string) error {
+	if err := assignBinary(item, v, tag); err == nil {
+		return nil
+	}
+	return parseValue(fmt.Sprint(genericValue(item)), v)
+}
+
+// assignTime stores the decoded timestamp, RFC 3339 string or unix time in seconds in the settable time.Time value
+func assignTime(item interface{}, v reflect.Value) error {
+	switch src := item.(type) {
+	case time.Time:
+		v.Set(reflect.ValueOf(src))
+		return nil
+	case string:
+		t, err := time.Parse(time.RFC3339Nano, src)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	}
+	if f, ok := toFloat64(item); ok {
+		sec, frac := math.Modf(f)
+		v.Set(reflect.ValueOf(time.Unix(int64(sec), int64(frac*1e9)).UTC()))
+		return nil
+	}
+	return mismatch(item, v.Type())
+}
+
+// genericValue converts the decoded item into the representation that encoding/json uses for empty interfaces
+func genericValue(item interface{}) interface{} {
+	switch src := item.(type) {
+	case int64:
+		return float64(src)
+	case uint64:
+		return float64(src)
+	case float32:
+		return float64(src)
+	case []interface{}:
+		slice := make([]interface{}, len(src))
+		for i, elem := range src {
+			slice[i] = genericValue(elem)
+		}
+		return slice
+	case binaryMap:
+		m := make(map[string]interface{}, len(src))
+		for _, entry := range src {
+			key, ok := entry.key.(string)
+			if !ok {
+				key = fmt.Sprint(genericValue(entry.key))
+			}
+			m[key] = genericValue(entry.value)
+		}
+		return m
+	}
+	return item
+}
+
+// toInt64 converts decoded numbers into an int64, if it can be represented without loss
+func toInt64(item interface{}) (int64, bool) {
+	switch src := item.(type) {
+	case int64:
+		return src, true
+	case uint64:
+		return int64(src), src <= math.MaxInt64
+	case float32:
+		return toInt64(float64(src))
+	case float64:
+		i := int64(src)
+		return i, float64(i) == src && src >= math.MinInt64 && src < math.MaxInt64
+	}
+	return 0, false
+}
+
+// toUint64 converts decoded numbers into an uint64, if it can be represented without loss
+func toUint64(item interface{}) (uint64, bool) {
+	switch src := item.(type) {
+	case int64:
+		return uint64(src), src >= 0
+	case uint64:
+		return src, true
+	case float32:
+		return toUint64(float64(src))
+	case float64:
+		u := uint64(src)
+		return u, src >= 0 && float64(u) == src && src < math.MaxUint64
+	}
+	return 0, false
+}
+
+// toFloat64 converts decoded numbers into a float64
+func toFloat64(item interface{}) (float64, bool) {
+	switch src := item.(type) {
+	case int64:
+		return float64(src), true
+	case uint64:
+		return float64(src), true
+	case float32:
+		return float64(src), true
+	case float64:
+		return src, true
+	}
+	return 0, false
+}
+
+// mismatch returns the error for decoded items that do not fit into the target type
+func mismatch(item interface{}, t reflect.Type) error {
+	return fmt.Errorf("%w: cannot decode %T into %s", ErrInvalidData, item, t)
+}
@@ -0,0 +1,440 @@
+package parsers
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"reflect"
+	"time"
+	"unicode/utf8"
+)
+
+const CborMimeType = "application/cbor"
+
+// major types defined by RFC 8949
+const (
+	cborUint   byte = 0
+	cborNegInt byte = 1
+	cborBytes  byte = 2
+	cborText   byte = 3
+	cborArray  byte = 4
+	cborMap    byte = 5
+	cborTag    byte = 6
+	cborSimple byte = 7
+)
+
+// tags of date and time values defined by RFC 8949
+const (
+	cborTagDateTime  = 0
+	cborTagEpochTime = 1
+)
+
+// cborIndefinite is the additional information of items with indefinite length, cborBreak terminates them
+const (
+	cborIndefinite = 31
+	cborBreak      = 0xff
+)
+
+// CborParser implements the procroute.Parser interface for CBOR documents as defined by RFC 8949.
+// Struct fields are named by the cbor tag, the json tag or the field name, in that order, and the omitempty option is honoured.
+// time.Time values are encoded as RFC 3339 strings with the date/time tag and []byte values as byte strings.
+// Items with indefinite length and epoch based timestamps are supported when decoding.
+//
+// Example:
+//  rs := procroute.NewRouteSet("/example", &parsers.JsonParser{}).AddParsers(&parsers.CborParser{})
+type CborParser struct{}
+
+// Unmarshal decodes the CBOR document into the value pointed to by v
+func (p *CborParser) Unmarshal(data []byte, v interface{}) error {
+	reader := &cborReader{data: data}
+	item, err := reader.read(0)
+	if err != nil {
+		return err
+	}
+	if reader.pos != len(data) {
+		return ErrTrailingData
+	}
+	return decodeBinary(item, v, "cbor")
+}
+
+// Marshal encodes the value as CBOR document
+func (p *CborParser) Marshal(v interface{}) ([]byte, error) {
+	w := &cborWriter{}
+	if err := encodeBinary(w, reflect.ValueOf(v), "cbor", 0); err != nil {
+		return nil, err
+	}
+	return w.buf.Bytes(), nil
+}
+
+// MimeType returns the mime type of CBOR documents
+func (p *CborParser) MimeType() string {
+	return CborMimeType
+}
+
+// FieldName returns the name the struct field is encoded with, which is used to select fields of responses
+func (p *CborParser) FieldName(field reflect.StructField) (string, bool) {
+	return encodedFieldName(field, "cbor")
+}
+
+// cborWriter writes the items of a CBOR document with the shortest argument encoding
+type cborWriter struct {
+	buf bytes.Buffer
+}
+
+// writeHead writes the major type and its argument
+func (w *cborWriter) writeHead(major byte, argument uint64) {
+	major <<= 5
+	switch {
+	case argument < 24:
+		w.buf.WriteByte(major | byte(argument))
+	case argument <= math.MaxUint8:
+		w.buf.Write([]byte{major | 24, byte(argument)})
+	case argument <= math.MaxUint16:
+		w.buf.WriteByte(major | 25)
+		w.writeBig(argument, 2)
+	case argument <= math.MaxUint32:
+		w.buf.WriteByte(major | 26)
+		w.writeBig(argument, 4)
+	default:
+		w.buf.WriteByte(major | 27)
+		w.writeBig(argument, 8)
+	}
+}
+
+// writeBig writes the n least significant bytes of the unsigned integer in big endian order
+func (w *cborWriter) writeBig(u uint64, n int) {
+	for i := n - 1; i >= 0; i-- {
+		w.buf.WriteByte(byte(u >> (8 * i)))
+	}
+}
+
+func (w *cborWriter) writeNil() {
+	w.buf.WriteByte(cborSimple<<5 | 22)
+}
+
+func (w *cborWriter) writeBool(b bool) {
+	if b {
+		w.buf.WriteByte(cborSimple<<5 | 21)
+		return
+	}
+	w.buf.WriteByte(cborSimple<<5 | 20)
+}
+
+func (w *cborWriter) writeInt(i int64) {
+	if i >= 0 {
+		w.writeHead(cborUint, uint64(i))
+		return
+	}
+	w.writeHead(cborNegInt, uint64(-(i + 1)))
+}
+
+func (w *cborWriter) writeUint(u uint64) {
+	w.writeHead(cborUint, u)
+}
+
+func (w *cborWriter) writeFloat32(f float32) {
+	w.buf.WriteByte(cborSimple<<5 | 26)
+	w.writeBig(uint64(math.Float32bits(f)), 4)
+}
+
+func (w *cborWriter) writeFloat64(f float64) {
+	w.buf.WriteByte(cborSimple<<5 | 27)
+	w.writeBig(math.Float64bits(f), 8)
+}
+
+func (w *cborWriter) writeString(s string) {
+	w.writeHead(cborText, uint64(len(s)))
+	w.buf.WriteString(s)
+}
+
+func (w *cborWriter) writeBytes(b []byte) {
+	w.writeHead(cborBytes, uint64(len(b)))
+	w.buf.Write(b)
+}
+
+func (w *cborWriter) writeTime(t time.Time) {
+	w.writeHead(cborTag, cborTagDateTime)
+	w.writeString(t.Format(time.RFC3339Nano))
+}
+
+func (w *cborWriter) writeArrayHeader(length int) {
+	w.writeHead(cborArray, uint64(length))
+}
+
+func (w *cborWriter) writeMapHeader(length int) {
+	w.writeHead(cborMap, uint64(length))
+}
+
+// cborReader reads the items of a CBOR document into their decoded representation
+type cborReader struct {
+	data []byte
+	pos  int
+}
+
+// next returns the next n bytes of the document
+func (r *cborReader) next(n uint64) ([]byte, error) {
+	if n > uint64(len(r.data)-r.pos) {
+		return nil, ErrUnexpectedEnd
+	}
+	bts := r.data[r.pos : r.pos+int(n)]
+	r.pos += int(n)
+	return bts, nil
+}
+
+// atBreak reports whether the next byte terminates an item of indefinite length and consumes it
+func (r *cborReader) atBreak() (bool, error) {
+	if r.pos >= len(r.data) {
+		return false, ErrUnexpectedEnd
+	}
+	if r.data[r.pos] == cborBreak {
+		r.pos++
+		return true, nil
+	}
+	return false, nil
+}
+
+// readHead reads the major type, the additional information and the argument of the next item
+func (r *cborReader) readHead() (byte, byte, uint64, error) {
+	head, err := r.next(1)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	major, info := head[0]>>5, head[0]&0x1f
+
+	switch {
+	case info < 24:
+		return major, info, uint64(info), nil
+	case info <= 27:
+		bts, err := r.next(1 << (info - 24))
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		var argument uint64
+		for _, b := range bts {
+			argument = argument<<8 | uint64(b)
+		}
+		return major, info, argument, nil
+	case info == cborIndefinite:
+		return major, info, 0, nil
+	}
+	return 0, 0, 0, fmt.Errorf("%w: reserved additional information %d", ErrInvalidData, info)
+}
+
+// read decodes the next item of the document
+func (r *cborReader) read(depth int) (interface{}, error) {
+	if depth > maxBinaryDepth {
+		return nil, ErrMaxDepthExceeded
+	}
+
+	major, info, argument, err := r.readHead()
+	if err != nil {
+		return nil, err
+	}
+	indefinite := info == cborIndefinite
+
+	switch major {
+	case cborUint, cborNegInt:
+		if indefinite {
+			break
+		}
+		if major == cborNegInt {
+			if argument > math.MaxInt64 {
+				return -1 - float64(argument), nil
+			}
+			return -1 - int64(argument), nil
+		}
+		if argument > math.MaxInt64 {
+			return argument, nil
+		}
+		return int64(argument), nil
+	case cborBytes, cborText:
+		bts, err := r.readString(major, argument, indefinite)
+		if err != nil {
+			return nil, err
+		}
+		if major == cborBytes {
+			return bts, nil
+		}
+		if !utf8.Valid(bts) {
+			return nil, fmt.Errorf("%w: text string is not valid UTF-8", ErrInvalidData)
+		}
+		return string(bts), nil
+	case cborArray:
+		return r.readArray(argument, indefinite, depth)
+	case cborMap:
+		return r.readMap(argument, indefinite, depth)
+	case cborTag:
+		if indefinite {
+			break
+		}
+		return r.readTag(argument, depth)
+	case cborSimple:
+		return r.readSimple(info, argument)
+	}
+	return nil, fmt.Errorf("%w: indefinite length is not allowed for major type %d", ErrInvalidData, major)
+}
+
+// readString reads the content of a byte or text string, strings of indefinite length are concatenated from their chunks
+func (r *cborReader) readString(major byte, length uint64, indefinite bool) ([]byte, error) {
+	if !indefinite {
+		bts, err := r.next(length)
+		return append([]byte{}, bts...), err
+	}
+
+	buf := []byte{}
+	for {
+		done, err := r.atBreak()
+		if err != nil {
+			return nil, err
+		}
+		if done {
+			return buf, nil
+		}
+
+		chunkMajor, info, chunkLength, err := r.readHead()
+		if err != nil {
+			return nil, err
+		}
+		if chunkMajor != major || info == cborIndefinite {
+			return nil, fmt.Errorf("%w: invalid chunk of string with indefinite length", ErrInvalidData)
+		}
+		chunk, err := r.next(chunkLength)
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, chunk...)
+	}
+}
+
+func (r *cborReader) readArray(length uint64, indefinite bool, depth int) (interface{}, error) {
+	if indefinite {
+		slice := []interface{}{}
+		for {
+			done, err := r.atBreak()
+			if err != nil {
+				return nil, err
+			}
+			if done {
+				return slice, nil
+			}
+			item, err := r.read(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			slice = append(slice, item)
+		}
+	}
+
+	// each item takes at least one byte, which protects against huge allocations
+	if length > uint64(len(r.data)-r.pos) {
+		return nil, ErrUnexpectedEnd
+	}
+	slice := make([]interface{}, length)
+	for i := range slice {
+		item, err := r.read(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		slice[i] = item
+	}
+	return slice, nil
+}
+
+func (r *cborReader) readMap(length uint64, indefinite bool, depth int) (interface{}, error) {
+	m := binaryMap{}
+	if !indefinite {
+		// each entry takes at least two bytes, which protects against huge allocations
+		if length > uint64(len(r.data)-r.pos)/2 {
+			return nil, ErrUnexpectedEnd
+		}
+		m = make(binaryMap, 0, length)
+	}
+
+	for i := uint64(0); indefinite || i < length; i++ {
+		if indefinite {
+			done, err := r.atBreak()
+			if err != nil {
+				return nil, err
+			}
+			if done {
+				break
+			}
+		}
+
+		key, err := r.read(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		value, err := r.read(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		m = append(m, binaryMapEntry{key: key, value: value})
+	}
+	return m, nil
+}
+
+// readTag reads the tagged item. Date and time tags are decoded into time.Time, the content of other tags is returned as is.
+func (r *cborReader) readTag(tag uint64, depth int) (interface{}, error) {
+	item, err := r.read(depth + 1)
+	if err != nil {
+		return nil, err
+	}
+
+	switch tag {
+	case cborTagDateTime:
+		text, ok := item.(string)
+		if !ok {
+			return nil, fmt.Errorf("%w: date/time tag requires a text string", ErrInvalidData)
+		}
+		return time.Parse(time.RFC3339Nano, text)
+	case cborTagEpochTime:
+		t := reflect.New(timeType).Elem()
+		if err := assignTime(item, t); err != nil {
+			return nil, err
+		}
+		return t.Interface(), nil
+	}
+	return item, nil
+}
+
+// readSimple reads simple values and floating point numbers
+func (r *cborReader) readSimple(info byte, argument uint64) (interface{}, error) {
+	switch info {
+	case 20:
+		return false, nil
+	case 21:
+		return true, nil
+	case 22, 23:
+		// null and undefined
+		return nil, nil
+	case 25:
+		return halfToFloat64(uint16(argument)), nil
+	case 26:
+		return math.Float32frombits(uint32(argument)), nil
+	case 27:
+		return math.Float64frombits(argument), nil
+	}
+	return nil, fmt.Errorf("%w: unsupported simple value %d", ErrInvalidData, argument)
+}
+
+// halfToFloat64 converts an IEEE 754 half precision number into a float64
+func halfToFloat64(half uint16) float64 {
+	exp, mant := int(half>>10)&0x1f, float64(half&0x3ff)
+	var f float64
+	switch exp {
+	case 0:
+		f = math.Ldexp(mant, -24)
+	case 0x1f:
+		f = math.Inf(1)
+		if mant != 0 {
+			f = math.NaN()
+		}
+	default:
+		f = math.Ldexp(mant+1024, exp-25)
+	}
+	if half&0x8000 != 0 {
+		return -f
+	}
+	return f
+}
@@ -0,0 +1,115 @@
+package parsers
+
+import (
+	"encoding/hex"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// the encoded values are taken from the examples of RFC 8949, Appendix A
+func TestCborParser_Marshal(t *testing.T) {
+	tests := []struct {
+		name string
+		data interface{}
+		want string
+	}{
+		{name: "null", data: nil, want: "f6"},
+		{name: "false", data: false, want: "f4"},
+		{name: "small_uint", data: 23, want: "17"},
+		{name: "uint8", data: 24, want: "1818"},
+		{name: "uint32", data: 1000000, want: "1a000f4240"},
+		{name: "uint64", data: uint64(18446744073709551615), want: "1bffffffffffffffff"},
+		{name: "negative", data: -1000, want: "3903e7"},
+		{name: "min_int64", data: int64(math.MinInt64), want: "3b7fffffffffffffff"},
+		{name: "float64", data: 1.1, want: "fb3ff199999999999a"},
+		{name: "float32", data: float32(100000), want: "fa47c35000"},
+		{name: "text", data: "ü", want: "62c3bc"},
+		{name: "bytes", data: []byte{1, 2, 3, 4}, want: "4401020304"},
+		{name: "array", data: []interface{}{1, []int{2, 3}}, want: "8201820203"},
+		{name: "map", data: map[string]string{"a": "A", "b": "B"}, want: "a26161614161626142"},
+		{name: "date_time", data: time.Date(2013, 3, 21, 20, 4, 0, 0, time.UTC), want: "c074323031332d30332d32315432303a30343a30305a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := (&CborParser{}).Marshal(&tt.data)
+			if err != nil {
+				t.Fatalf("CborParser.Marshal() error = %v", err)
+			}
+			if hex.EncodeToString(got) != tt.want {
+				t.Errorf("CborParser.Marshal() = %x, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCborParser_Unmarshal(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    interface{}
+		wantErr error
+	}{
+		{name: "undefined", data: "f7", want: nil},
+		{name: "negative", data: "3863", want: float64(-100)},
+		{name: "half_float", data: "f93e00", want: float64(1.5)},
+		{name: "half_float_subnormal", data: "f90001", want: 5.960464477539063e-08},
+		{name: "half_float_negative_infinity", data: "f9fc00", want: math.Inf(-1)},
+		{name: "negative_beyond_int64", data: "3bffffffffffffffff", want: float64(-18446744073709551616)},
+		{name: "epoch_time", data: "c11a514b67b0", want: time.Unix(1363896240, 0).UTC()},
+		{name: "epoch_time_float", data: "c1fb41d452d9ec200000", want: time.Unix(1363896240, 500000000).UTC()},
+		{name: "unknown_tag", data: "d74401020304", want: []byte{1, 2, 3, 4}},
+		{name: "indefinite_bytes", data: "5f42010243030405ff", want: []byte{1, 2, 3, 4, 5}},
+		{name: "indefinite_text", data: "7f657374726561646d696e67ff", want: "streaming"},
+		{name: "indefinite_array", data: "9f018202039f0405ffff", want: []interface{}{float64(1), []interface{}{float64(2), float64(3)}, []interface{}{float64(4), float64(5)}}},
+		{name: "indefinite_map", data: "bf61610161629f0203ffff", want: map[string]interface{}{"a": float64(1), "b": []interface{}{float64(2), float64(3)}}},
+		{name: "invalid_utf8", data: "61ff", wantErr: ErrInvalidData},
+		{name: "reserved_information", data: "1c", wantErr: ErrInvalidData},
+		{name: "indefinite_integer", data: "1f", wantErr: ErrInvalidData},
+		{name: "huge_array", data: "9bffffffffffffffff", wantErr: ErrUnexpectedEnd},
+		{name: "unterminated", data: "9f01", wantErr: ErrUnexpectedEnd},
+		{name: "trailing_data", data: "f6f6", wantErr: ErrTrailingData},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bts, _ := hex.DecodeString(tt.data)
+			var got interface{}
+			err := (&CborParser{}).Unmarshal(bts, &got)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CborParser.Unmarshal() error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CborParser.Unmarshal() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCborParser_typed(t *testing.T) {
+	parser := &CborParser{}
+	bts, err := parser.Marshal(&testBinaryModel)
+	if err != nil {
+		t.Fatalf("CborParser.Marshal() error = %v", err)
+	}
+
+	got := binaryModel{}
+	if err := parser.Unmarshal(bts, &got); err != nil {
+		t.Fatalf("CborParser.Unmarshal() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, testBinaryModel) {
+		t.Errorf("CborParser.Unmarshal() = %+v, want %+v", got, testBinaryModel)
+	}
+
+	small := struct {
+		Value int8 `cbor:"v"`
+	}{}
+	if err := parser.Unmarshal([]byte{0xa1, 0x61, 'v', 0x19, 0x01, 0x00}, &small); !errors.Is(err, ErrInvalidData) {
+		t.Errorf("CborParser.Unmarshal() error = %v, want %v", err, ErrInvalidData)
+	}
+}
+
+func TestCborParser_roundTrip(t *testing.T) {
+	roundTrip(t, &CborParser{}, &testItems[1], &item{})
+}
@@ -0,0 +1,265 @@
+package parsers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+const CsvMimeType = "text/csv"
+
+// CsvParser implements the procroute.Parser interface for CSV documents, which is mostly used to export the results of get all routes.
+// The header row is derived from the struct fields named by the csv tag, the json tag or the field name, in that order, or from the sorted keys of maps.
+// Fields that are neither scalars nor implement encoding.TextMarshaler are encoded as JSON.
+// Documents decoded into an empty interface are represented by a slice of maps from the header names to the cell values.
+//
+// Example:
+//  type Model struct {
+//  	Name  string `csv:"name"`
+//  	Price float64 `csv:"price"`
+//  }
+type CsvParser struct {
+	// Comma is the field delimiter. If zero, ',' is used.
+	Comma rune
+}
+
+// Unmarshal decodes the CSV document into the value pointed to by v.
+// The first row is the header, the following rows are decoded into the elements of a slice or into a single struct or map.
+func (p *CsvParser) Unmarshal(data []byte, v interface{}) error {
+	rv, err := target(v)
+	if err != nil {
+		return err
+	}
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	if p.Comma != 0 {
+		reader.Comma = p.Comma
+	}
+	records, err := reader.ReadAll()
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		return nil
+	}
+	header, rows := records[0], records[1:]
+
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+		rv = rv.Elem()
+	}
+
+	switch {
+	case rv.Kind() == reflect.Interface && rv.NumMethod() == 0:
+		slice := make([]interface{}, len(rows))
+		for i, row := range rows {
+			m := map[string]interface{}{}
+			if err := decodeCsvRow(header, row, reflect.ValueOf(&m).Elem()); err != nil {
+				return fmt.Errorf("row %d: %w", i+1, err)
+			}
+			slice[i] = m
+		}
+		rv.Set(reflect.ValueOf(slice))
+		return nil
+	case rv.Kind() == reflect.Slice:
+		slice := reflect.MakeSlice(rv.Type(), len(rows), len(rows))
+		for i, row := range rows {
+			if err := decodeCsvRow(header, row, slice.Index(i)); err != nil {
+				return fmt.Errorf("row %d: %w", i+1, err)
+			}
+		}
+		rv.Set(slice)
+		return nil
+	case len(rows) == 0:
+		return nil
+	}
+	return decodeCsvRow(header, rows[0], rv)
+}
+
+// Marshal encodes a slice of structs or maps as CSV document. Single structs or maps are encoded as document with a single row.
+func (p *CsvParser) Marshal(v interface{}) ([]byte, error) {
+	rv := indirect(reflect.ValueOf(v))
+
+	rows := []reflect.Value{}
+	if rv.IsValid() && (rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array) {
+		for i := 0; i < rv.Len(); i++ {
+			if row := indirect(rv.Index(i)); row.IsValid() {
+				rows = append(rows, row)
+			}
+		}
+	} else if rv.IsValid() {
+		rows = append(rows, rv)
+	}
+
+	header, err := csvHeader(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := &bytes.Buffer{}
+	writer := csv.NewWriter(buf)
+	if p.Comma != 0 {
+		writer.Comma = p.Comma
+	}
+	if len(header) > 0 {
+		if err := writer.Write(header); err != nil {
+			return nil, err
+		}
+	}
+	for _, row := range rows {
+		record, err := encodeCsvRow(header, row)
+		if err != nil {
+			return nil, err
+		}
+		if err := writer.Write(record); err != nil {
+			return nil, err
+		}
+	}
+	writer.Flush()
+	return buf.Bytes(), writer.Error()
+}
+
+// MimeType returns the mime type of CSV documents
+func (p *CsvParser) MimeType() string {
+	return CsvMimeType
+}
+
+// FieldName returns the name the struct field is encoded with, which is used to select fields of responses
+func (p *CsvParser) FieldName(field reflect.StructField) (string, bool) {
+	return encodedFieldName(field, "csv")
+}
+
+// csvHeader returns the column names of the rows. The columns of structs are defined by the first row,
+// the columns of maps are the sorted keys of all rows.
+func csvHeader(rows []reflect.Value) ([]string, error) {
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	switch first := rows[0]; {
+	case first.Kind() == reflect.Struct:
+		header := []string{}
+		for _, f := range structFields(first.Type(), "csv") {
+			header = append(header, f.name)
+		}
+		return header, nil
+	case first.Kind() == reflect.Map && first.Type().Key().Kind() == reflect.String:
+		keys := map[string]bool{}
+		for _, row := range rows {
+			if row.Kind() != reflect.Map {
+				continue
+			}
+			for _, key := range row.MapKeys() {
+				keys[key.String()] = true
+			}
+		}
+		header := make([]string, 0, len(keys))
+		for key := range keys {
+			header = append(header, key)
+		}
+		sort.Strings(header)
+		return header, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedType, first.Type())
+	}
+}
+
+// encodeCsvRow returns the cells of the struct or map in the order of the header
+func encodeCsvRow(header []string, row reflect.Value) ([]string, error) {
+	cells := map[string]reflect.Value{}
+	switch {
+	case row.Kind() == reflect.Struct:
+		for _, f := range structFields(row.Type(), "csv") {
+			cells[f.name] = fieldByIndex(row, f.index, false)
+		}
+	case row.Kind() == reflect.Map && row.Type().Key().Kind() == reflect.String:
+		for _, key := range row.MapKeys() {
+			cells[key.String()] = row.MapIndex(key)
+		}
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedType, row.Type())
+	}
+
+	record := make([]string, len(header))
+	for i, name := range header {
+		text, err := formatCsvValue(cells[name])
+		if err != nil {
+			return nil, fmt.Errorf("column %q: %w", name, err)
+		}
+		record[i] = text
+	}
+	return record, nil
+}
+
+// formatCsvValue returns the text of scalar values and the JSON representation of other values
+func formatCsvValue(v reflect.Value) (string, error) {
+	text, err := formatValue(v)
+	if errors.Is(err, ErrUnsupportedType) {
+		bts, err := json.Marshal(v.Interface())
+		return string(bts), err
+	}
+	return text, err
+}
+
+// decodeCsvRow stores the cells of the row in the settable struct, map or empty interface
+func decodeCsvRow(header, row []string, v reflect.Value) error {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return decodeCsvRow(header, row, v.Elem())
+	}
+
+	switch {
+	case v.Kind() == reflect.Interface && v.NumMethod() == 0:
+		m := map[string]interface{}{}
+		if err := decodeCsvRow(header, row, reflect.ValueOf(&m).Elem()); err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(m))
+		return nil
+	case v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String:
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+		for i, name := range header {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if err := parseCsvValue(row[i], elem); err != nil {
+				return fmt.Errorf("column %q: %w", name, err)
+			}
+			v.SetMapIndex(reflect.ValueOf(name).Convert(v.Type().Key()), elem)
+		}
+		return nil
+	case v.Kind() == reflect.Struct:
+		columns := map[string]int{}
+		for i, name := range header {
+			columns[name] = i
+		}
+		for _, f := range structFields(v.Type(), "csv") {
+			i, ok := columns[f.name]
+			if !ok || row[i] == "" {
+				continue
+			}
+			if err := parseCsvValue(row[i], fieldByIndex(v, f.index, true)); err != nil {
+				return fmt.Errorf("column %q: %w", f.name, err)
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrUnsupportedType, v.Type())
+}
+
+// parseCsvValue parses scalar values from their text and other values from their JSON representation
+func parseCsvValue(text string, v reflect.Value) error {
+	err := parseValue(text, v)
+	if errors.Is(err, ErrUnsupportedType) {
+		return json.Unmarshal([]byte(text), v.Addr().Interface())
+	}
+	return err
+}
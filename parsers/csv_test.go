@@ -0,0 +1,99 @@
+package parsers
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestCsvParser_Marshal(t *testing.T) {
+	type nested struct {
+		Name  string            `csv:"name"`
+		Attrs map[string]string `csv:"attrs"`
+	}
+	tests := []struct {
+		name    string
+		parser  *CsvParser
+		data    interface{}
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "structs",
+			parser: &CsvParser{},
+			data:   []interface{}{testItems[0], &testItems[1], nil},
+			want:   "id,name,price,created\n1,apple,1.5,2021-01-02T03:04:05Z\n2,\"pear, green\",2,2021-02-03T04:05:06Z\n",
+		},
+		{
+			name:   "maps_with_custom_delimiter",
+			parser: &CsvParser{Comma: ';'},
+			data:   []map[string]interface{}{{"b": 1, "a": "x"}, {"c": true}},
+			want:   "a;b;c\nx;1;\n;;true\n",
+		},
+		{
+			name:   "single_struct_with_composite_field",
+			parser: &CsvParser{},
+			data:   nested{Name: "a", Attrs: map[string]string{"k": "v"}},
+			want:   "name,attrs\na,\"{\"\"k\"\":\"\"v\"\"}\"\n",
+		},
+		{
+			name:   "empty_list",
+			parser: &CsvParser{},
+			data:   []interface{}{},
+			want:   "",
+		},
+		{
+			name:    "unsupported_type",
+			parser:  &CsvParser{},
+			data:    []int{1},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.parser.Marshal(&tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CsvParser.Marshal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if string(got) != tt.want {
+				t.Errorf("CsvParser.Marshal() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCsvParser_Unmarshal(t *testing.T) {
+	got := new(interface{})
+	if err := (&CsvParser{}).Unmarshal([]byte("name,price\napple,1.5\n"), got); err != nil {
+		t.Fatalf("CsvParser.Unmarshal() error = %v", err)
+	}
+
+	want := []interface{}{map[string]interface{}{"name": "apple", "price": "1.5"}}
+	if !reflect.DeepEqual(*got, want) {
+		t.Errorf("CsvParser.Unmarshal() = %+v, want %+v", *got, want)
+	}
+
+	if err := (&CsvParser{}).Unmarshal([]byte("id,price\n1,cheap\n"), &[]item{}); err == nil {
+		t.Errorf("CsvParser.Unmarshal() expected an error for an invalid number")
+	}
+}
+
+func TestCsvParser_roundTrip(t *testing.T) {
+	w := serve(t, "GET", "", CsvMimeType, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %v, want %v", w.Code, http.StatusOK)
+	}
+	if got := w.Header().Get("Content-Type"); got != CsvMimeType {
+		t.Errorf("Content-Type = %v, want %v", got, CsvMimeType)
+	}
+
+	got := []item{}
+	if err := (&CsvParser{}).Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("CsvParser.Unmarshal() error = %v, body = %s", err, w.Body.String())
+	}
+	if !reflect.DeepEqual(got, testItems) {
+		t.Errorf("received = %+v, want %+v", got, testItems)
+	}
+
+	roundTrip(t, &CsvParser{}, &item{base: base{ID: 1}, Name: "apple", Price: 1.5, Created: testItems[0].Created}, &item{})
+}
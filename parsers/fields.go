@@ -0,0 +1,259 @@
+package parsers
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrUnsupportedType = errors.New("unsupported type")
+	ErrNotAPointer     = errors.New("target is not a non-nil pointer")
+)
+
+var (
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// field describes an exported struct field and the name it is encoded with
+type field struct {
+	name      string
+	index     []int
+	omitEmpty bool
+}
+
+// structFields returns the exported fields of the struct type in declaration order.
+// The name of a field is taken from the passed tag, the json tag or the field name, in that order. Fields tagged with "-" are skipped.
+// Fields of embedded structs are promoted, unless the embedding struct declares a field with the same name.
+func structFields(t reflect.Type, tag string) []field {
+	fields := []field{}
+	promoted := [][]field{}
+	names := map[string]bool{}
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name, omitEmpty, ok := fieldName(sf, tag)
+		if !ok {
+			continue
+		}
+
+		ft := sf.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if sf.Anonymous && name == "" && ft.Kind() == reflect.Struct && !isText(ft) {
+			embedded := structFields(ft, tag)
+			for j := range embedded {
+				embedded[j].index = append([]int{i}, embedded[j].index...)
+			}
+			fields = append(fields, field{index: []int{i}})
+			promoted = append(promoted, embedded)
+			continue
+		}
+		if !sf.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = sf.Name
+		}
+		names[name] = true
+		fields = append(fields, field{name: name, index: []int{i}, omitEmpty: omitEmpty})
+	}
+
+	// replace the placeholders of the embedded structs by their promoted fields
+	result := []field{}
+	for _, f := range fields {
+		if f.name != "" {
+			result = append(result, f)
+			continue
+		}
+		for _, ef := range promoted[0] {
+			if !names[ef.name] {
+				names[ef.name] = true
+				result = append(result, ef)
+			}
+		}
+		promoted = promoted[1:]
+	}
+	return result
+}
+
+// fieldName returns the name and the omitempty option defined by the passed tag or the json tag.
+// The last bool is false, if the field must be skipped.
+func fieldName(sf reflect.StructField, tag string) (string, bool, bool) {
+	value, ok := sf.Tag.Lookup(tag)
+	if !ok {
+		value = sf.Tag.Get("json")
+	}
+	if value == "-" {
+		return "", false, false
+	}
+
+	parts := strings.Split(value, ",")
+	for _, option := range parts[1:] {
+		if option == "omitempty" {
+			return parts[0], true, true
+		}
+	}
+	return parts[0], false, true
+}
+
+// encodedFieldName returns the name of the field defined by the passed tag, the json tag or the field name, in that order.
+// Embedded structs without name return an empty name, since their fields are promoted. The bool is false, if the field is skipped.
+func encodedFieldName(sf reflect.StructField, tag string) (string, bool) {
+	name, _, ok := fieldName(sf, tag)
+	if !ok {
+		return "", false
+	}
+
+	ft := sf.Type
+	if ft.Kind() == reflect.Ptr {
+		ft = ft.Elem()
+	}
+	if sf.Anonymous && name == "" && ft.Kind() == reflect.Struct && !isText(ft) {
+		return "", true
+	}
+	if !sf.IsExported() {
+		return "", false
+	}
+	if name == "" {
+		name = sf.Name
+	}
+	return name, true
+}
+
+// fieldByIndex returns the nested field of the struct value. If alloc is set, nil pointers to embedded structs are allocated,
+// otherwise the returned value is invalid if a nil pointer is on the path.
+func fieldByIndex(v reflect.Value, index []int, alloc bool) reflect.Value {
+	for i, idx := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				if !alloc {
+					return reflect.Value{}
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(idx)
+	}
+	return v
+}
+
+// isText reports whether the type is encoded as text by the encoding.TextMarshaler interface
+func isText(t reflect.Type) bool {
+	return t.Implements(textMarshalerType) || reflect.PtrTo(t).Implements(textMarshalerType)
+}
+
+// indirect dereferences pointers and interfaces until a concrete value is reached
+func indirect(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// formatValue returns the text representation of a scalar value or of a value implementing encoding.TextMarshaler.
+// Nil values are represented by an empty string.
+func formatValue(v reflect.Value) (string, error) {
+	v = indirect(v)
+	if !v.IsValid() {
+		return "", nil
+	}
+	if v.Type().Implements(textMarshalerType) {
+		bts, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(bts), err
+	}
+	if reflect.PtrTo(v.Type()).Implements(textMarshalerType) {
+		ptr := reflect.New(v.Type())
+		ptr.Elem().Set(v)
+		bts, err := ptr.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(bts), err
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 32), nil
+	case reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 64), nil
+	}
+	return "", fmt.Errorf("%w: %s", ErrUnsupportedType, v.Type())
+}
+
+// parseValue parses the text into the settable value. Pointers are allocated and empty interfaces receive the text as string.
+func parseValue(text string, v reflect.Value) error {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return parseValue(text, v.Elem())
+	}
+	if v.CanAddr() && v.Addr().Type().Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(text))
+	}
+
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.NumMethod() != 0 {
+			break
+		}
+		v.Set(reflect.ValueOf(text))
+		return nil
+	case reflect.String:
+		v.SetString(text)
+		return nil
+	case reflect.Bool:
+		b, err := strconv.ParseBool(text)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(text, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u, err := strconv.ParseUint(text, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(u)
+		return nil
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(text, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrUnsupportedType, v.Type())
+}
+
+// target returns the value pointed to by v or an error, if v is not a non-nil pointer
+func target(v interface{}) (reflect.Value, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return reflect.Value{}, ErrNotAPointer
+	}
+	return rv.Elem(), nil
+}
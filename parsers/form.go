@@ -0,0 +1,187 @@
+package parsers
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+)
+
+const FormMimeType = "application/x-www-form-urlencoded"
+
+var urlValuesType = reflect.TypeOf(url.Values{})
+
+// FormParser implements the procroute.Parser interface for url encoded forms.
+// Forms are decoded into structs, maps with string keys, url.Values or an empty interface, which receives a map[string]interface{}.
+// Struct fields are named by the form tag, the json tag or the field name, in that order. Keys occurring multiple times are decoded into slices.
+//
+// Example:
+//  type Model struct {
+//  	Name string `form:"name"`
+//  	Tags []string `form:"tag"`
+//  }
+type FormParser struct{}
+
+// Unmarshal decodes the url encoded form into the value pointed to by v
+func (p *FormParser) Unmarshal(data []byte, v interface{}) error {
+	values, err := url.ParseQuery(string(data))
+	if err != nil {
+		return err
+	}
+
+	rv, err := target(v)
+	if err != nil {
+		return err
+	}
+	return decodeForm(values, rv)
+}
+
+// Marshal encodes the struct or map as url encoded form
+func (p *FormParser) Marshal(v interface{}) ([]byte, error) {
+	values, err := encodeForm(indirect(reflect.ValueOf(v)))
+	if err != nil {
+		return nil, err
+	}
+	return []byte(values.Encode()), nil
+}
+
+// MimeType returns the mime type of url encoded forms
+func (p *FormParser) MimeType() string {
+	return FormMimeType
+}
+
+// FieldName returns the name the struct field is encoded with, which is used to select fields of responses
+func (p *FormParser) FieldName(field reflect.StructField) (string, bool) {
+	return encodedFieldName(field, "form")
+}
+
+// decodeForm stores the form values in the settable value
+func decodeForm(values url.Values, v reflect.Value) error {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return decodeForm(values, v.Elem())
+	}
+
+	switch {
+	case v.Type() == urlValuesType:
+		v.Set(reflect.ValueOf(values))
+		return nil
+	case v.Kind() == reflect.Interface && v.NumMethod() == 0:
+		m := map[string]interface{}{}
+		if err := decodeForm(values, reflect.ValueOf(&m).Elem()); err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(m))
+		return nil
+	case v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String:
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+		for key, vals := range values {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if err := setFormValue(vals, elem); err != nil {
+				return fmt.Errorf("form key %q: %w", key, err)
+			}
+			v.SetMapIndex(reflect.ValueOf(key).Convert(v.Type().Key()), elem)
+		}
+		return nil
+	case v.Kind() == reflect.Struct:
+		for _, f := range structFields(v.Type(), "form") {
+			vals, ok := values[f.name]
+			if !ok {
+				continue
+			}
+			if err := setFormValue(vals, fieldByIndex(v, f.index, true)); err != nil {
+				return fmt.Errorf("form key %q: %w", f.name, err)
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrUnsupportedType, v.Type())
+}
+
+// setFormValue stores the values of a single key in the settable value.
+// Slices receive all values, empty interfaces a string or a slice if the key occurs multiple times and other types the first value.
+func setFormValue(vals []string, v reflect.Value) error {
+	switch {
+	case v.Kind() == reflect.Slice && !isText(v.Type()):
+		slice := reflect.MakeSlice(v.Type(), len(vals), len(vals))
+		for i, val := range vals {
+			if err := parseValue(val, slice.Index(i)); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+		return nil
+	case v.Kind() == reflect.Interface && v.NumMethod() == 0 && len(vals) > 1:
+		slice := make([]interface{}, len(vals))
+		for i, val := range vals {
+			slice[i] = val
+		}
+		v.Set(reflect.ValueOf(slice))
+		return nil
+	case len(vals) == 0:
+		return nil
+	}
+	return parseValue(vals[0], v)
+}
+
+// encodeForm returns the form values of the struct or map
+func encodeForm(v reflect.Value) (url.Values, error) {
+	values := url.Values{}
+	if !v.IsValid() {
+		return values, nil
+	}
+
+	switch {
+	case v.Type() == urlValuesType:
+		return v.Interface().(url.Values), nil
+	case v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		for _, key := range keys {
+			if err := addFormValue(values, key.String(), v.MapIndex(key)); err != nil {
+				return nil, err
+			}
+		}
+		return values, nil
+	case v.Kind() == reflect.Struct:
+		for _, f := range structFields(v.Type(), "form") {
+			fv := fieldByIndex(v, f.index, false)
+			if !fv.IsValid() {
+				continue
+			}
+			if err := addFormValue(values, f.name, fv); err != nil {
+				return nil, err
+			}
+		}
+		return values, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedType, v.Type())
+}
+
+// addFormValue adds the value to the form, slices are added as repeated keys
+func addFormValue(values url.Values, key string, v reflect.Value) error {
+	v = indirect(v)
+	if !v.IsValid() {
+		return nil
+	}
+
+	if (v.Kind() == reflect.Slice || v.Kind() == reflect.Array) && !isText(v.Type()) {
+		for i := 0; i < v.Len(); i++ {
+			if err := addFormValue(values, key, v.Index(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	text, err := formatValue(v)
+	if err != nil {
+		return fmt.Errorf("form key %q: %w", key, err)
+	}
+	values.Add(key, text)
+	return nil
+}
@@ -0,0 +1,107 @@
+package parsers
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestFormParser_Unmarshal(t *testing.T) {
+	type model struct {
+		Name   string   `form:"name"`
+		Count  *int     `json:"count"`
+		Tags   []string `form:"tag"`
+		Active bool
+	}
+	count := 3
+	tests := []struct {
+		name    string
+		data    string
+		target  interface{}
+		want    interface{}
+		wantErr bool
+	}{
+		{
+			name:   "struct",
+			data:   "name=example+name&count=3&tag=a&tag=b&Active=true&unknown=1",
+			target: &model{},
+			want:   &model{Name: "example name", Count: &count, Tags: []string{"a", "b"}, Active: true},
+		},
+		{
+			name:   "empty_interface",
+			data:   "name=example&tag=a&tag=b",
+			target: new(interface{}),
+			want:   &[]interface{}{map[string]interface{}{"name": "example", "tag": []interface{}{"a", "b"}}}[0],
+		},
+		{
+			name:   "url_values",
+			data:   "name=example&tag=a&tag=b",
+			target: &url.Values{},
+			want:   &url.Values{"name": []string{"example"}, "tag": []string{"a", "b"}},
+		},
+		{
+			name:    "invalid_value",
+			data:    "count=three",
+			target:  &model{},
+			want:    &model{Count: new(int)},
+			wantErr: true,
+		},
+		{
+			name:    "invalid_encoding",
+			data:    "name=%zz",
+			target:  &model{},
+			want:    &model{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := (&FormParser{}).Unmarshal([]byte(tt.data), tt.target); (err != nil) != tt.wantErr {
+				t.Fatalf("FormParser.Unmarshal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(tt.target, tt.want) {
+				t.Errorf("FormParser.Unmarshal() = %+v, want %+v", tt.target, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormParser_Marshal(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    interface{}
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "struct",
+			data: &item{base: base{ID: 1}, Name: "a b", Tags: []string{"x", "y"}, Created: testItems[0].Created, Hidden: "secret"},
+			want: "created=2021-01-02T03%3A04%3A05Z&id=1&name=a+b&price=0&tags=x&tags=y",
+		},
+		{
+			name: "map",
+			data: map[string]interface{}{"b": 1, "a": []int{1, 2}},
+			want: "a=1&a=2&b=1",
+		},
+		{
+			name:    "unsupported_type",
+			data:    []string{"a"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := (&FormParser{}).Marshal(&tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FormParser.Marshal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if string(got) != tt.want {
+				t.Errorf("FormParser.Marshal() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormParser_roundTrip(t *testing.T) {
+	roundTrip(t, &FormParser{}, &item{base: base{ID: 1}, Name: "apple", Price: 1.5, Tags: []string{"red", "sweet"}, Created: testItems[0].Created}, &item{})
+}
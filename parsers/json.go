@@ -0,0 +1,71 @@
+package parsers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+const JsonMimeType = "application/json"
+
+var ErrTrailingData = errors.New("unexpected data after top-level value")
+
+// JsonParser implements the procroute.Parser interface for JSON documents
+//
+// Example:
+//  rs := procroute.NewRouteSet("/example", &parsers.JsonParser{DisallowUnknownFields: true})
+type JsonParser struct {
+	// DisallowUnknownFields causes Unmarshal to return an error, if the document contains fields that are not part of the target type
+	DisallowUnknownFields bool
+	// UseNumber causes Unmarshal to decode numbers into an interface{} as json.Number instead of float64
+	UseNumber bool
+}
+
+// Unmarshal decodes the JSON document into the value pointed to by v
+func (p *JsonParser) Unmarshal(data []byte, v interface{}) error {
+	return p.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+// Marshal encodes the value as JSON document
+func (p *JsonParser) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// MimeType returns the mime type of JSON documents
+func (p *JsonParser) MimeType() string {
+	return JsonMimeType
+}
+
+// NewDecoder returns a decoder that reads a single JSON document from r, like Unmarshal decodes it
+func (p *JsonParser) NewDecoder(r io.Reader) Decoder {
+	decoder := json.NewDecoder(r)
+	if p.DisallowUnknownFields {
+		decoder.DisallowUnknownFields()
+	}
+	if p.UseNumber {
+		decoder.UseNumber()
+	}
+	return &jsonDecoder{decoder: decoder}
+}
+
+// NewEncoder returns an encoder that writes JSON documents to w
+func (p *JsonParser) NewEncoder(w io.Writer) Encoder {
+	return json.NewEncoder(w)
+}
+
+// jsonDecoder decodes a single JSON document and rejects data following it
+type jsonDecoder struct {
+	decoder *json.Decoder
+}
+
+// Decode decodes the JSON document into the value pointed to by v
+func (d *jsonDecoder) Decode(v interface{}) error {
+	if err := d.decoder.Decode(v); err != nil {
+		return err
+	}
+	if _, err := d.decoder.Token(); err != io.EOF {
+		return ErrTrailingData
+	}
+	return nil
+}
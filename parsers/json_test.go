@@ -0,0 +1,73 @@
+package parsers
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestJsonParser_Unmarshal(t *testing.T) {
+	type model struct {
+		Name string `json:"name"`
+	}
+	tests := []struct {
+		name    string
+		parser  *JsonParser
+		data    string
+		target  interface{}
+		want    interface{}
+		wantErr bool
+	}{
+		{
+			name:   "unknown_fields_allowed",
+			parser: &JsonParser{},
+			data:   `{"name":"example","unknown":1}`,
+			target: &model{},
+			want:   &model{Name: "example"},
+		},
+		{
+			name:    "unknown_fields_disallowed",
+			parser:  &JsonParser{DisallowUnknownFields: true},
+			data:    `{"name":"example","unknown":1}`,
+			target:  &model{},
+			want:    &model{Name: "example"},
+			wantErr: true,
+		},
+		{
+			name:   "float_numbers",
+			parser: &JsonParser{},
+			data:   `{"value":12345678901234567890}`,
+			target: new(interface{}),
+			want:   &[]interface{}{map[string]interface{}{"value": float64(12345678901234567890)}}[0],
+		},
+		{
+			name:   "use_number",
+			parser: &JsonParser{UseNumber: true},
+			data:   `{"value":12345678901234567890}`,
+			target: new(interface{}),
+			want:   &[]interface{}{map[string]interface{}{"value": json.Number("12345678901234567890")}}[0],
+		},
+		{
+			name:    "trailing_data",
+			parser:  &JsonParser{},
+			data:    `{"name":"example"} {}`,
+			target:  &model{},
+			want:    &model{Name: "example"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.parser.Unmarshal([]byte(tt.data), tt.target); (err != nil) != tt.wantErr {
+				t.Fatalf("JsonParser.Unmarshal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(tt.target, tt.want) {
+				t.Errorf("JsonParser.Unmarshal() = %+v, want %+v", tt.target, tt.want)
+			}
+		})
+	}
+}
+
+func TestJsonParser_roundTrip(t *testing.T) {
+	roundTrip(t, &JsonParser{DisallowUnknownFields: true}, &testItems[0], &item{})
+}
@@ -0,0 +1,368 @@
+package parsers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"reflect"
+	"time"
+)
+
+const MsgpackMimeType = "application/msgpack"
+
+// msgpackTimestamp is the extension type of timestamps defined by the MessagePack specification
+const msgpackTimestamp = -1
+
+// MsgpackParser implements the procroute.Parser interface for MessagePack documents.
+// Struct fields are named by the msgpack tag, the json tag or the field name, in that order, and the omitempty option is honoured.
+// time.Time values are encoded by the timestamp extension type and []byte values as binary data.
+//
+// Example:
+//  rs := procroute.NewRouteSet("/example", &parsers.JsonParser{}).AddParsers(&parsers.MsgpackParser{})
+type MsgpackParser struct{}
+
+// Unmarshal decodes the MessagePack document into the value pointed to by v
+func (p *MsgpackParser) Unmarshal(data []byte, v interface{}) error {
+	reader := &msgpackReader{data: data}
+	item, err := reader.read(0)
+	if err != nil {
+		return err
+	}
+	if reader.pos != len(data) {
+		return ErrTrailingData
+	}
+	return decodeBinary(item, v, "msgpack")
+}
+
+// Marshal encodes the value as MessagePack document
+func (p *MsgpackParser) Marshal(v interface{}) ([]byte, error) {
+	w := &msgpackWriter{}
+	if err := encodeBinary(w, reflect.ValueOf(v), "msgpack", 0); err != nil {
+		return nil, err
+	}
+	return w.buf.Bytes(), nil
+}
+
+// MimeType returns the mime type of MessagePack documents
+func (p *MsgpackParser) MimeType() string {
+	return MsgpackMimeType
+}
+
+// FieldName returns the name the struct field is encoded with, which is used to select fields of responses
+func (p *MsgpackParser) FieldName(field reflect.StructField) (string, bool) {
+	return encodedFieldName(field, "msgpack")
+}
+
+// msgpackWriter writes the items of a MessagePack document in their smallest representation
+type msgpackWriter struct {
+	buf bytes.Buffer
+}
+
+func (w *msgpackWriter) writeNil() {
+	w.buf.WriteByte(0xc0)
+}
+
+func (w *msgpackWriter) writeBool(b bool) {
+	if b {
+		w.buf.WriteByte(0xc3)
+		return
+	}
+	w.buf.WriteByte(0xc2)
+}
+
+func (w *msgpackWriter) writeInt(i int64) {
+	switch {
+	case i >= 0:
+		w.writeUint(uint64(i))
+	case i >= -32:
+		w.buf.WriteByte(byte(i))
+	case i >= math.MinInt8:
+		w.buf.Write([]byte{0xd0, byte(i)})
+	case i >= math.MinInt16:
+		w.buf.WriteByte(0xd1)
+		w.writeBig(uint64(i), 2)
+	case i >= math.MinInt32:
+		w.buf.WriteByte(0xd2)
+		w.writeBig(uint64(i), 4)
+	default:
+		w.buf.WriteByte(0xd3)
+		w.writeBig(uint64(i), 8)
+	}
+}
+
+func (w *msgpackWriter) writeUint(u uint64) {
+	switch {
+	case u <= 0x7f:
+		w.buf.WriteByte(byte(u))
+	case u <= math.MaxUint8:
+		w.buf.Write([]byte{0xcc, byte(u)})
+	case u <= math.MaxUint16:
+		w.buf.WriteByte(0xcd)
+		w.writeBig(u, 2)
+	case u <= math.MaxUint32:
+		w.buf.WriteByte(0xce)
+		w.writeBig(u, 4)
+	default:
+		w.buf.WriteByte(0xcf)
+		w.writeBig(u, 8)
+	}
+}
+
+func (w *msgpackWriter) writeFloat32(f float32) {
+	w.buf.WriteByte(0xca)
+	w.writeBig(uint64(math.Float32bits(f)), 4)
+}
+
+func (w *msgpackWriter) writeFloat64(f float64) {
+	w.buf.WriteByte(0xcb)
+	w.writeBig(uint64(math.Float64bits(f)), 8)
+}
+
+func (w *msgpackWriter) writeString(s string) {
+	w.writeLength(len(s), 0xa0, 31, 0xd9, 0xda, 0xdb)
+	w.buf.WriteString(s)
+}
+
+func (w *msgpackWriter) writeBytes(b []byte) {
+	w.writeLength(len(b), 0, -1, 0xc4, 0xc5, 0xc6)
+	w.buf.Write(b)
+}
+
+// writeTime writes the timestamp by the smallest of the timestamp 32, 64 and 96 formats
+func (w *msgpackWriter) writeTime(t time.Time) {
+	sec, nsec := t.Unix(), uint32(t.Nanosecond())
+	switch {
+	case nsec == 0 && sec >= 0 && sec <= math.MaxUint32:
+		w.buf.Write([]byte{0xd6, 0xff})
+		w.writeBig(uint64(sec), 4)
+	case sec >= 0 && sec>>34 == 0:
+		w.buf.Write([]byte{0xd7, 0xff})
+		w.writeBig(uint64(nsec)<<34|uint64(sec), 8)
+	default:
+		w.buf.Write([]byte{0xc7, 12, 0xff})
+		w.writeBig(uint64(nsec), 4)
+		w.writeBig(uint64(sec), 8)
+	}
+}
+
+func (w *msgpackWriter) writeArrayHeader(length int) {
+	w.writeLength(length, 0x90, 15, 0, 0xdc, 0xdd)
+}
+
+func (w *msgpackWriter) writeMapHeader(length int) {
+	w.writeLength(length, 0x80, 15, 0, 0xde, 0xdf)
+}
+
+// writeLength writes the type and length of a string, binary, array or map item in the smallest representation.
+// fixMax is the maximum length of the fix format, the 8 bit format is skipped, if its type is zero.
+func (w *msgpackWriter) writeLength(length int, fix byte, fixMax int, type8, type16, type32 byte) {
+	switch {
+	case length <= fixMax:
+		w.buf.WriteByte(fix | byte(length))
+	case length <= math.MaxUint8 && type8 != 0:
+		w.buf.Write([]byte{type8, byte(length)})
+	case length <= math.MaxUint16:
+		w.buf.WriteByte(type16)
+		w.writeBig(uint64(length), 2)
+	default:
+		w.buf.WriteByte(type32)
+		w.writeBig(uint64(length), 4)
+	}
+}
+
+// writeBig writes the n least significant bytes of the unsigned integer in big endian order
+func (w *msgpackWriter) writeBig(u uint64, n int) {
+	for i := n - 1; i >= 0; i-- {
+		w.buf.WriteByte(byte(u >> (8 * i)))
+	}
+}
+
+// msgpackReader reads the items of a MessagePack document into their decoded representation
+type msgpackReader struct {
+	data []byte
+	pos  int
+}
+
+// next returns the next n bytes of the document
+func (r *msgpackReader) next(n int) ([]byte, error) {
+	if n < 0 || len(r.data)-r.pos < n {
+		return nil, ErrUnexpectedEnd
+	}
+	bts := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return bts, nil
+}
+
+// uint reads a big endian unsigned integer of n bytes
+func (r *msgpackReader) uint(n int) (uint64, error) {
+	bts, err := r.next(n)
+	if err != nil {
+		return 0, err
+	}
+	var u uint64
+	for _, b := range bts {
+		u = u<<8 | uint64(b)
+	}
+	return u, nil
+}
+
+// length reads a length of n bytes and ensures that the document contains at least one byte per element
+func (r *msgpackReader) length(n int) (int, error) {
+	u, err := r.uint(n)
+	if err != nil {
+		return 0, err
+	}
+	if u > uint64(len(r.data)-r.pos) {
+		return 0, ErrUnexpectedEnd
+	}
+	return int(u), nil
+}
+
+// read decodes the next item of the document
+func (r *msgpackReader) read(depth int) (interface{}, error) {
+	if depth > maxBinaryDepth {
+		return nil, ErrMaxDepthExceeded
+	}
+
+	head, err := r.next(1)
+	if err != nil {
+		return nil, err
+	}
+
+	switch b := head[0]; {
+	case b <= 0x7f:
+		return int64(b), nil
+	case b >= 0xe0:
+		return int64(int8(b)), nil
+	case b >= 0x80 && b <= 0x8f:
+		return r.readMap(int(b&0x0f), depth)
+	case b >= 0x90 && b <= 0x9f:
+		return r.readArray(int(b&0x0f), depth)
+	case b >= 0xa0 && b <= 0xbf:
+		return r.readString(int(b & 0x1f))
+	}
+
+	switch b := head[0]; b {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6:
+		length, err := r.length(1 << (b - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+		bts, err := r.next(length)
+		return append([]byte{}, bts...), err
+	case 0xc7, 0xc8, 0xc9:
+		length, err := r.length(1 << (b - 0xc7))
+		if err != nil {
+			return nil, err
+		}
+		return r.readExt(length)
+	case 0xca:
+		u, err := r.uint(4)
+		return math.Float32frombits(uint32(u)), err
+	case 0xcb:
+		u, err := r.uint(8)
+		return math.Float64frombits(u), err
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		u, err := r.uint(1 << (b - 0xcc))
+		if err != nil || u > math.MaxInt64 {
+			return u, err
+		}
+		return int64(u), nil
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		n := 1 << (b - 0xd0)
+		u, err := r.uint(n)
+		// sign extend the value
+		shift := 64 - 8*n
+		return int64(u<<shift) >> shift, err
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return r.readExt(1 << (b - 0xd4))
+	case 0xd9, 0xda, 0xdb:
+		length, err := r.length(1 << (b - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return r.readString(length)
+	case 0xdc, 0xdd:
+		length, err := r.length(2 << (b - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return r.readArray(length, depth)
+	case 0xde, 0xdf:
+		length, err := r.length(2 << (b - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return r.readMap(length, depth)
+	}
+	return nil, fmt.Errorf("%w: unknown type 0x%x", ErrInvalidData, head[0])
+}
+
+func (r *msgpackReader) readString(length int) (interface{}, error) {
+	bts, err := r.next(length)
+	if err != nil {
+		return nil, err
+	}
+	return string(bts), nil
+}
+
+func (r *msgpackReader) readArray(length int, depth int) (interface{}, error) {
+	slice := make([]interface{}, length)
+	for i := range slice {
+		item, err := r.read(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		slice[i] = item
+	}
+	return slice, nil
+}
+
+func (r *msgpackReader) readMap(length int, depth int) (interface{}, error) {
+	m := make(binaryMap, length)
+	for i := range m {
+		key, err := r.read(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		value, err := r.read(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		m[i] = binaryMapEntry{key: key, value: value}
+	}
+	return m, nil
+}
+
+// readExt reads an extension item of the passed length. Only the timestamp extension type is supported.
+func (r *msgpackReader) readExt(length int) (interface{}, error) {
+	typ, err := r.next(1)
+	if err != nil {
+		return nil, err
+	}
+	bts, err := r.next(length)
+	if err != nil {
+		return nil, err
+	}
+	if int8(typ[0]) != msgpackTimestamp {
+		return nil, fmt.Errorf("%w: unsupported extension type %d", ErrInvalidData, int8(typ[0]))
+	}
+
+	switch length {
+	case 4:
+		return time.Unix(int64(binary.BigEndian.Uint32(bts)), 0).UTC(), nil
+	case 8:
+		u := binary.BigEndian.Uint64(bts)
+		return time.Unix(int64(u&(1<<34-1)), int64(u>>34)).UTC(), nil
+	case 12:
+		return time.Unix(int64(binary.BigEndian.Uint64(bts[4:])), int64(binary.BigEndian.Uint32(bts))).UTC(), nil
+	}
+	return nil, fmt.Errorf("%w: invalid timestamp length %d", ErrInvalidData, length)
+}
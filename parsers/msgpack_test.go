@@ -0,0 +1,141 @@
+package parsers
+
+import (
+	"encoding/hex"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type binaryModel struct {
+	Name     string            `json:"name" msgpack:"n" cbor:"n"`
+	Count    int               `json:"count,omitempty"`
+	Data     []byte            `json:"data"`
+	Checksum [4]byte           `json:"checksum"`
+	Created  time.Time         `json:"created"`
+	Deleted  *time.Time        `json:"deleted"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Scores   []float64         `json:"scores"`
+}
+
+var testBinaryModel = binaryModel{
+	Name:     "example",
+	Data:     []byte{0x00, 0x01, 0xff},
+	Checksum: [4]byte{0xde, 0xad, 0xbe, 0xef},
+	Created:  time.Date(2021, 1, 2, 3, 4, 5, 6, time.UTC),
+	Labels:   map[string]string{"b": "2", "a": "1"},
+	Scores:   []float64{1.5, -2},
+}
+
+func TestMsgpackParser_Marshal(t *testing.T) {
+	tests := []struct {
+		name string
+		data interface{}
+		want string
+	}{
+		{name: "nil", data: nil, want: "c0"},
+		{name: "bool", data: true, want: "c3"},
+		{name: "positive_fixint", data: 127, want: "7f"},
+		{name: "negative_fixint", data: -32, want: "e0"},
+		{name: "uint8", data: 200, want: "ccc8"},
+		{name: "int8", data: -100, want: "d09c"},
+		{name: "uint16", data: 1000, want: "cd03e8"},
+		{name: "int32", data: -100000, want: "d2fffe7960"},
+		{name: "uint64", data: uint64(math.MaxUint64), want: "cfffffffffffffffff"},
+		{name: "float64", data: 1.5, want: "cb3ff8000000000000"},
+		{name: "fixstr", data: "abc", want: "a3616263"},
+		{name: "bin8", data: []byte{1, 2}, want: "c4020102"},
+		{name: "fixarray", data: []int{1, 2}, want: "920102"},
+		{name: "sorted_fixmap", data: map[string]int{"b": 2, "a": 1}, want: "82a16101a16202"},
+		{name: "timestamp32", data: time.Unix(1, 0), want: "d6ff00000001"},
+		{name: "timestamp64", data: time.Unix(1, 1), want: "d7ff0000000400000001"},
+		{name: "timestamp96", data: time.Unix(-1, 0), want: "c70cff00000000ffffffffffffffff"},
+		{name: "struct_with_tags_and_omitempty", data: struct {
+			Name  string `msgpack:"n"`
+			Count int    `json:"c,omitempty"`
+			Skip  string `json:"-"`
+		}{Name: "a"}, want: "81a16ea161"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := (&MsgpackParser{}).Marshal(&tt.data)
+			if err != nil {
+				t.Fatalf("MsgpackParser.Marshal() error = %v", err)
+			}
+			if hex.EncodeToString(got) != tt.want {
+				t.Errorf("MsgpackParser.Marshal() = %x, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMsgpackParser_Unmarshal(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    interface{}
+		wantErr error
+	}{
+		{name: "nil", data: "c0", want: nil},
+		{name: "negative_fixint", data: "ff", want: float64(-1)},
+		{name: "int16", data: "d1fc18", want: float64(-1000)},
+		{name: "float32", data: "ca3fc00000", want: float64(1.5)},
+		{name: "str8", data: "d903616263", want: "abc"},
+		{name: "bin", data: "c4020102", want: []byte{1, 2}},
+		{name: "timestamp", data: "d6ff00000001", want: time.Unix(1, 0).UTC()},
+		{name: "map_like_json", data: "82a161920102a162c0", want: map[string]interface{}{"a": []interface{}{float64(1), float64(2)}, "b": nil}},
+		{name: "integer_keys_as_strings", data: "8101a161", want: map[string]interface{}{"1": "a"}},
+		{name: "truncated", data: "a3616", wantErr: ErrUnexpectedEnd},
+		{name: "huge_length", data: "dbffffffff", wantErr: ErrUnexpectedEnd},
+		{name: "trailing_data", data: "c0c0", wantErr: ErrTrailingData},
+		{name: "unknown_extension", data: "d40100", wantErr: ErrInvalidData},
+		{name: "never_used_type", data: "c1", wantErr: ErrInvalidData},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bts, _ := hex.DecodeString(tt.data)
+			var got interface{}
+			err := (&MsgpackParser{}).Unmarshal(bts, &got)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("MsgpackParser.Unmarshal() error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MsgpackParser.Unmarshal() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMsgpackParser_typed(t *testing.T) {
+	parser := &MsgpackParser{}
+	bts, err := parser.Marshal(&testBinaryModel)
+	if err != nil {
+		t.Fatalf("MsgpackParser.Marshal() error = %v", err)
+	}
+
+	got := binaryModel{}
+	if err := parser.Unmarshal(bts, &got); err != nil {
+		t.Fatalf("MsgpackParser.Unmarshal() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, testBinaryModel) {
+		t.Errorf("MsgpackParser.Unmarshal() = %+v, want %+v", got, testBinaryModel)
+	}
+
+	if err := parser.Unmarshal([]byte{0x81, 0xa5, 'c', 'o', 'u', 'n', 't', 0xa1, 'x'}, &got); !errors.Is(err, ErrInvalidData) {
+		t.Errorf("MsgpackParser.Unmarshal() error = %v, want %v", err, ErrInvalidData)
+	}
+
+	nested := []byte{}
+	for i := 0; i <= maxBinaryDepth+1; i++ {
+		nested = append(nested, 0x91)
+	}
+	if err := parser.Unmarshal(append(nested, 0xc0), new(interface{})); !errors.Is(err, ErrMaxDepthExceeded) {
+		t.Errorf("MsgpackParser.Unmarshal() error = %v, want %v", err, ErrMaxDepthExceeded)
+	}
+}
+
+func TestMsgpackParser_roundTrip(t *testing.T) {
+	roundTrip(t, &MsgpackParser{}, &testItems[0], &item{})
+}
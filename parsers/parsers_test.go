@@ -0,0 +1,187 @@
+package parsers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/leonsteinhaeuser/procroute"
+)
+
+type testLogger struct{}
+
+func (l *testLogger) Trace(format string, v ...interface{}) {}
+func (l *testLogger) Debug(format string, v ...interface{}) {}
+func (l *testLogger) Info(format string, v ...interface{})  {}
+func (l *testLogger) Warn(format string, v ...interface{})  {}
+func (l *testLogger) Error(format string, v ...interface{}) {}
+func (l *testLogger) Fatal(format string, v ...interface{}) {}
+
+type base struct {
+	ID int `json:"id"`
+}
+
+type item struct {
+	base
+	Name    string    `json:"name" xml:"name"`
+	Price   float64   `json:"price" xml:"price"`
+	Tags    []string  `json:"tags" xml:"tag" csv:"-"`
+	Created time.Time `json:"created" xml:"created"`
+	Hidden  string    `json:"-" xml:"-"`
+}
+
+type itemRoute struct {
+	items []interface{}
+}
+
+func (i *itemRoute) Type() interface{} {
+	return &item{}
+}
+
+func (i *itemRoute) GetAll(requestData interface{}) ([]interface{}, *procroute.HttpError) {
+	return i.items, nil
+}
+
+func (i *itemRoute) PostWithResponse(ctx context.Context, requestData interface{}) (*procroute.Response, error) {
+	return &procroute.Response{Body: requestData}, nil
+}
+
+var testItems = []item{
+	{base: base{ID: 1}, Name: "apple", Price: 1.5, Created: time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)},
+	{base: base{ID: 2}, Name: "pear, green", Price: 2, Created: time.Date(2021, 2, 3, 4, 5, 6, 0, time.UTC)},
+}
+
+// serve sends the request to a route machine with a route set serving the item route, which supports all parsers
+func serve(t *testing.T, method, contentType, accept string, body []byte) *httptest.ResponseRecorder {
+	t.Helper()
+
+	items := []interface{}{}
+	for _, i := range testItems {
+		items = append(items, i)
+	}
+
+	rm := procroute.NewRouteMachine("127.0.0.1", 0, "/api", &testLogger{})
+	rs := procroute.NewRouteSet("/items", &JsonParser{}).
+		AddParsers(&XmlParser{}, &FormParser{}, &CsvParser{}, &MsgpackParser{}, &CborParser{}).
+		AddRoutes(&itemRoute{items: items})
+	if err := rm.AddRouteSet(rs); err != nil {
+		t.Fatalf("RouteMachine.AddRouteSet() error = %v", err)
+	}
+
+	r := httptest.NewRequest(method, "/api/items", bytes.NewReader(body))
+	if contentType != "" {
+		r.Header.Set("Content-Type", contentType)
+	}
+	r.Header.Set("Accept", accept)
+
+	w := httptest.NewRecorder()
+	rm.ServeHTTP(w, r)
+	return w
+}
+
+// roundTrip posts the item encoded by the parser, receives the echoed item encoded by the same parser and compares it with the sent item
+func roundTrip(t *testing.T, parser procroute.Parser, sent interface{}, received interface{}) {
+	t.Helper()
+
+	body, err := parser.Marshal(sent)
+	if err != nil {
+		t.Fatalf("%T.Marshal() error = %v", parser, err)
+	}
+
+	w := serve(t, "POST", parser.MimeType(), parser.MimeType(), body)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status = %v, want %v, body = %s", w.Code, http.StatusCreated, w.Body.String())
+	}
+	if got := w.Header().Get("Content-Type"); got != parser.MimeType() {
+		t.Errorf("Content-Type = %v, want %v", got, parser.MimeType())
+	}
+
+	if err := parser.Unmarshal(w.Body.Bytes(), received); err != nil {
+		t.Fatalf("%T.Unmarshal() error = %v, body = %s", parser, err, w.Body.String())
+	}
+	if got := reflect.ValueOf(received).Elem().Interface(); !reflect.DeepEqual(got, reflect.ValueOf(sent).Elem().Interface()) {
+		t.Errorf("received = %+v, want %+v", got, sent)
+	}
+}
+
+func TestFieldName(t *testing.T) {
+	type tagged struct {
+		base
+		Name   string `json:"name" form:"form_name" csv:"csv_name" msgpack:"msgpack_name" cbor:"cbor_name"`
+		Price  float64
+		Hidden string `json:"-"`
+	}
+	typ := reflect.TypeOf(tagged{})
+
+	tests := []struct {
+		name   string
+		parser procroute.FieldNamer
+		want   []string
+	}{
+		{name: "form", parser: &FormParser{}, want: []string{"", "form_name", "Price"}},
+		{name: "csv", parser: &CsvParser{}, want: []string{"", "csv_name", "Price"}},
+		{name: "msgpack", parser: &MsgpackParser{}, want: []string{"", "msgpack_name", "Price"}},
+		{name: "cbor", parser: &CborParser{}, want: []string{"", "cbor_name", "Price"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []string{}
+			for i := 0; i < typ.NumField(); i++ {
+				if name, ok := tt.parser.FieldName(typ.Field(i)); ok {
+					got = append(got, name)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FieldName() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStreamParser(t *testing.T) {
+	tests := []struct {
+		name   string
+		parser procroute.Parser
+	}{
+		{
+			name:   "json",
+			parser: &JsonParser{},
+		},
+		{
+			name:   "xml",
+			parser: &XmlParser{Root: "list"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			streamParser, ok := tt.parser.(procroute.StreamParser)
+			if !ok {
+				t.Fatalf("%T does not implement procroute.StreamParser", tt.parser)
+			}
+
+			want, err := tt.parser.Marshal(testItems)
+			if err != nil {
+				t.Fatalf("%T.Marshal() error = %v", tt.parser, err)
+			}
+			buf := &bytes.Buffer{}
+			if err := streamParser.NewEncoder(buf).Encode(testItems); err != nil {
+				t.Fatalf("%T.NewEncoder().Encode() error = %v", tt.parser, err)
+			}
+			if got := bytes.TrimSuffix(buf.Bytes(), []byte("\n")); !bytes.Equal(got, want) {
+				t.Errorf("%T.NewEncoder().Encode() = %s, want %s", tt.parser, got, want)
+			}
+
+			decoded := []item{}
+			if err := streamParser.NewDecoder(buf).Decode(&decoded); err != nil {
+				t.Fatalf("%T.NewDecoder().Decode() error = %v", tt.parser, err)
+			}
+			if len(decoded) != len(testItems) || decoded[1].Name != testItems[1].Name {
+				t.Errorf("%T.NewDecoder().Decode() = %+v, want %+v", tt.parser, decoded, testItems)
+			}
+		})
+	}
+}
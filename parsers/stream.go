@@ -0,0 +1,13 @@
+package parsers
+
+// Decoder reads and decodes values from an input stream. It is identical to procroute.Decoder,
+// so that the parsers of this package implement the procroute.StreamParser interface.
+type Decoder = interface {
+	Decode(v interface{}) error
+}
+
+// Encoder writes encoded values to an output stream. It is identical to procroute.Encoder,
+// so that the parsers of this package implement the procroute.StreamParser interface.
+type Encoder = interface {
+	Encode(v interface{}) error
+}
@@ -0,0 +1,176 @@
+package parsers
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"reflect"
+)
+
+const (
+	XmlMimeType = "application/xml"
+
+	// defaultXmlRoot is the name of the root element that wraps lists
+	defaultXmlRoot = "items"
+)
+
+// XmlParser implements the procroute.Parser interface for XML documents.
+// Lists are wrapped in a root element, since a XML document must not have multiple root elements.
+// Documents decoded into an empty interface are represented by maps of the child element names,
+// whose values are strings, nested maps or slices, if an element name occurs multiple times.
+//
+// Example:
+//  rs := procroute.NewRouteSet("/example", &parsers.JsonParser{}).AddParsers(&parsers.XmlParser{})
+type XmlParser struct {
+	// Root is the name of the root element that wraps lists. If empty, "items" is used.
+	Root string
+}
+
+// Unmarshal decodes the XML document into the value pointed to by v
+func (p *XmlParser) Unmarshal(data []byte, v interface{}) error {
+	return p.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+// Marshal encodes the value as XML document
+func (p *XmlParser) Marshal(v interface{}) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := p.NewEncoder(buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// NewDecoder returns a decoder that reads a XML document from r, like Unmarshal decodes it
+func (p *XmlParser) NewDecoder(r io.Reader) Decoder {
+	return &xmlDecoder{decoder: xml.NewDecoder(r)}
+}
+
+// NewEncoder returns an encoder that writes XML documents to w, like Marshal encodes them
+func (p *XmlParser) NewEncoder(w io.Writer) Encoder {
+	root := p.Root
+	if root == "" {
+		root = defaultXmlRoot
+	}
+	return &xmlEncoder{encoder: xml.NewEncoder(w), root: root}
+}
+
+// xmlDecoder decodes XML documents into empty interfaces, slices and the types supported by the encoding/xml package
+type xmlDecoder struct {
+	decoder *xml.Decoder
+}
+
+// Decode decodes the XML document into the value pointed to by v
+func (d *xmlDecoder) Decode(v interface{}) error {
+	rv, err := target(v)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case rv.Kind() == reflect.Interface && rv.NumMethod() == 0:
+		value, err := decodeXmlElement(d.decoder)
+		if err != nil {
+			return err
+		}
+		rv.Set(reflect.ValueOf(value))
+		return nil
+	case rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() != reflect.Uint8:
+		// decode the children of the root element into the slice
+		wrapper := reflect.New(reflect.StructOf([]reflect.StructField{{
+			Name: "Items",
+			Type: rv.Type(),
+			Tag:  `xml:",any"`,
+		}}))
+		if err := d.decoder.Decode(wrapper.Interface()); err != nil {
+			return err
+		}
+		rv.Set(wrapper.Elem().Field(0))
+		return nil
+	}
+	return d.decoder.Decode(v)
+}
+
+// xmlEncoder encodes values as XML documents and wraps lists in the root element
+type xmlEncoder struct {
+	encoder *xml.Encoder
+	root    string
+}
+
+// Encode writes the XML document of v
+func (e *xmlEncoder) Encode(v interface{}) error {
+	rv := indirect(reflect.ValueOf(v))
+	if !rv.IsValid() || (rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array) || rv.Type().Elem().Kind() == reflect.Uint8 {
+		return e.encoder.Encode(v)
+	}
+
+	start := xml.StartElement{Name: xml.Name{Local: e.root}}
+	if err := e.encoder.EncodeToken(start); err != nil {
+		return err
+	}
+	for i := 0; i < rv.Len(); i++ {
+		if err := e.encoder.Encode(rv.Index(i).Interface()); err != nil {
+			return err
+		}
+	}
+	if err := e.encoder.EncodeToken(start.End()); err != nil {
+		return err
+	}
+	return e.encoder.Flush()
+}
+
+// MimeType returns the mime type of XML documents
+func (p *XmlParser) MimeType() string {
+	return XmlMimeType
+}
+
+// decodeXmlElement decodes the root element of the document into a generic representation
+func decodeXmlElement(decoder *xml.Decoder) (interface{}, error) {
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil, io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return nil, err
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return decodeXmlChildren(decoder, start)
+		}
+	}
+}
+
+// decodeXmlChildren decodes the content of the started element.
+// Elements without children are represented by their text, other elements by a map of their children.
+func decodeXmlChildren(decoder *xml.Decoder, start xml.StartElement) (interface{}, error) {
+	children := map[string]interface{}{}
+	text := &bytes.Buffer{}
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			child, err := decodeXmlChildren(decoder, t)
+			if err != nil {
+				return nil, err
+			}
+			switch existing := children[t.Name.Local].(type) {
+			case nil:
+				children[t.Name.Local] = child
+			case []interface{}:
+				children[t.Name.Local] = append(existing, child)
+			default:
+				children[t.Name.Local] = []interface{}{existing, child}
+			}
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			if len(children) == 0 {
+				return text.String(), nil
+			}
+			return children, nil
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package parsers
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestXmlParser_Unmarshal(t *testing.T) {
+	got := new(interface{})
+	data := `<item><name>apple</name><tag>a</tag><tag>b</tag><price>1.5</price></item>`
+	if err := (&XmlParser{}).Unmarshal([]byte(data), got); err != nil {
+		t.Fatalf("XmlParser.Unmarshal() error = %v", err)
+	}
+
+	want := map[string]interface{}{
+		"name":  "apple",
+		"tag":   []interface{}{"a", "b"},
+		"price": "1.5",
+	}
+	if !reflect.DeepEqual(*got, want) {
+		t.Errorf("XmlParser.Unmarshal() = %+v, want %+v", *got, want)
+	}
+}
+
+func TestXmlParser_Marshal(t *testing.T) {
+	type entry struct {
+		Name string `xml:"name"`
+	}
+	tests := []struct {
+		name   string
+		parser *XmlParser
+		data   interface{}
+		want   string
+	}{
+		{
+			name:   "single_element",
+			parser: &XmlParser{},
+			data:   entry{Name: "a"},
+			want:   `<entry><name>a</name></entry>`,
+		},
+		{
+			name:   "list_with_default_root",
+			parser: &XmlParser{},
+			data:   []interface{}{entry{Name: "a"}, &entry{Name: "b"}},
+			want:   `<items><entry><name>a</name></entry><entry><name>b</name></entry></items>`,
+		},
+		{
+			name:   "list_with_custom_root",
+			parser: &XmlParser{Root: "entries"},
+			data:   []entry{},
+			want:   `<entries></entries>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.parser.Marshal(&tt.data)
+			if err != nil {
+				t.Fatalf("XmlParser.Marshal() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("XmlParser.Marshal() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestXmlParser_roundTrip(t *testing.T) {
+	roundTrip(t, &XmlParser{}, &item{Name: "apple", Price: 1.5, Tags: []string{"red", "sweet"}, Created: testItems[0].Created}, &item{})
+
+	w := serve(t, "GET", "", XmlMimeType, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %v, want %v", w.Code, http.StatusOK)
+	}
+
+	got := []item{}
+	if err := (&XmlParser{}).Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("XmlParser.Unmarshal() error = %v, body = %s", err, w.Body.String())
+	}
+	if !reflect.DeepEqual(got, testItems) {
+		t.Errorf("received = %+v, want %+v", got, testItems)
+	}
+}
@@ -0,0 +1,429 @@
+package procroute
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	// MergePatchMimeType is the mime type of JSON Merge Patch documents as defined in RFC 7396
+	MergePatchMimeType = "application/merge-patch+json"
+	// JsonPatchMimeType is the mime type of JSON Patch documents as defined in RFC 6902
+	JsonPatchMimeType = "application/json-patch+json"
+)
+
+var (
+	ErrPatchPathNotFound = errors.New("patch path not found")
+	ErrPatchTestFailed   = errors.New("patch test operation failed")
+)
+
+// jsonPatchOperation represents a single operation of a JSON Patch document
+type jsonPatchOperation struct {
+	Op    string           `json:"op"`
+	Path  *string          `json:"path"`
+	From  *string          `json:"from"`
+	Value *json.RawMessage `json:"value"`
+}
+
+// applyPatch applies the patch document of the passed mime type to the JSON encoded document and returns the patched document
+func applyPatch(mimeType string, document []byte, patch []byte) ([]byte, *HttpError) {
+	doc, err := decodeJson(document)
+	if err != nil {
+		return nil, &HttpError{
+			Status:    http.StatusInternalServerError,
+			ErrorCode: "",
+			Message:   err.Error(),
+		}
+	}
+
+	switch mimeType {
+	case MergePatchMimeType:
+		mergePatchDoc, err := decodeJson(patch)
+		if err != nil {
+			return nil, &HttpError{
+				Status:    http.StatusBadRequest,
+				ErrorCode: "",
+				Message:   err.Error(),
+			}
+		}
+		doc = mergePatch(doc, mergePatchDoc)
+	case JsonPatchMimeType:
+		var operations []jsonPatchOperation
+		if err := json.Unmarshal(patch, &operations); err != nil {
+			return nil, &HttpError{
+				Status:    http.StatusBadRequest,
+				ErrorCode: "",
+				Message:   err.Error(),
+			}
+		}
+
+		doc, err = jsonPatch(doc, operations)
+		if err != nil {
+			return nil, patchError(err)
+		}
+	default:
+		return nil, &HttpError{
+			Status:    http.StatusUnsupportedMediaType,
+			ErrorCode: "",
+			Message:   fmt.Sprintf("unsupported patch format: %s", mimeType),
+		}
+	}
+
+	bts, err := json.Marshal(doc)
+	if err != nil {
+		return nil, &HttpError{
+			Status:    http.StatusInternalServerError,
+			ErrorCode: "",
+			Message:   err.Error(),
+		}
+	}
+	return bts, nil
+}
+
+// patchError converts an error returned while applying a JSON Patch document into a HttpError
+func patchError(err error) *HttpError {
+	status := http.StatusUnprocessableEntity
+	var syntaxErr *json.SyntaxError
+	switch {
+	case errors.Is(err, ErrPatchTestFailed):
+		status = http.StatusConflict
+	case errors.As(err, &syntaxErr):
+		status = http.StatusBadRequest
+	}
+
+	return &HttpError{
+		Status:    status,
+		ErrorCode: "",
+		Message:   err.Error(),
+	}
+}
+
+// decodeJson decodes the JSON document into its generic representation and keeps the precision of numbers
+func decodeJson(bts []byte) (interface{}, error) {
+	var doc interface{}
+	decoder := json.NewDecoder(bytes.NewReader(bts))
+	decoder.UseNumber()
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// mergePatch applies the merge patch to the target as defined in RFC 7396
+func mergePatch(target interface{}, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObj, ok := target.(map[string]interface{})
+	if !ok {
+		targetObj = map[string]interface{}{}
+	}
+
+	for key, value := range patchObj {
+		if value == nil {
+			delete(targetObj, key)
+			continue
+		}
+		targetObj[key] = mergePatch(targetObj[key], value)
+	}
+	return targetObj
+}
+
+// jsonPatch applies the operations to the document as defined in RFC 6902
+func jsonPatch(doc interface{}, operations []jsonPatchOperation) (interface{}, error) {
+	for i, operation := range operations {
+		var err error
+		doc, err = operation.apply(doc)
+		if err != nil {
+			return nil, fmt.Errorf("operation %d: %w", i, err)
+		}
+	}
+	return doc, nil
+}
+
+// apply applies the operation to the document and returns the resulting document
+func (o jsonPatchOperation) apply(doc interface{}) (interface{}, error) {
+	if o.Path == nil {
+		return nil, fmt.Errorf("missing path in %q operation", o.Op)
+	}
+	path, err := parseJsonPointer(*o.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch o.Op {
+	case "add", "replace", "test":
+		if o.Value == nil {
+			return nil, fmt.Errorf("missing value in %q operation", o.Op)
+		}
+		value, err := decodeJson(*o.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		switch o.Op {
+		case "add":
+			return pointerAdd(doc, path, value)
+		case "replace":
+			if doc, err = pointerRemove(doc, path); err != nil {
+				return nil, err
+			}
+			return pointerAdd(doc, path, value)
+		default:
+			current, err := pointerGet(doc, path)
+			if err != nil {
+				return nil, err
+			}
+			if !jsonEqual(current, value) {
+				return nil, fmt.Errorf("%w: value at %q does not match", ErrPatchTestFailed, *o.Path)
+			}
+			return doc, nil
+		}
+	case "remove":
+		return pointerRemove(doc, path)
+	case "move", "copy":
+		if o.From == nil {
+			return nil, fmt.Errorf("missing from in %q operation", o.Op)
+		}
+		from, err := parseJsonPointer(*o.From)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := pointerGet(doc, from)
+		if err != nil {
+			return nil, err
+		}
+
+		if o.Op == "copy" {
+			return pointerAdd(doc, path, deepCopyJson(value))
+		}
+
+		if len(path) > len(from) && reflect.DeepEqual(path[:len(from)], from) {
+			return nil, fmt.Errorf("cannot move %q into one of its children", *o.From)
+		}
+		if doc, err = pointerRemove(doc, from); err != nil {
+			return nil, err
+		}
+		return pointerAdd(doc, path, value)
+	}
+	return nil, fmt.Errorf("unsupported operation %q", o.Op)
+}
+
+// parseJsonPointer splits the JSON pointer into its unescaped reference tokens as defined in RFC 6901
+func parseJsonPointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid json pointer %q", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// arrayIndex parses the reference token as index of an array with the passed length.
+// If allowEnd is true, the index may point just behind the last element.
+func arrayIndex(token string, length int, allowEnd bool) (int, error) {
+	if allowEnd && token == "-" {
+		return length, nil
+	}
+
+	idx, err := strconv.Atoi(token)
+	if err != nil || idx < 0 || (token != "0" && strings.HasPrefix(token, "0")) {
+		return 0, fmt.Errorf("%w: invalid array index %q", ErrPatchPathNotFound, token)
+	}
+
+	if idx > length || (!allowEnd && idx == length) {
+		return 0, fmt.Errorf("%w: array index %d out of range", ErrPatchPathNotFound, idx)
+	}
+	return idx, nil
+}
+
+// pointerGet returns the value the path points to
+func pointerGet(doc interface{}, path []string) (interface{}, error) {
+	for _, token := range path {
+		switch node := doc.(type) {
+		case map[string]interface{}:
+			value, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("%w: member %q does not exist", ErrPatchPathNotFound, token)
+			}
+			doc = value
+		case []interface{}:
+			idx, err := arrayIndex(token, len(node), false)
+			if err != nil {
+				return nil, err
+			}
+			doc = node[idx]
+		default:
+			return nil, fmt.Errorf("%w: cannot resolve %q", ErrPatchPathNotFound, token)
+		}
+	}
+	return doc, nil
+}
+
+// pointerAdd adds the value at the location the path points to and returns the resulting document
+func pointerAdd(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	token := path[0]
+	switch node := doc.(type) {
+	case map[string]interface{}:
+		if len(path) == 1 {
+			node[token] = value
+			return node, nil
+		}
+
+		child, ok := node[token]
+		if !ok {
+			return nil, fmt.Errorf("%w: member %q does not exist", ErrPatchPathNotFound, token)
+		}
+		child, err := pointerAdd(child, path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		node[token] = child
+		return node, nil
+	case []interface{}:
+		if len(path) == 1 {
+			idx, err := arrayIndex(token, len(node), true)
+			if err != nil {
+				return nil, err
+			}
+			node = append(node, nil)
+			copy(node[idx+1:], node[idx:])
+			node[idx] = value
+			return node, nil
+		}
+
+		idx, err := arrayIndex(token, len(node), false)
+		if err != nil {
+			return nil, err
+		}
+		child, err := pointerAdd(node[idx], path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		node[idx] = child
+		return node, nil
+	}
+	return nil, fmt.Errorf("%w: cannot resolve %q", ErrPatchPathNotFound, token)
+}
+
+// pointerRemove removes the value at the location the path points to and returns the resulting document
+func pointerRemove(doc interface{}, path []string) (interface{}, error) {
+	if len(path) == 0 {
+		return nil, nil
+	}
+
+	token := path[0]
+	switch node := doc.(type) {
+	case map[string]interface{}:
+		child, ok := node[token]
+		if !ok {
+			return nil, fmt.Errorf("%w: member %q does not exist", ErrPatchPathNotFound, token)
+		}
+		if len(path) == 1 {
+			delete(node, token)
+			return node, nil
+		}
+
+		child, err := pointerRemove(child, path[1:])
+		if err != nil {
+			return nil, err
+		}
+		node[token] = child
+		return node, nil
+	case []interface{}:
+		idx, err := arrayIndex(token, len(node), false)
+		if err != nil {
+			return nil, err
+		}
+		if len(path) == 1 {
+			return append(node[:idx], node[idx+1:]...), nil
+		}
+
+		child, err := pointerRemove(node[idx], path[1:])
+		if err != nil {
+			return nil, err
+		}
+		node[idx] = child
+		return node, nil
+	}
+	return nil, fmt.Errorf("%w: cannot resolve %q", ErrPatchPathNotFound, token)
+}
+
+// deepCopyJson copies the generic JSON value, so that the copy does not share maps or slices with the source
+func deepCopyJson(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		cp := make(map[string]interface{}, len(v))
+		for key, val := range v {
+			cp[key] = deepCopyJson(val)
+		}
+		return cp
+	case []interface{}:
+		cp := make([]interface{}, len(v))
+		for i, val := range v {
+			cp[i] = deepCopyJson(val)
+		}
+		return cp
+	}
+	return value
+}
+
+// jsonEqual compares two generic JSON values, numbers are compared by their numeric value
+func jsonEqual(a, b interface{}) bool {
+	switch av := a.(type) {
+	case json.Number:
+		bv, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		af, aErr := av.Float64()
+		bf, bErr := bv.Float64()
+		if aErr != nil || bErr != nil {
+			return av == bv
+		}
+		return af == bf
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for key, val := range av {
+			other, ok := bv[key]
+			if !ok || !jsonEqual(val, other) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for i := range av {
+			if !jsonEqual(av[i], bv[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return a == b
+}
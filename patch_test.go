@@ -0,0 +1,301 @@
+package procroute
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestMergePatch(t *testing.T) {
+	// test cases taken from RFC 7396 appendix A
+	tests := []struct {
+		target string
+		patch  string
+		want   string
+	}{
+		{target: `{"a":"b"}`, patch: `{"a":"c"}`, want: `{"a":"c"}`},
+		{target: `{"a":"b"}`, patch: `{"b":"c"}`, want: `{"a":"b","b":"c"}`},
+		{target: `{"a":"b"}`, patch: `{"a":null}`, want: `{}`},
+		{target: `{"a":"b","b":"c"}`, patch: `{"a":null}`, want: `{"b":"c"}`},
+		{target: `{"a":["b"]}`, patch: `{"a":"c"}`, want: `{"a":"c"}`},
+		{target: `{"a":"c"}`, patch: `{"a":["b"]}`, want: `{"a":["b"]}`},
+		{target: `{"a":{"b":"c"}}`, patch: `{"a":{"b":"d","c":null}}`, want: `{"a":{"b":"d"}}`},
+		{target: `{"a":[{"b":"c"}]}`, patch: `{"a":[1]}`, want: `{"a":[1]}`},
+		{target: `["a","b"]`, patch: `["c","d"]`, want: `["c","d"]`},
+		{target: `{"a":"b"}`, patch: `["c"]`, want: `["c"]`},
+		{target: `{"a":"foo"}`, patch: `null`, want: `null`},
+		{target: `{"a":"foo"}`, patch: `"bar"`, want: `"bar"`},
+		{target: `{"e":null}`, patch: `{"a":1}`, want: `{"a":1,"e":null}`},
+		{target: `[1,2]`, patch: `{"a":"b","c":null}`, want: `{"a":"b"}`},
+		{target: `{}`, patch: `{"a":{"bb":{"ccc":null}}}`, want: `{"a":{"bb":{}}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target+"+"+tt.patch, func(t *testing.T) {
+			got, err := applyPatch(MergePatchMimeType, []byte(tt.target), []byte(tt.patch))
+			if err != nil {
+				t.Fatalf("applyPatch() error = %v", err)
+			}
+			assertJsonEqual(t, got, tt.want)
+		})
+	}
+}
+
+func TestJsonPatch(t *testing.T) {
+	// most test cases are taken from RFC 6902 appendix A
+	tests := []struct {
+		name       string
+		document   string
+		patch      string
+		want       string
+		wantStatus int
+	}{
+		{
+			name:     "add_object_member",
+			document: `{"foo":"bar"}`,
+			patch:    `[{"op":"add","path":"/baz","value":"qux"}]`,
+			want:     `{"baz":"qux","foo":"bar"}`,
+		},
+		{
+			name:     "add_array_element",
+			document: `{"foo":["bar","baz"]}`,
+			patch:    `[{"op":"add","path":"/foo/1","value":"qux"}]`,
+			want:     `{"foo":["bar","qux","baz"]}`,
+		},
+		{
+			name:     "append_array_element",
+			document: `{"foo":["bar"]}`,
+			patch:    `[{"op":"add","path":"/foo/-","value":["abc","def"]}]`,
+			want:     `{"foo":["bar",["abc","def"]]}`,
+		},
+		{
+			name:     "remove_object_member",
+			document: `{"baz":"qux","foo":"bar"}`,
+			patch:    `[{"op":"remove","path":"/baz"}]`,
+			want:     `{"foo":"bar"}`,
+		},
+		{
+			name:     "remove_array_element",
+			document: `{"foo":["bar","qux","baz"]}`,
+			patch:    `[{"op":"remove","path":"/foo/1"}]`,
+			want:     `{"foo":["bar","baz"]}`,
+		},
+		{
+			name:     "replace",
+			document: `{"baz":"qux","foo":"bar"}`,
+			patch:    `[{"op":"replace","path":"/baz","value":"boo"}]`,
+			want:     `{"baz":"boo","foo":"bar"}`,
+		},
+		{
+			name:     "move_value",
+			document: `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`,
+			patch:    `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`,
+			want:     `{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`,
+		},
+		{
+			name:     "move_array_element",
+			document: `{"foo":["all","grass","cows","eat"]}`,
+			patch:    `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`,
+			want:     `{"foo":["all","cows","eat","grass"]}`,
+		},
+		{
+			name:     "copy_value",
+			document: `{"foo":{"bar":1}}`,
+			patch:    `[{"op":"copy","from":"/foo","path":"/baz"},{"op":"replace","path":"/baz/bar","value":2}]`,
+			want:     `{"foo":{"bar":1},"baz":{"bar":2}}`,
+		},
+		{
+			name:     "test_success",
+			document: `{"baz":"qux","foo":["a",2,"c"]}`,
+			patch:    `[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2.0}]`,
+			want:     `{"baz":"qux","foo":["a",2,"c"]}`,
+		},
+		{
+			name:     "escaped_pointer",
+			document: `{"/":9,"~1":10}`,
+			patch:    `[{"op":"test","path":"/~01","value":10},{"op":"remove","path":"/~1"}]`,
+			want:     `{"~1":10}`,
+		},
+		{
+			name:       "test_failure",
+			document:   `{"baz":"qux"}`,
+			patch:      `[{"op":"test","path":"/baz","value":"bar"}]`,
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name:       "add_to_nonexistent_target",
+			document:   `{"foo":"bar"}`,
+			patch:      `[{"op":"add","path":"/baz/bat","value":"qux"}]`,
+			wantStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:       "array_index_out_of_range",
+			document:   `{"foo":["bar"]}`,
+			patch:      `[{"op":"add","path":"/foo/2","value":"qux"}]`,
+			wantStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:       "move_into_child",
+			document:   `{"foo":{"bar":1}}`,
+			patch:      `[{"op":"move","from":"/foo","path":"/foo/bar/baz"}]`,
+			wantStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:       "unsupported_operation",
+			document:   `{"foo":"bar"}`,
+			patch:      `[{"op":"merge","path":"/foo","value":"qux"}]`,
+			wantStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:       "missing_value",
+			document:   `{"foo":"bar"}`,
+			patch:      `[{"op":"add","path":"/foo"}]`,
+			wantStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:       "malformed_document",
+			document:   `{"foo":"bar"}`,
+			patch:      `{"op":"add"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := applyPatch(JsonPatchMimeType, []byte(tt.document), []byte(tt.patch))
+			if tt.wantStatus != 0 {
+				if err == nil || err.Status != tt.wantStatus {
+					t.Fatalf("applyPatch() error = %+v, want status %v", err, tt.wantStatus)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("applyPatch() error = %v", err)
+			}
+			assertJsonEqual(t, got, tt.want)
+		})
+	}
+}
+
+func TestRouteSet_definePatchRoute(t *testing.T) {
+	tests := []struct {
+		name            string
+		rt              *patchExample
+		contentType     string
+		body            string
+		wantStatus      int
+		wantPatched     interface{}
+		wantAcceptPatch string
+	}{
+		{
+			name:        "parser_format",
+			rt:          &patchExample{},
+			contentType: "application/json",
+			body:        `{"name":"sample"}`,
+			wantStatus:  http.StatusOK,
+			wantPatched: map[string]interface{}{"name": "sample"},
+		},
+		{
+			name:        "merge_patch_typed",
+			rt:          &patchExample{current: data{Name: "sample", Value: 1}, typ: &data{}},
+			contentType: "application/merge-patch+json; charset=utf-8",
+			body:        `{"Value":2}`,
+			wantStatus:  http.StatusOK,
+			wantPatched: &data{Name: "sample", Value: 2},
+		},
+		{
+			name:        "json_patch",
+			rt:          &patchExample{current: data{Name: "sample", Value: 1}},
+			contentType: JsonPatchMimeType,
+			body:        `[{"op":"replace","path":"/Name","value":"patched"}]`,
+			wantStatus:  http.StatusOK,
+			wantPatched: map[string]interface{}{"Name": "patched", "Value": float64(1)},
+		},
+		{
+			name:        "patched_resource_does_not_fit",
+			rt:          &patchExample{current: data{Name: "sample", Value: 1}, typ: &data{}},
+			contentType: MergePatchMimeType,
+			body:        `{"Value":"two"}`,
+			wantStatus:  http.StatusUnprocessableEntity,
+		},
+		{
+			name:        "current_resource_not_found",
+			rt:          &patchExample{},
+			contentType: MergePatchMimeType,
+			body:        `{"Value":2}`,
+			wantStatus:  http.StatusNotFound,
+		},
+		{
+			name:        "unsupported_format",
+			rt:          &patchExample{},
+			contentType: "application/xml",
+			body:        `<data/>`,
+			wantStatus:  http.StatusUnsupportedMediaType,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := mux.NewRouter()
+			rs := NewRouteSet("/api", &exampleParser{}).AddRoutes(tt.rt)
+			rs.withLogger(&exampleLogger{}).withRouter(router)
+			if err := rs.build(); err != nil {
+				t.Fatalf("RouteSet.build() error = %v", err)
+			}
+
+			r := httptest.NewRequest("PATCH", "/api", strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", tt.contentType)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %v, want %v, body = %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if !reflect.DeepEqual(tt.rt.patched, tt.wantPatched) {
+				t.Errorf("patched = %+#v, want %+#v", tt.rt.patched, tt.wantPatched)
+			}
+			if got, want := w.Header().Get("Accept-Patch"), "application/json, application/merge-patch+json, application/json-patch+json"; got != want {
+				t.Errorf("Accept-Patch = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestRouteSet_definePatchRouteWithoutGet(t *testing.T) {
+	router := mux.NewRouter()
+	rs := NewRouteSet("/api", &exampleParser{}).AddRoutes(&patchOnlyExample{})
+	rs.withLogger(&exampleLogger{}).withRouter(router)
+	if err := rs.build(); err != nil {
+		t.Fatalf("RouteSet.build() error = %v", err)
+	}
+
+	r := httptest.NewRequest("PATCH", "/api", strings.NewReader(`{"a":1}`))
+	r.Header.Set("Content-Type", MergePatchMimeType)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+
+	if w.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("status = %v, want %v", w.Code, http.StatusUnsupportedMediaType)
+	}
+	if got := w.Header().Get("Accept-Patch"); got != "application/json" {
+		t.Errorf("Accept-Patch = %v, want %v", got, "application/json")
+	}
+}
+
+// assertJsonEqual compares the JSON document with the expected JSON string
+func assertJsonEqual(t *testing.T, got []byte, want string) {
+	t.Helper()
+
+	var gotDoc, wantDoc interface{}
+	if err := json.Unmarshal(got, &gotDoc); err != nil {
+		t.Fatalf("invalid json %s: %v", got, err)
+	}
+	if err := json.Unmarshal([]byte(want), &wantDoc); err != nil {
+		t.Fatalf("invalid json %s: %v", want, err)
+	}
+	if !reflect.DeepEqual(gotDoc, wantDoc) {
+		t.Errorf("document = %s, want %s", got, want)
+	}
+}
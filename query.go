@@ -0,0 +1,626 @@
+package procroute
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var (
+	ErrInvalidQuery = errors.New("invalid query")
+)
+
+// FilterOp represents an operator of the filter grammar
+type FilterOp string
+
+const (
+	OpAnd      FilterOp = "and"
+	OpOr       FilterOp = "or"
+	OpEq       FilterOp = "eq"
+	OpNe       FilterOp = "ne"
+	OpGt       FilterOp = "gt"
+	OpGe       FilterOp = "ge"
+	OpLt       FilterOp = "lt"
+	OpLe       FilterOp = "le"
+	OpContains FilterOp = "contains"
+	OpIn       FilterOp = "in"
+)
+
+// CollectionQuery defines an optional interface that receives the filter and sort query parameters of the request parsed into a Query.
+// The fields of the query are validated against the type returned by the QueryTyper interface, invalid queries are answered with 400 Bad Request.
+type CollectionQuery interface {
+	// SetQuery passes the parsed query to the route controller before the route is called.
+	//
+	// Example:
+	//  // GET /api/issues?filter=status eq 'open' and age gt 3&sort=-createdAt,name
+	//  func (m *MyType) SetQuery(query *procroute.Query) {
+	//  	m.query = query
+	//  }
+	//
+	//  func (m *MyType) GetAll(requestData interface{}) ([]interface{}, *HttpError) {
+	//  	issues := procroute.ApplyQuery(m.query, m.store.Issues())
+	//  	// do something
+	//  }
+	SetQuery(query *Query)
+}
+
+// QueryTyper defines an optional interface for routes implementing the CollectionQuery interface, which declares the type of the returned items.
+// The fields of the query are validated against the fields of this type and the values are converted to the field types.
+// Without it, the fields of the query are not validated.
+type QueryTyper interface {
+	// QueryType returns a value of the type of the items the filter and sort query parameters refer to.
+	//
+	// Example:
+	//  func (m *MyType) QueryType() interface{} {
+	//  	return Issue{}
+	//  }
+	QueryType() interface{}
+}
+
+// Query represents the parsed filter and sort query parameters of a collection request
+type Query struct {
+	// Filter is the root of the filter expression, nil if the request does not filter
+	Filter FilterExpr
+	// Sort contains the fields the items are sorted by, in order of precedence
+	Sort []SortField
+}
+
+// SortField represents a single field of the sort query parameter
+type SortField struct {
+	Field      string
+	Descending bool
+}
+
+// FilterExpr represents a node of the filter expression, which is a LogicalExpr, NotExpr or ComparisonExpr
+type FilterExpr interface {
+	// String returns the expression in the filter grammar
+	String() string
+	filterExpr()
+}
+
+// LogicalExpr combines two expressions by OpAnd or OpOr
+type LogicalExpr struct {
+	Op    FilterOp
+	Left  FilterExpr
+	Right FilterExpr
+}
+
+// NotExpr negates the expression
+type NotExpr struct {
+	Expr FilterExpr
+}
+
+// ComparisonExpr compares a field with a value. The value is a string, float64, bool, time.Time or nil.
+// For OpIn, the value is a []interface{} of such values.
+type ComparisonExpr struct {
+	Field string
+	Op    FilterOp
+	Value interface{}
+}
+
+func (l *LogicalExpr) filterExpr()    {}
+func (n *NotExpr) filterExpr()        {}
+func (c *ComparisonExpr) filterExpr() {}
+
+// String returns the expression in the filter grammar
+func (l *LogicalExpr) String() string {
+	return "(" + l.Left.String() + " " + string(l.Op) + " " + l.Right.String() + ")"
+}
+
+// String returns the expression in the filter grammar
+func (n *NotExpr) String() string {
+	return "not " + n.Expr.String()
+}
+
+// String returns the expression in the filter grammar
+func (c *ComparisonExpr) String() string {
+	if values, ok := c.Value.([]interface{}); ok {
+		literals := make([]string, 0, len(values))
+		for _, value := range values {
+			literals = append(literals, formatLiteral(value))
+		}
+		return c.Field + " " + string(c.Op) + " (" + strings.Join(literals, ", ") + ")"
+	}
+	return c.Field + " " + string(c.Op) + " " + formatLiteral(c.Value)
+}
+
+// formatLiteral returns the value as literal of the filter grammar
+func formatLiteral(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "'" + strings.ReplaceAll(v, "'", "''") + "'"
+	case time.Time:
+		return "'" + v.Format(time.RFC3339Nano) + "'"
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+	return fmt.Sprint(value)
+}
+
+// ParseQuery parses the filter and sort query parameters.
+// If model is not nil, the fields are validated against the fields of its type, which are named by their json struct tag,
+// and the values are converted to the type of the fields, e.g. strings compared with time.Time fields are parsed as RFC 3339 timestamps.
+//
+// The filter grammar:
+//  expr       = term { "or" term }
+//  term       = factor { "and" factor }
+//  factor     = "not" factor | "(" expr ")" | comparison
+//  comparison = field ( "eq" | "ne" | "gt" | "ge" | "lt" | "le" | "contains" ) value | field "in" "(" value { "," value } ")"
+//  value      = 'string' | number | "true" | "false" | "null"
+//
+// The sort parameter is a comma separated list of fields, a leading "-" sorts the field in descending order.
+func ParseQuery(filter, sort string, model interface{}) (*Query, error) {
+	query := &Query{}
+
+	if strings.TrimSpace(filter) != "" {
+		p := &filterParser{tokens: tokenizeFilter(filter)}
+		expr, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if tok := p.peek(); tok.kind != tokenEOF {
+			return nil, p.errorf(tok, "unexpected %s", tok)
+		}
+		query.Filter = expr
+	}
+
+	for _, field := range strings.Split(sort, ",") {
+		field = strings.TrimSpace(field)
+		sortField := SortField{Field: strings.TrimPrefix(strings.TrimPrefix(field, "-"), "+"), Descending: strings.HasPrefix(field, "-")}
+		if field == "" {
+			continue
+		}
+		if !isFieldPath(sortField.Field) {
+			return nil, fmt.Errorf("%w: invalid sort field %q", ErrInvalidQuery, field)
+		}
+		query.Sort = append(query.Sort, sortField)
+	}
+
+	if model != nil {
+		if err := query.bind(reflect.TypeOf(model)); err != nil {
+			return nil, err
+		}
+	}
+	return query, nil
+}
+
+// setQuery parses the query of the request and passes it to the route controller, if it implements the CollectionQuery interface
+func (rs *RouteSet) setQuery(routeController interface{}, r *http.Request) *HttpError {
+	m, ok := routeController.(CollectionQuery)
+	if !ok {
+		return nil
+	}
+
+	// the query refers to the returned items, which may differ from the type of the request body
+	var model interface{}
+	if t, ok := routeController.(QueryTyper); ok {
+		model = t.QueryType()
+	}
+
+	values := r.URL.Query()
+	query, err := ParseQuery(values.Get("filter"), values.Get("sort"), model)
+	if err != nil {
+		return BadRequest(err.Error()).WithCause(err)
+	}
+	m.SetQuery(query)
+	return nil
+}
+
+// bind validates the fields of the query against the model type and converts the values to the type of the fields
+func (q *Query) bind(model reflect.Type) error {
+	for _, sortField := range q.Sort {
+		typ, ok := lookupFieldType(model, sortField.Field)
+		if !ok {
+			return fmt.Errorf("%w: unknown sort field %s", ErrInvalidQuery, sortField.Field)
+		}
+		if category := valueCategory(typ); category == categoryOther {
+			return fmt.Errorf("%w: field %s can not be sorted", ErrInvalidQuery, sortField.Field)
+		}
+	}
+	return bindExpr(q.Filter, model)
+}
+
+// bindExpr validates and converts the comparisons of the expression
+func bindExpr(expr FilterExpr, model reflect.Type) error {
+	switch e := expr.(type) {
+	case *LogicalExpr:
+		if err := bindExpr(e.Left, model); err != nil {
+			return err
+		}
+		return bindExpr(e.Right, model)
+	case *NotExpr:
+		return bindExpr(e.Expr, model)
+	case *ComparisonExpr:
+		typ, ok := lookupFieldType(model, e.Field)
+		if !ok {
+			return fmt.Errorf("%w: unknown filter field %s", ErrInvalidQuery, e.Field)
+		}
+
+		if values, ok := e.Value.([]interface{}); ok {
+			for i, value := range values {
+				converted, err := bindValue(e, typ, value)
+				if err != nil {
+					return err
+				}
+				values[i] = converted
+			}
+			return nil
+		}
+
+		converted, err := bindValue(e, typ, e.Value)
+		if err != nil {
+			return err
+		}
+		e.Value = converted
+	}
+	return nil
+}
+
+// bindValue checks whether the operator and the value are applicable to the field type and converts the value to it
+func bindValue(e *ComparisonExpr, typ reflect.Type, value interface{}) (interface{}, error) {
+	category := valueCategory(typ)
+	if category == categoryAny {
+		return value, nil
+	}
+
+	if value == nil {
+		if e.Op == OpEq || e.Op == OpNe {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("%w: operator %s can not compare %s with null", ErrInvalidQuery, e.Op, e.Field)
+	}
+
+	switch e.Op {
+	case OpGt, OpGe, OpLt, OpLe:
+		if category == categoryBool || category == categoryOther {
+			return nil, fmt.Errorf("%w: operator %s is not supported by field %s", ErrInvalidQuery, e.Op, e.Field)
+		}
+	case OpContains:
+		if category != categoryString {
+			return nil, fmt.Errorf("%w: operator %s is not supported by field %s", ErrInvalidQuery, e.Op, e.Field)
+		}
+	}
+
+	converted, ok := convertScalar(value, category)
+	if !ok {
+		return nil, fmt.Errorf("%w: value %s is not applicable to field %s", ErrInvalidQuery, formatLiteral(value), e.Field)
+	}
+	return converted, nil
+}
+
+// lookupFieldType returns the type of the field addressed by the dot separated path.
+// Fields are named by their json struct tag, fields of embedded structs are promoted.
+func lookupFieldType(typ reflect.Type, path string) (reflect.Type, bool) {
+	for _, name := range strings.Split(path, ".") {
+		for typ.Kind() == reflect.Ptr {
+			typ = typ.Elem()
+		}
+
+		switch typ.Kind() {
+		case reflect.Interface:
+			return typ, true
+		case reflect.Map:
+			if typ.Key().Kind() != reflect.String {
+				return nil, false
+			}
+			typ = typ.Elem()
+		case reflect.Struct:
+			sf, ok := structFieldByName(typ, name)
+			if !ok {
+				return nil, false
+			}
+			typ = sf.Type
+		default:
+			return nil, false
+		}
+	}
+	return typ, true
+}
+
+// structFieldByName returns the exported field of the struct named by the json struct tag or the field name, including promoted fields
+func structFieldByName(typ reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < typ.NumField(); i++ {
+		sf := typ.Field(i)
+		fieldName, promoted := validationFieldName(sf)
+		if promoted {
+			embedded := sf.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if nested, ok := structFieldByName(embedded, name); ok {
+				nested.Index = append([]int{i}, nested.Index...)
+				return nested, true
+			}
+			continue
+		}
+		if sf.PkgPath == "" && fieldName == name {
+			return sf, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// tokenKind represents the kind of a token of the filter grammar
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenLParen
+	tokenRParen
+	tokenComma
+	tokenInvalid
+)
+
+// token represents a single token of the filter grammar
+type token struct {
+	kind  tokenKind
+	text  string
+	value interface{}
+	pos   int
+}
+
+// String returns the description of the token used in error messages
+func (t token) String() string {
+	if t.kind == tokenEOF {
+		return "end of filter"
+	}
+	return "'" + t.text + "'"
+}
+
+// tokenizeFilter splits the filter into tokens. Invalid input results in a tokenInvalid token, which fails the parser.
+func tokenizeFilter(filter string) []token {
+	tokens := []token{}
+	runes := []rune(filter)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenLParen, text: "(", pos: i})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenRParen, text: ")", pos: i})
+			i++
+		case r == ',':
+			tokens = append(tokens, token{kind: tokenComma, text: ",", pos: i})
+			i++
+		case r == '\'':
+			start, value := i, strings.Builder{}
+			for i++; ; i++ {
+				if i >= len(runes) {
+					return append(tokens, token{kind: tokenInvalid, text: string(runes[start:]), pos: start})
+				}
+				if runes[i] == '\'' {
+					// a quote is escaped by doubling it
+					if i+1 < len(runes) && runes[i+1] == '\'' {
+						value.WriteRune('\'')
+						i++
+						continue
+					}
+					break
+				}
+				value.WriteRune(runes[i])
+			}
+			i++
+			tokens = append(tokens, token{kind: tokenString, text: string(runes[start:i]), value: value.String(), pos: start})
+		case r == '-' || r == '+' || unicode.IsDigit(r):
+			start := i
+			for i++; i < len(runes) && (unicode.IsDigit(runes[i]) || strings.ContainsRune(".eE+-", runes[i])); i++ {
+			}
+			number, err := strconv.ParseFloat(string(runes[start:i]), 64)
+			if err != nil {
+				return append(tokens, token{kind: tokenInvalid, text: string(runes[start:i]), pos: start})
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: string(runes[start:i]), value: number, pos: start})
+		case isIdentRune(r, true):
+			start := i
+			for i++; i < len(runes) && isIdentRune(runes[i], false); i++ {
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: string(runes[start:i]), pos: start})
+		default:
+			return append(tokens, token{kind: tokenInvalid, text: string(r), pos: i})
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(runes)})
+}
+
+// isIdentRune reports whether the rune is part of an identifier, identifiers may contain dots to address nested fields
+func isIdentRune(r rune, first bool) bool {
+	if unicode.IsLetter(r) || r == '_' {
+		return true
+	}
+	return !first && (unicode.IsDigit(r) || r == '.')
+}
+
+// isFieldPath reports whether the string is a valid field path
+func isFieldPath(field string) bool {
+	for i, r := range field {
+		if !isIdentRune(r, i == 0) {
+			return false
+		}
+	}
+	return field != "" && !strings.HasSuffix(field, ".") && !strings.Contains(field, "..")
+}
+
+// filterParser is a recursive descent parser of the filter grammar
+type filterParser struct {
+	tokens []token
+	pos    int
+}
+
+// peek returns the current token without consuming it
+func (p *filterParser) peek() token {
+	return p.tokens[p.pos]
+}
+
+// next consumes the current token
+func (p *filterParser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// keyword consumes the current token, if it is the passed keyword
+func (p *filterParser) keyword(keyword string) bool {
+	if tok := p.peek(); tok.kind == tokenIdent && strings.EqualFold(tok.text, keyword) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// errorf returns an error that describes the position of the token
+func (p *filterParser) errorf(tok token, format string, args ...interface{}) error {
+	return fmt.Errorf("%w: "+format+" at position %d", append(append([]interface{}{ErrInvalidQuery}, args...), tok.pos+1)...)
+}
+
+// parseExpr parses the or expressions
+func (p *filterParser) parseExpr() (FilterExpr, error) {
+	left, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword(string(OpOr)) {
+		right, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		left = &LogicalExpr{Op: OpOr, Left: left, Right: right}
+	}
+	return left, nil
+}
+
+// parseTerm parses the and expressions, which bind stronger than or expressions
+func (p *filterParser) parseTerm() (FilterExpr, error) {
+	left, err := p.parseFactor()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword(string(OpAnd)) {
+		right, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		left = &LogicalExpr{Op: OpAnd, Left: left, Right: right}
+	}
+	return left, nil
+}
+
+// parseFactor parses negations, parenthesized expressions and comparisons
+func (p *filterParser) parseFactor() (FilterExpr, error) {
+	if p.keyword("not") {
+		expr, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		return &NotExpr{Expr: expr}, nil
+	}
+
+	if p.peek().kind == tokenLParen {
+		p.next()
+		expr, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if tok := p.next(); tok.kind != tokenRParen {
+			return nil, p.errorf(tok, "expected ')' instead of %s", tok)
+		}
+		return expr, nil
+	}
+
+	return p.parseComparison()
+}
+
+// parseComparison parses a comparison of a field with a value
+func (p *filterParser) parseComparison() (FilterExpr, error) {
+	field := p.next()
+	if field.kind != tokenIdent || isKeyword(field.text) || !isFieldPath(field.text) {
+		return nil, p.errorf(field, "expected field instead of %s", field)
+	}
+
+	opTok := p.next()
+	op := FilterOp(strings.ToLower(opTok.text))
+	switch {
+	case opTok.kind != tokenIdent:
+		return nil, p.errorf(opTok, "expected operator instead of %s", opTok)
+	case op == OpIn:
+		values, err := p.parseList()
+		if err != nil {
+			return nil, err
+		}
+		return &ComparisonExpr{Field: field.text, Op: op, Value: values}, nil
+	case op == OpEq, op == OpNe, op == OpGt, op == OpGe, op == OpLt, op == OpLe, op == OpContains:
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		return &ComparisonExpr{Field: field.text, Op: op, Value: value}, nil
+	}
+	return nil, p.errorf(opTok, "unknown operator %s", opTok)
+}
+
+// parseList parses the parenthesized values of the in operator
+func (p *filterParser) parseList() ([]interface{}, error) {
+	if tok := p.next(); tok.kind != tokenLParen {
+		return nil, p.errorf(tok, "expected '(' instead of %s", tok)
+	}
+
+	values := []interface{}{}
+	for {
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+
+		switch tok := p.next(); tok.kind {
+		case tokenComma:
+			continue
+		case tokenRParen:
+			return values, nil
+		default:
+			return nil, p.errorf(tok, "expected ',' or ')' instead of %s", tok)
+		}
+	}
+}
+
+// parseValue parses a literal value
+func (p *filterParser) parseValue() (interface{}, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokenString, tokenNumber:
+		return tok.value, nil
+	case tokenIdent:
+		switch strings.ToLower(tok.text) {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+	}
+	return nil, p.errorf(tok, "expected value instead of %s", tok)
+}
+
+// isKeyword reports whether the identifier is a keyword of the filter grammar
+func isKeyword(ident string) bool {
+	switch FilterOp(strings.ToLower(ident)) {
+	case OpAnd, OpOr, OpEq, OpNe, OpGt, OpGe, OpLt, OpLe, OpContains, OpIn, "not", "true", "false", "null":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,310 @@
+package procroute
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// category represents the kind of values a field can be compared with
+type category int
+
+const (
+	categoryAny category = iota
+	categoryString
+	categoryNumber
+	categoryBool
+	categoryTime
+	categoryOther
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// opaqueValue represents a value that is not nil, but can not be compared, e.g. a struct
+type opaqueValue struct{}
+
+// valueCategory returns the category of the field type
+func valueCategory(typ reflect.Type) category {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ == timeType {
+		return categoryTime
+	}
+
+	switch typ.Kind() {
+	case reflect.Interface:
+		return categoryAny
+	case reflect.String:
+		return categoryString
+	case reflect.Bool:
+		return categoryBool
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return categoryNumber
+	}
+	return categoryOther
+}
+
+// convertScalar converts the value into the representation of the category, which is a string, float64, bool or time.Time.
+// The bool is false, if the value can not be converted.
+func convertScalar(value interface{}, target category) (interface{}, bool) {
+	scalar, actual := toScalar(reflect.ValueOf(value))
+	switch {
+	case actual == target:
+		return scalar, true
+	case actual == categoryString && target == categoryTime:
+		t, err := time.Parse(time.RFC3339Nano, scalar.(string))
+		return t, err == nil
+	}
+	return nil, false
+}
+
+// toScalar returns the value as string, float64, bool or time.Time and its category.
+// Nil values return a nil scalar, values of other types return an opaqueValue and categoryOther.
+func toScalar(v reflect.Value) (interface{}, category) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, categoryAny
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return nil, categoryAny
+	}
+	if v.Type() == timeType {
+		if !v.CanInterface() {
+			return opaqueValue{}, categoryOther
+		}
+		return v.Interface().(time.Time), categoryTime
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), categoryString
+	case reflect.Bool:
+		return v.Bool(), categoryBool
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), categoryNumber
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(v.Uint()), categoryNumber
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), categoryNumber
+	}
+	return opaqueValue{}, categoryOther
+}
+
+// compareScalars compares two scalars of the same category. Nil is less than any other value.
+// The bool is false, if the scalars are not comparable.
+func compareScalars(a, b interface{}) (int, bool) {
+	if a == nil || b == nil {
+		switch {
+		case a == nil && b == nil:
+			return 0, true
+		case a == nil:
+			return -1, true
+		}
+		return 1, true
+	}
+
+	// strings compared with timestamps are parsed, since filter values of untyped queries are strings
+	if s, ok := a.(string); ok {
+		if _, isTime := b.(time.Time); isTime {
+			t, err := time.Parse(time.RFC3339Nano, s)
+			if err != nil {
+				return 0, false
+			}
+			a = t
+		}
+	}
+	if s, ok := b.(string); ok {
+		if _, isTime := a.(time.Time); isTime {
+			t, err := time.Parse(time.RFC3339Nano, s)
+			if err != nil {
+				return 0, false
+			}
+			b = t
+		}
+	}
+
+	switch x := a.(type) {
+	case string:
+		if y, ok := b.(string); ok {
+			return strings.Compare(x, y), true
+		}
+	case float64:
+		if y, ok := b.(float64); ok {
+			switch {
+			case x < y:
+				return -1, true
+			case x > y:
+				return 1, true
+			}
+			return 0, true
+		}
+	case bool:
+		if y, ok := b.(bool); ok {
+			switch {
+			case x == y:
+				return 0, true
+			case !x:
+				return -1, true
+			}
+			return 1, true
+		}
+	case time.Time:
+		if y, ok := b.(time.Time); ok {
+			switch {
+			case x.Before(y):
+				return -1, true
+			case x.After(y):
+				return 1, true
+			}
+			return 0, true
+		}
+	}
+	return 0, false
+}
+
+// ApplyQuery returns the items matching the filter of the query, sorted by the sort fields of the query.
+// It is meant for controllers that hold their resources in memory. Fields are resolved like by ParseQuery,
+// fields that do not exist are treated as null. A nil query returns a copy of the items.
+//
+// Example:
+//  func (m *MyType) GetAll(requestData interface{}) ([]interface{}, *HttpError) {
+//  	issues := procroute.ApplyQuery(m.query, m.issues)
+//  	// do something
+//  }
+func ApplyQuery[T any](query *Query, items []T) []T {
+	result := make([]T, 0, len(items))
+	for _, item := range items {
+		if query.Matches(item) {
+			result = append(result, item)
+		}
+	}
+
+	if query == nil || len(query.Sort) == 0 {
+		return result
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		a, b := reflect.ValueOf(result[i]), reflect.ValueOf(result[j])
+		for _, sortField := range query.Sort {
+			x, _ := toScalar(resolveField(a, sortField.Field))
+			y, _ := toScalar(resolveField(b, sortField.Field))
+			cmp, ok := compareScalars(x, y)
+			if !ok || cmp == 0 {
+				continue
+			}
+			if sortField.Descending {
+				return cmp > 0
+			}
+			return cmp < 0
+		}
+		return false
+	})
+	return result
+}
+
+// Matches reports whether the item matches the filter of the query. A query without filter matches every item.
+func (q *Query) Matches(item interface{}) bool {
+	if q == nil || q.Filter == nil {
+		return true
+	}
+	return evaluate(q.Filter, reflect.ValueOf(item))
+}
+
+// evaluate evaluates the expression against the item
+func evaluate(expr FilterExpr, item reflect.Value) bool {
+	switch e := expr.(type) {
+	case *LogicalExpr:
+		if e.Op == OpAnd {
+			return evaluate(e.Left, item) && evaluate(e.Right, item)
+		}
+		return evaluate(e.Left, item) || evaluate(e.Right, item)
+	case *NotExpr:
+		return !evaluate(e.Expr, item)
+	case *ComparisonExpr:
+		field, _ := toScalar(resolveField(item, e.Field))
+		return compare(e.Op, field, e.Value)
+	}
+	return false
+}
+
+// compare applies the operator to the field and the value. Values that are not comparable only match the ne operator.
+func compare(op FilterOp, field, value interface{}) bool {
+	switch op {
+	case OpIn:
+		values, _ := value.([]interface{})
+		for _, v := range values {
+			if compare(OpEq, field, v) {
+				return true
+			}
+		}
+		return false
+	case OpContains:
+		s, ok := field.(string)
+		substr, isString := value.(string)
+		return ok && isString && strings.Contains(s, substr)
+	}
+
+	scalar, _ := toScalar(reflect.ValueOf(value))
+	cmp, ok := compareScalars(field, scalar)
+	switch op {
+	case OpEq:
+		return ok && cmp == 0
+	case OpNe:
+		return !ok || cmp != 0
+	}
+
+	// null values are not ordered by filters
+	if !ok || field == nil || scalar == nil {
+		return false
+	}
+	switch op {
+	case OpGt:
+		return cmp > 0
+	case OpGe:
+		return cmp >= 0
+	case OpLt:
+		return cmp < 0
+	case OpLe:
+		return cmp <= 0
+	}
+	return false
+}
+
+// resolveField returns the value of the field addressed by the dot separated path or an invalid value, if the field does not exist
+func resolveField(v reflect.Value, path string) reflect.Value {
+	for _, name := range strings.Split(path, ".") {
+		for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+			if v.IsNil() {
+				return reflect.Value{}
+			}
+			v = v.Elem()
+		}
+
+		switch v.Kind() {
+		case reflect.Map:
+			if v.Type().Key().Kind() != reflect.String {
+				return reflect.Value{}
+			}
+			v = v.MapIndex(reflect.ValueOf(name).Convert(v.Type().Key()))
+		case reflect.Struct:
+			sf, ok := structFieldByName(v.Type(), name)
+			if !ok {
+				return reflect.Value{}
+			}
+			field, err := v.FieldByIndexErr(sf.Index)
+			if err != nil {
+				return reflect.Value{}
+			}
+			v = field
+		default:
+			return reflect.Value{}
+		}
+	}
+	return v
+}
@@ -0,0 +1,232 @@
+package procroute
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		name       string
+		filter     string
+		sort       string
+		model      interface{}
+		wantFilter string
+		wantSort   []SortField
+		wantErr    bool
+	}{
+		{
+			name:       "precedence",
+			filter:     "status eq 'open' or age gt 3 and not done eq true",
+			wantFilter: "(status eq 'open' or (age gt 3 and not done eq true))",
+		},
+		{
+			name:       "parentheses_and_keywords_case",
+			filter:     "(status EQ 'it''s' OR status eq null) AND owner.name contains 'x'",
+			wantFilter: "((status eq 'it''s' or status eq null) and owner.name contains 'x')",
+		},
+		{
+			name:       "in_list",
+			filter:     "age in (1, -2.5, 3e2)",
+			wantFilter: "age in (1, -2.5, 300)",
+		},
+		{
+			name:     "sort",
+			sort:     "-createdAt, name,+age",
+			wantSort: []SortField{{Field: "createdAt", Descending: true}, {Field: "name"}, {Field: "age"}},
+		},
+		{
+			name:       "bound_to_model",
+			filter:     "createdAt ge '2022-01-02T00:00:00Z' and owner.name eq 'leon'",
+			sort:       "-age,owner.name",
+			model:      &queryModel{},
+			wantFilter: "(createdAt ge '2022-01-02T00:00:00Z' and owner.name eq 'leon')",
+			wantSort:   []SortField{{Field: "age", Descending: true}, {Field: "owner.name"}},
+		},
+		{name: "unterminated_string", filter: "status eq 'open", wantErr: true},
+		{name: "missing_value", filter: "status eq", wantErr: true},
+		{name: "unknown_operator", filter: "status like 'open'", wantErr: true},
+		{name: "missing_parenthesis", filter: "(status eq 'open'", wantErr: true},
+		{name: "trailing_token", filter: "status eq 'open' 'closed'", wantErr: true},
+		{name: "keyword_as_field", filter: "and eq 1", wantErr: true},
+		{name: "invalid_sort_field", sort: "-", wantErr: true},
+		{name: "unknown_field", filter: "priority eq 1", model: queryModel{}, wantErr: true},
+		{name: "unknown_sort_field", sort: "priority", model: queryModel{}, wantErr: true},
+		{name: "unsortable_field", sort: "labels", model: queryModel{}, wantErr: true},
+		{name: "type_mismatch", filter: "age eq 'three'", model: queryModel{}, wantErr: true},
+		{name: "invalid_time", filter: "createdAt gt 'yesterday'", model: queryModel{}, wantErr: true},
+		{name: "ordered_bool", filter: "done gt false", model: queryModel{}, wantErr: true},
+		{name: "contains_number", filter: "age contains 1", model: queryModel{}, wantErr: true},
+		{name: "ordered_null", filter: "age lt null", model: queryModel{}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseQuery(tt.filter, tt.sort, tt.model)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseQuery() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidQuery) {
+					t.Errorf("ParseQuery() error = %v, want %v", err, ErrInvalidQuery)
+				}
+				return
+			}
+
+			gotFilter := ""
+			if got.Filter != nil {
+				gotFilter = got.Filter.String()
+			}
+			if gotFilter != tt.wantFilter {
+				t.Errorf("ParseQuery() filter = %v, want %v", gotFilter, tt.wantFilter)
+			}
+			if !reflect.DeepEqual(got.Sort, tt.wantSort) {
+				t.Errorf("ParseQuery() sort = %+v, want %+v", got.Sort, tt.wantSort)
+			}
+		})
+	}
+}
+
+func TestParseQuery_bindsTimeValues(t *testing.T) {
+	query, err := ParseQuery("createdAt gt '2022-01-02T00:00:00Z'", "", queryModel{})
+	if err != nil {
+		t.Fatalf("ParseQuery() error = %v", err)
+	}
+	if _, ok := query.Filter.(*ComparisonExpr).Value.(time.Time); !ok {
+		t.Errorf("ParseQuery() value = %T, want time.Time", query.Filter.(*ComparisonExpr).Value)
+	}
+}
+
+func TestApplyQuery(t *testing.T) {
+	day := func(d int) queryTimestamps {
+		return queryTimestamps{CreatedAt: time.Date(2022, 1, d, 0, 0, 0, 0, time.UTC)}
+	}
+	items := []queryModel{
+		{queryTimestamps: day(1), Status: "open", Age: 5, Owner: &queryOwner{Name: "leon"}},
+		{queryTimestamps: day(2), Status: "closed", Age: 1, Done: true},
+		{queryTimestamps: day(3), Status: "open", Age: 2, Owner: &queryOwner{Name: "anna"}},
+		{queryTimestamps: day(4), Status: "review", Age: 9},
+	}
+
+	tests := []struct {
+		name   string
+		filter string
+		sort   string
+		model  interface{}
+		want   []string
+	}{
+		{
+			name: "no_query",
+			want: []string{"open", "closed", "open", "review"},
+		},
+		{
+			name:   "and_or",
+			filter: "status eq 'open' and age gt 3 or done eq true",
+			model:  queryModel{},
+			want:   []string{"open", "closed"},
+		},
+		{
+			name:   "nested_field_and_null",
+			filter: "owner eq null or owner.name contains 'nn'",
+			sort:   "-age",
+			want:   []string{"review", "open", "closed"},
+		},
+		{
+			name:   "in_and_not",
+			filter: "not status in ('open', 'closed')",
+			model:  queryModel{},
+			want:   []string{"review"},
+		},
+		{
+			name:   "untyped_time_comparison",
+			filter: "createdAt ge '2022-01-03T00:00:00Z'",
+			sort:   "-createdAt",
+			want:   []string{"review", "open"},
+		},
+		{
+			name: "multiple_sort_fields",
+			sort: "status,-age",
+			want: []string{"closed", "open", "open", "review"},
+		},
+		{
+			name:   "unknown_field_is_null",
+			filter: "priority ne null",
+			want:   []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var query *Query
+			if tt.filter != "" || tt.sort != "" {
+				var err error
+				if query, err = ParseQuery(tt.filter, tt.sort, tt.model); err != nil {
+					t.Fatalf("ParseQuery() error = %v", err)
+				}
+			}
+
+			got := []string{}
+			for _, item := range ApplyQuery(query, items) {
+				got = append(got, item.Status)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ApplyQuery() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	query, _ := ParseQuery("", "-age", nil)
+	if sorted := ApplyQuery(query, items); sorted[0].Age != 9 || items[0].Age != 5 {
+		t.Errorf("ApplyQuery() must not sort the passed items")
+	}
+}
+
+func TestRouteSet_setQuery(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      url.Values
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "filter_and_sort",
+			query:      url.Values{"filter": {"age lt 5"}, "sort": {"-age"}},
+			wantStatus: http.StatusOK,
+			wantBody:   `["open","closed"]`,
+		},
+		{
+			name:       "without_query",
+			wantStatus: http.StatusOK,
+			wantBody:   `["closed","open","review"]`,
+		},
+		{
+			name:       "invalid_field",
+			query:      url.Values{"filter": {"priority eq 1"}},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "field_of_request_body",
+			query:      url.Values{"sort": {"Name"}},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			route := &queryExample{items: []queryModel{{Status: "closed", Age: 1}, {Status: "open", Age: 3}, {Status: "review", Age: 7}}}
+			rs := NewRouteSet("/api", &exampleParser{})
+
+			w := httptest.NewRecorder()
+			rs.defineGetAllRoute(w, httptest.NewRequest("GET", "/api?"+tt.query.Encode(), nil), route)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %v, want %v, body = %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantBody != "" && w.Body.String() != tt.wantBody {
+				t.Errorf("body = %s, want %s", w.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package procroute
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"runtime/debug"
+	"strings"
+)
+
+// SetDevelopmentMode provides a method that includes the panic value and the stack trace in the response of recovered panics.
+// The development mode must not be enabled in production, since it exposes internals of the application.
+func (rm *RouteMachine) SetDevelopmentMode(enabled bool) *RouteMachine {
+	rm.developmentMode = enabled
+	for _, routeSet := range rm.routeSets {
+		routeSet.withDevelopmentMode(enabled)
+	}
+	return rm
+}
+
+// withDevelopmentMode provides a method that sets the development mode of the route machine
+func (rs *RouteSet) withDevelopmentMode(enabled bool) *RouteSet {
+	rs.developmentMode = enabled
+	return rs
+}
+
+// withRecovery wraps the handler, so that panics are logged and answered with an internal server error encoded by the parser of the route set
+func (rs *RouteSet) withRecovery(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tw := &trackingResponseWriter{ResponseWriter: w}
+		defer func() {
+			if rec := recover(); rec != nil {
+				httpErr := recoveredError(rs.logger, r, rec, debug.Stack(), rs.developmentMode)
+				if !tw.written {
+					rs.writeError(w, r, httpErr)
+				}
+			}
+		}()
+		handler(tw, r)
+	}
+}
+
+// recoverPanic answers panics that were not recovered by a route set, e.g. panics of middlewares. It must be called by defer.
+func (rm *RouteMachine) recoverPanic(w *trackingResponseWriter, r *http.Request) {
+	rec := recover()
+	if rec == nil {
+		return
+	}
+
+	httpErr := recoveredError(rm.logger, r, rec, debug.Stack(), rm.developmentMode)
+	if !w.written {
+		rm.writeError(w.ResponseWriter, r, httpErr)
+	}
+}
+
+// recoveredError logs the recovered panic and returns the error sent to the client.
+// The panic value and the stack trace are only part of the error in development mode.
+// A http.ErrAbortHandler panic is passed on, since it is used to abort the response on purpose.
+func recoveredError(logger Loggable, r *http.Request, rec interface{}, stack []byte, developmentMode bool) *HttpError {
+	if rec == http.ErrAbortHandler {
+		panic(rec)
+	}
+
+	if logger != nil {
+		logger.Error("recovered panic while serving %s %s: %v\n%s", r.Method, r.URL.Path, rec, stack)
+	}
+
+	httpErr := InternalServerError(http.StatusText(http.StatusInternalServerError))
+	if err, ok := rec.(error); ok {
+		httpErr.WithCause(err)
+	}
+	if developmentMode {
+		httpErr.Message = fmt.Sprintf("panic: %v", rec)
+		httpErr.Stack = strings.Split(strings.TrimSpace(string(stack)), "\n")
+	}
+	return httpErr
+}
+
+// trackingResponseWriter records whether the response has been started, since an error can not be sent afterwards
+type trackingResponseWriter struct {
+	http.ResponseWriter
+	written bool
+}
+
+// WriteHeader records that the response has been started
+func (t *trackingResponseWriter) WriteHeader(status int) {
+	t.written = true
+	t.ResponseWriter.WriteHeader(status)
+}
+
+// Write records that the response has been started
+func (t *trackingResponseWriter) Write(b []byte) (int, error) {
+	t.written = true
+	return t.ResponseWriter.Write(b)
+}
+
+// Flush passes the flush to the underlying response writer, if supported
+func (t *trackingResponseWriter) Flush() {
+	if flusher, ok := t.ResponseWriter.(http.Flusher); ok {
+		t.written = true
+		flusher.Flush()
+	}
+}
+
+// Hijack passes the hijack to the underlying response writer, if supported
+func (t *trackingResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := t.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	t.written = true
+	return hijacker.Hijack()
+}
+
+// Unwrap returns the underlying response writer, which is used by http.ResponseController
+func (t *trackingResponseWriter) Unwrap() http.ResponseWriter {
+	return t.ResponseWriter
+}
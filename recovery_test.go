@@ -0,0 +1,108 @@
+package procroute
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRouteSet_withRecovery(t *testing.T) {
+	tests := []struct {
+		name            string
+		developmentMode bool
+		method          string
+		value           interface{}
+		middlewarePanic bool
+		wantStatus      int
+		wantMessage     string
+		wantStack       bool
+	}{
+		{
+			name:        "route_panic",
+			method:      "GET",
+			value:       "boom",
+			wantStatus:  http.StatusInternalServerError,
+			wantMessage: "Internal Server Error",
+		},
+		{
+			name:            "route_panic_in_development_mode",
+			developmentMode: true,
+			method:          "GET",
+			value:           "boom",
+			wantStatus:      http.StatusInternalServerError,
+			wantMessage:     "panic: boom",
+			wantStack:       true,
+		},
+		{
+			name:            "middleware_panic",
+			method:          "GET",
+			value:           "boom",
+			middlewarePanic: true,
+			wantStatus:      http.StatusInternalServerError,
+			wantMessage:     "Internal Server Error",
+		},
+		{
+			name:       "panic_after_response_started",
+			method:     "POST",
+			value:      "boom",
+			wantStatus: http.StatusAccepted,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := &recordingLogger{}
+			rm := NewRouteMachine("", 0, "/api", logger)
+			if err := rm.AddRouteSet(NewRouteSet("/sample", &exampleParser{}).AddRoutes(&panicExample{value: tt.value})); err != nil {
+				t.Fatalf("RouteMachine.AddRouteSet() error = %v", err)
+			}
+			rm.SetDevelopmentMode(tt.developmentMode)
+			if tt.middlewarePanic {
+				rm.AddMiddleware(&funcMiddleware{fn: func(next http.Handler) http.Handler {
+					return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						panic(tt.value)
+					})
+				}})
+			}
+
+			w := httptest.NewRecorder()
+			rm.ServeHTTP(w, httptest.NewRequest(tt.method, "/api/sample", nil))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %v, want %v", w.Code, tt.wantStatus)
+			}
+			if len(logger.errors) != 1 || !strings.Contains(logger.errors[0], "recovered panic while serving "+tt.method+" /api/sample: boom") {
+				t.Errorf("logged errors = %v, want the recovered panic", logger.errors)
+			}
+			if tt.wantMessage == "" {
+				return
+			}
+
+			got := HttpError{}
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatalf("json.Unmarshal() error = %v, body = %s", err, w.Body.String())
+			}
+			if got.Message != tt.wantMessage {
+				t.Errorf("message = %v, want %v", got.Message, tt.wantMessage)
+			}
+			if (len(got.Stack) > 0) != tt.wantStack {
+				t.Errorf("stack = %v, want stack %v", got.Stack, tt.wantStack)
+			}
+		})
+	}
+}
+
+func TestRouteSet_withRecoveryAbortHandler(t *testing.T) {
+	rs := NewRouteSet("/api", &exampleParser{}).withLogger(&exampleLogger{})
+	handler := rs.withRecovery(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	})
+
+	defer func() {
+		if rec := recover(); rec != http.ErrAbortHandler {
+			t.Errorf("recovered = %v, want %v", rec, http.ErrAbortHandler)
+		}
+	}()
+	handler(httptest.NewRecorder(), httptest.NewRequest("GET", "/api", nil))
+}
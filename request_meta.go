@@ -0,0 +1,62 @@
+package procroute
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"net/url"
+)
+
+// RequestMeta contains the request information that is not part of the request body, url params or query params.
+type RequestMeta struct {
+	// Method is the http method of the request
+	Method string
+	// URL is the requested url
+	URL *url.URL
+	// Host is the host the request has been sent to
+	Host string
+	// Header contains the request headers
+	Header http.Header
+	// Cookies contains the cookies sent with the request
+	Cookies []*http.Cookie
+	// RemoteAddr is the network address of the client or the last proxy
+	RemoteAddr string
+	// TLS is the tls connection state, or nil if the request has not been sent via tls
+	TLS *tls.ConnectionState
+}
+
+// newRequestMeta collects the metadata of the request
+func newRequestMeta(r *http.Request) *RequestMeta {
+	return &RequestMeta{
+		Method:     r.Method,
+		URL:        r.URL,
+		Host:       r.Host,
+		Header:     r.Header,
+		Cookies:    r.Cookies(),
+		RemoteAddr: r.RemoteAddr,
+		TLS:        r.TLS,
+	}
+}
+
+// Cookie returns the cookie with the passed name or nil, if no such cookie has been sent
+func (m *RequestMeta) Cookie(name string) *http.Cookie {
+	for _, cookie := range m.Cookies {
+		if cookie.Name == name {
+			return cookie
+		}
+	}
+	return nil
+}
+
+// RequestMetaFromContext returns the request metadata of the request the context belongs to.
+// It is the context aware alternative to the RequestMetadata interface.
+//
+// Example:
+//  procroute.Get(rs, "", func(ctx context.Context, _ struct{}) ([]Model, error) {
+//  	tenant := procroute.RequestMetaFromContext(ctx).Header.Get("X-Tenant")
+//  	// do something
+//  })
+func RequestMetaFromContext(ctx context.Context) *RequestMeta {
+	meta, _ := ctx.Value(requestMetaContextKey).(*RequestMeta)
+	return meta
+}
@@ -0,0 +1,115 @@
+package procroute
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestRouteSet_doHttpOpRequestMeta(t *testing.T) {
+	r := httptest.NewRequest("GET", "https://example.local/api?id=1", nil)
+	r.Header.Set("X-Tenant", "tenant-a")
+	r.AddCookie(&http.Cookie{Name: "session", Value: "abc"})
+	r.RemoteAddr = "10.0.0.1:1234"
+
+	rt := &requestMetaExample{}
+	rs := &RouteSet{
+		parser: &exampleParser{},
+	}
+	if _, err := rs.doHttpOp(rt, r); err != nil {
+		t.Fatalf("RouteSet.doHttpOp() error = %v", err)
+	}
+
+	if rt.meta == nil {
+		t.Fatalf("RouteSet.doHttpOp() request meta not set")
+	}
+	if rt.meta.Method != "GET" {
+		t.Errorf("RequestMeta.Method = %v, want %v", rt.meta.Method, "GET")
+	}
+	if got := rt.meta.Header.Get("X-Tenant"); got != "tenant-a" {
+		t.Errorf("RequestMeta.Header = %v, want %v", got, "tenant-a")
+	}
+	if got := rt.meta.Cookie("session"); got == nil || got.Value != "abc" {
+		t.Errorf("RequestMeta.Cookie() = %v, want %v", got, "abc")
+	}
+	if rt.meta.RemoteAddr != "10.0.0.1:1234" {
+		t.Errorf("RequestMeta.RemoteAddr = %v, want %v", rt.meta.RemoteAddr, "10.0.0.1:1234")
+	}
+	if rt.meta.TLS == nil {
+		t.Errorf("RequestMeta.TLS = nil, want tls connection state")
+	}
+	if rt.meta.Host != "example.local" {
+		t.Errorf("RequestMeta.Host = %v, want %v", rt.meta.Host, "example.local")
+	}
+}
+
+func TestRequestMeta_Cookie(t *testing.T) {
+	meta := &RequestMeta{
+		Cookies: []*http.Cookie{
+			{Name: "session", Value: "abc"},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		cookie    string
+		wantValue string
+		wantNil   bool
+	}{
+		{
+			name:      "existing",
+			cookie:    "session",
+			wantValue: "abc",
+		},
+		{
+			name:    "missing",
+			cookie:  "missing",
+			wantNil: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := meta.Cookie(tt.cookie)
+			if (got == nil) != tt.wantNil {
+				t.Fatalf("RequestMeta.Cookie() = %v, wantNil %v", got, tt.wantNil)
+			}
+			if got != nil && got.Value != tt.wantValue {
+				t.Errorf("RequestMeta.Cookie() = %v, want %v", got.Value, tt.wantValue)
+			}
+		})
+	}
+}
+
+func TestRequestMetaFromContext(t *testing.T) {
+	var meta *RequestMeta
+	rs := Get(NewRouteSet("/api", &exampleParser{}), "", func(ctx context.Context, _ struct{}) (struct{}, error) {
+		meta = RequestMetaFromContext(ctx)
+		return struct{}{}, nil
+	})
+	router := mux.NewRouter()
+	rs.withLogger(&exampleLogger{}).withRouter(router)
+	if err := rs.build(); err != nil {
+		t.Fatalf("RouteSet.build() error = %v", err)
+	}
+
+	r := httptest.NewRequest("GET", "/api", nil)
+	r.Header.Set("Accept-Language", "de")
+	router.ServeHTTP(httptest.NewRecorder(), r)
+
+	if meta == nil {
+		t.Fatalf("RequestMetaFromContext() = nil")
+	}
+	if got := meta.Header.Get("Accept-Language"); got != "de" {
+		t.Errorf("RequestMeta.Header = %v, want %v", got, "de")
+	}
+	if meta.TLS != nil {
+		t.Errorf("RequestMeta.TLS = %v, want nil", meta.TLS)
+	}
+
+	if got := RequestMetaFromContext(context.Background()); got != nil {
+		t.Errorf("RequestMetaFromContext() = %v, want nil", got)
+	}
+}
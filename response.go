@@ -0,0 +1,193 @@
+package procroute
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Response represents an envelope that can be returned by routes to customize the http response.
+// Get routes and functional routes may return a Response as data, post, update and delete routes
+// return it by implementing the corresponding WithResponse interface.
+//
+// Example:
+//  func (m *MyType) PostWithResponse(ctx context.Context, requestData interface{}) (*procroute.Response, error) {
+//  	model, err := m.store.Create(ctx, requestData)
+//  	if err != nil {
+//  		return nil, err
+//  	}
+//  	return &procroute.Response{
+//  		Status:  http.StatusCreated,
+//  		Headers: http.Header{"Location": []string{"/api/example/" + model.ID}},
+//  		Body:    model,
+//  	}, nil
+//  }
+type Response struct {
+	// Status is the http status code of the response. If not set, the default status of the route is used.
+	Status int
+	// Headers are added to the response headers
+	Headers http.Header
+	// Cookies are sent as Set-Cookie headers
+	Cookies []*http.Cookie
+	// Body is encoded by the parser of the route set. If nil, no body is sent.
+	Body interface{}
+}
+
+// asResponse returns the response envelope, if data is a Response or a pointer to a Response
+func asResponse(data interface{}) (*Response, bool) {
+	switch resp := data.(type) {
+	case *Response:
+		return resp, resp != nil
+	case Response:
+		return &resp, true
+	}
+	return nil, false
+}
+
+// writeData marshals the data by the parser negotiated by the Accept header and sends it back to the client with the passed status.
+// If data is a response envelope, it is written by writeResponse instead.
+func (rs *RouteSet) writeData(w http.ResponseWriter, r *http.Request, status int, data interface{}) {
+	if resp, ok := asResponse(data); ok {
+		rs.writeResponse(w, r, status, resp)
+		return
+	}
+
+	parser, err := rs.encoderFor(r)
+	if err != nil {
+		rs.writeError(w, r, err)
+		return
+	}
+
+	rs.writeBody(w, r, parser, status, data, func() {
+		varyAccept(w)
+		w.Header().Add("Content-Type", parser.MimeType())
+	})
+}
+
+// writeResponse sends the response envelope back to the client.
+// The default status is used if resp is nil or does not define a status.
+func (rs *RouteSet) writeResponse(w http.ResponseWriter, r *http.Request, defaultStatus int, resp *Response) {
+	if resp == nil {
+		resp = &Response{}
+	}
+
+	status := resp.Status
+	if status == 0 {
+		status = defaultStatus
+	}
+
+	parser, err := rs.encoderFor(r)
+	if err != nil {
+		rs.writeError(w, r, err)
+		return
+	}
+
+	setHeaders := func() {
+		varyAccept(w)
+		if bodyAllowed(status) {
+			w.Header().Set("Content-Type", parser.MimeType())
+		}
+		applyHeaders(w, resp.Headers)
+		for _, cookie := range resp.Cookies {
+			http.SetCookie(w, cookie)
+		}
+	}
+
+	if resp.Body == nil || !bodyAllowed(status) {
+		setHeaders()
+		w.WriteHeader(status)
+		return
+	}
+	rs.writeBody(w, r, parser, status, resp.Body, setHeaders)
+}
+
+// writeBody encodes the body by the parser and sends it with the status, after setHeaders prepared the response headers.
+// Stream parsers encode the body straight into the response writer, the headers are written with the first encoded bytes,
+// so that encoding errors can still be answered with an error response.
+func (rs *RouteSet) writeBody(w http.ResponseWriter, r *http.Request, parser Parser, status int, body interface{}, setHeaders func()) {
+	if encoded, ok := body.(*encodedBody); ok && encoded.parser == parser {
+		setHeaders()
+		w.WriteHeader(status)
+		w.Write(encoded.bts)
+		return
+	}
+
+	streamParser, ok := parser.(StreamParser)
+	if !ok {
+		bts, err := rs.marshal(parser, body)
+		if err != nil {
+			rs.writeError(w, r, err)
+			return
+		}
+
+		setHeaders()
+		w.WriteHeader(status)
+		w.Write(bts)
+		return
+	}
+
+	hw := &headerWriter{ResponseWriter: w, writeHeader: func() {
+		setHeaders()
+		w.WriteHeader(status)
+	}}
+	if err := rs.encode(streamParser, hw, body); err != nil {
+		if !hw.written {
+			rs.writeError(w, r, err)
+			return
+		}
+		// the status has already been sent, so the connection is aborted to signal the client that the response is incomplete
+		rs.logger.Error("failed to encode the response of %s %s: %v", r.Method, r.URL.Path, err)
+		panic(http.ErrAbortHandler)
+	}
+	hw.writeHeaderOnce()
+}
+
+// headerWriter writes the response headers on the first write to the response writer
+type headerWriter struct {
+	http.ResponseWriter
+	writeHeader func()
+	written     bool
+}
+
+// Write writes the headers, if not yet done, and the bytes to the response writer
+func (h *headerWriter) Write(b []byte) (int, error) {
+	h.writeHeaderOnce()
+	return h.ResponseWriter.Write(b)
+}
+
+// writeHeaderOnce writes the response headers, unless they have already been written
+func (h *headerWriter) writeHeaderOnce() {
+	if !h.written {
+		h.written = true
+		h.writeHeader()
+	}
+}
+
+// applyHeaders sets the headers of a response envelope on the response.
+// Fields of the Vary header are added to the fields listed already, e.g. Accept, so that caches keep distinguishing the negotiated representations.
+func applyHeaders(w http.ResponseWriter, headers http.Header) {
+	for key, values := range headers {
+		key = http.CanonicalHeaderKey(key)
+		if key != "Vary" {
+			w.Header()[key] = values
+			continue
+		}
+		for _, value := range values {
+			for _, field := range strings.Split(value, ",") {
+				if field = strings.TrimSpace(field); field != "" {
+					addVary(w.Header(), field)
+				}
+			}
+		}
+	}
+}
+
+// bodyAllowed reports whether a response with the passed status may contain a body
+func bodyAllowed(status int) bool {
+	switch {
+	case status >= 100 && status <= 199:
+		return false
+	case status == http.StatusNoContent, status == http.StatusNotModified:
+		return false
+	}
+	return true
+}
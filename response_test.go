@@ -0,0 +1,269 @@
+package procroute
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestRouteSet_responseRoutes(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		target      string
+		resp        *Response
+		err         error
+		wantStatus  int
+		wantBody    string
+		wantHeaders map[string]string
+	}{
+		{
+			name:   "get",
+			method: "GET",
+			target: "/api/1",
+			resp: &Response{
+				Headers: http.Header{"cache-control": []string{"max-age=60"}},
+				Body:    data{Name: "sample", Value: 1},
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"Name":"sample","Value":1}`,
+			wantHeaders: map[string]string{
+				"Cache-Control": "max-age=60",
+				"Content-Type":  "application/json",
+			},
+		},
+		{
+			name:   "get_all",
+			method: "GET",
+			target: "/api",
+			resp: &Response{
+				Status: http.StatusPartialContent,
+				Body:   []data{{Name: "sample", Value: 1}},
+			},
+			wantStatus: http.StatusPartialContent,
+			wantBody:   `[{"Name":"sample","Value":1}]`,
+		},
+		{
+			name:       "get_all_nil_response",
+			method:     "GET",
+			target:     "/api",
+			resp:       nil,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:   "post_created_with_location",
+			method: "POST",
+			target: "/api",
+			resp: &Response{
+				Headers: http.Header{"Location": []string{"/api/1"}},
+				Cookies: []*http.Cookie{{Name: "session", Value: "abc"}},
+				Body:    data{Name: "sample", Value: 1},
+			},
+			wantStatus: http.StatusCreated,
+			wantBody:   `{"Name":"sample","Value":1}`,
+			wantHeaders: map[string]string{
+				"Location":   "/api/1",
+				"Set-Cookie": "session=abc",
+			},
+		},
+		{
+			name:   "post_accepted",
+			method: "POST",
+			target: "/api",
+			resp: &Response{
+				Status: http.StatusAccepted,
+			},
+			wantStatus: http.StatusAccepted,
+		},
+		{
+			name:       "update_nil_response",
+			method:     "PUT",
+			target:     "/api/1",
+			resp:       nil,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:   "delete_no_content",
+			method: "DELETE",
+			target: "/api/1",
+			resp: &Response{
+				Status: http.StatusNoContent,
+				Body:   data{Name: "ignored"},
+			},
+			wantStatus: http.StatusNoContent,
+			wantHeaders: map[string]string{
+				"Content-Type": "",
+			},
+		},
+		{
+			name:       "delete_error",
+			method:     "DELETE",
+			target:     "/api/1",
+			err:        &HttpError{Status: http.StatusConflict, Message: "conflict"},
+			wantStatus: http.StatusConflict,
+			wantBody:   `{"Status":409,"ErrorCode":"","Message":"conflict"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := mux.NewRouter()
+			rs := NewRouteSet("/api", &exampleParser{}).AddRoutes(&responseExample{resp: tt.resp, err: tt.err})
+			rs.withLogger(&exampleLogger{}).withRouter(router)
+			if err := rs.build(); err != nil {
+				t.Fatalf("RouteSet.build() error = %v", err)
+			}
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, nil))
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %v, want %v", w.Code, tt.wantStatus)
+			}
+			if got := w.Body.String(); got != tt.wantBody {
+				t.Errorf("body = %v, want %v", got, tt.wantBody)
+			}
+			for key, want := range tt.wantHeaders {
+				if got := strings.Join(w.Header().Values(key), ", "); got != want {
+					t.Errorf("header %s = %v, want %v", key, got, want)
+				}
+			}
+		})
+	}
+}
+
+func Test_asResponse(t *testing.T) {
+	var nilResponse *Response
+
+	tests := []struct {
+		name   string
+		data   interface{}
+		wantOk bool
+	}{
+		{
+			name:   "pointer",
+			data:   &Response{},
+			wantOk: true,
+		},
+		{
+			name:   "value",
+			data:   Response{},
+			wantOk: true,
+		},
+		{
+			name:   "nil_pointer",
+			data:   nilResponse,
+			wantOk: false,
+		},
+		{
+			name:   "other",
+			data:   data{},
+			wantOk: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := asResponse(tt.data); ok != tt.wantOk {
+				t.Errorf("asResponse() ok = %v, want %v", ok, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestRouteSet_writeBody(t *testing.T) {
+	tests := []struct {
+		name         string
+		data         interface{}
+		wantStatus   int
+		wantBody     string
+		wantHeaders  map[string]string
+		wantEncoders int
+	}{
+		{
+			name:       "encoded",
+			data:       data{Name: "sample", Value: 1},
+			wantStatus: http.StatusCreated,
+			wantBody:   "{\"Name\":\"sample\",\"Value\":1}\n",
+			wantHeaders: map[string]string{
+				"Content-Type": "application/json",
+				"Vary":         "Accept",
+			},
+			wantEncoders: 1,
+		},
+		{
+			name: "response_envelope",
+			data: &Response{
+				Status:  http.StatusAccepted,
+				Headers: http.Header{"Location": []string{"/api/1"}},
+				Body:    data{Name: "sample", Value: 1},
+			},
+			wantStatus: http.StatusAccepted,
+			wantBody:   "{\"Name\":\"sample\",\"Value\":1}\n",
+			wantHeaders: map[string]string{
+				"Content-Type": "application/json",
+				"Location":     "/api/1",
+			},
+			wantEncoders: 1,
+		},
+		{
+			name: "response_vary",
+			data: &Response{
+				Headers: http.Header{"Vary": []string{"Origin, accept"}},
+				Body:    data{Name: "sample", Value: 1},
+			},
+			wantStatus: http.StatusCreated,
+			wantBody:   "{\"Name\":\"sample\",\"Value\":1}\n",
+			wantHeaders: map[string]string{
+				"Vary": "Accept, Origin",
+			},
+			wantEncoders: 1,
+		},
+		{
+			name: "response_without_body",
+			data: &Response{
+				Status:  http.StatusNoContent,
+				Headers: http.Header{"Location": []string{"/api/1"}},
+			},
+			wantStatus: http.StatusNoContent,
+			wantBody:   "",
+			wantHeaders: map[string]string{
+				"Location": "/api/1",
+			},
+		},
+		{
+			name:       "encoding_error",
+			data:       &Response{Headers: http.Header{"Location": []string{"/api/1"}}, Body: make(chan int)},
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   `{"Status":500,"ErrorCode":"","Message":"json: unsupported type: chan int"}`,
+			wantHeaders: map[string]string{
+				"Location": "",
+			},
+			wantEncoders: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := &exampleStreamParser{}
+			rs := NewRouteSet("/api", parser).withLogger(&exampleLogger{})
+
+			w := httptest.NewRecorder()
+			rs.writeData(w, httptest.NewRequest("POST", "/api", nil), http.StatusCreated, tt.data)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %v, want %v, body = %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if got := w.Body.String(); got != tt.wantBody {
+				t.Errorf("body = %q, want %q", got, tt.wantBody)
+			}
+			for key, want := range tt.wantHeaders {
+				if got := strings.Join(w.Header().Values(key), ", "); got != want {
+					t.Errorf("header %s = %v, want %v", key, got, want)
+				}
+			}
+			if parser.encoders != tt.wantEncoders {
+				t.Errorf("encoders = %v, want %v", parser.encoders, tt.wantEncoders)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package procroute
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+var (
+	ErrHandlerFuncIsNil = errors.New("handler func is nil")
+)
+
+// HandlerFunc defines the signature of typed route handlers registered by Get, Post, Put, Patch and Delete.
+// The request body is decoded into Req by the parser of the route set, the returned Resp is encoded the same way.
+// Resp may be a Response to customize the status, headers and cookies of the http response.
+// The returned error may be a *HttpError to define the response status, other errors are translated by the error mappings of MapError and MapErrorType, unmapped errors result in an internal server error without details.
+type HandlerFunc[Req, Resp any] func(ctx context.Context, req Req) (Resp, error)
+
+// funcRoute represents a route registered by the functional route api
+type funcRoute struct {
+	method string
+	name   string
+	path   string
+	status int
+
+	newRequest func() interface{}
+	handle     func(ctx context.Context, requestData interface{}) (interface{}, error)
+}
+
+// newFuncRoute wraps the typed handler into an untyped route
+func newFuncRoute[Req, Resp any](method, name string, status int, path string, handler HandlerFunc[Req, Resp]) *funcRoute {
+	rt := &funcRoute{
+		method: method,
+		name:   name,
+		path:   path,
+		status: status,
+		newRequest: func() interface{} {
+			return new(Req)
+		},
+	}
+
+	if handler != nil {
+		rt.handle = func(ctx context.Context, requestData interface{}) (interface{}, error) {
+			var req Req
+			// the request data is nil if the request body is empty
+			if requestData != nil {
+				req = *requestData.(*Req)
+			}
+			return handler(ctx, req)
+		}
+	}
+	return rt
+}
+
+// Type implements the Typer interface, so that the request body is decoded into Req
+func (f *funcRoute) Type() interface{} {
+	return f.newRequest()
+}
+
+// Get registers a typed get route at the passed path, relative to the base path of the route set.
+//
+// Example:
+//  procroute.Get(rs, "/{id}", func(ctx context.Context, _ struct{}) (*Model, error) {
+//  	return store.Find(ctx, procroute.UrlParamsFromContext(ctx)["id"])
+//  })
+func Get[Req, Resp any](rs *RouteSet, path string, handler HandlerFunc[Req, Resp]) *RouteSet {
+	return rs.AddRoutes(newFuncRoute(http.MethodGet, "get", http.StatusOK, path, handler))
+}
+
+// Post registers a typed post route at the passed path, relative to the base path of the route set.
+// On success, the returned value is sent with status 201 Created.
+//
+// Example:
+//  procroute.Post(rs, "", func(ctx context.Context, model Model) (*Model, error) {
+//  	return store.Create(ctx, model)
+//  })
+func Post[Req, Resp any](rs *RouteSet, path string, handler HandlerFunc[Req, Resp]) *RouteSet {
+	return rs.AddRoutes(newFuncRoute(http.MethodPost, "post", http.StatusCreated, path, handler))
+}
+
+// Put registers a typed update route at the passed path, relative to the base path of the route set.
+//
+// Example:
+//  procroute.Put(rs, "/{id}", func(ctx context.Context, model Model) (*Model, error) {
+//  	return store.Update(ctx, procroute.UrlParamsFromContext(ctx)["id"], model)
+//  })
+func Put[Req, Resp any](rs *RouteSet, path string, handler HandlerFunc[Req, Resp]) *RouteSet {
+	return rs.AddRoutes(newFuncRoute(http.MethodPut, "update", http.StatusOK, path, handler))
+}
+
+// Patch registers a typed patch route at the passed path, relative to the base path of the route set.
+//
+// Example:
+//  procroute.Patch(rs, "/{id}", func(ctx context.Context, changes map[string]interface{}) (*Model, error) {
+//  	return store.Patch(ctx, procroute.UrlParamsFromContext(ctx)["id"], changes)
+//  })
+func Patch[Req, Resp any](rs *RouteSet, path string, handler HandlerFunc[Req, Resp]) *RouteSet {
+	return rs.AddRoutes(newFuncRoute(http.MethodPatch, "patch", http.StatusOK, path, handler))
+}
+
+// Delete registers a typed delete route at the passed path, relative to the base path of the route set.
+//
+// Example:
+//  procroute.Delete(rs, "/{id}", func(ctx context.Context, _ struct{}) (struct{}, error) {
+//  	return struct{}{}, store.Delete(ctx, procroute.UrlParamsFromContext(ctx)["id"])
+//  })
+func Delete[Req, Resp any](rs *RouteSet, path string, handler HandlerFunc[Req, Resp]) *RouteSet {
+	return rs.AddRoutes(newFuncRoute(http.MethodDelete, "delete", http.StatusOK, path, handler))
+}
+
+// registerFuncRoute creates a new route registered by the functional route api
+func (rs *RouteSet) registerFuncRoute(rt *funcRoute) error {
+	if rt.handle == nil {
+		return ErrHandlerFuncIsNil
+	}
+
+	path := rs.buildPath(rt.path)
+	rs.logger.Info("registered %s route at: %s", rt.name, path)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rs.defineFuncRoute(w, r, rt)
+	})
+	methods := []string{rt.method}
+	// get routes answer HEAD requests as well and are cached, other routes invalidate the cached responses
+	if rt.method == http.MethodGet {
+		handler, methods = withHead(rs.withCache(rt, handler)), []string{http.MethodGet, http.MethodHead}
+	} else {
+		handler = rs.withInvalidation(handler)
+	}
+	rs.handle(path, handler, methods...)
+
+	return nil
+}
+
+// defineFuncRoute defines the structure used for routes registered by the functional route api
+func (rs *RouteSet) defineFuncRoute(w http.ResponseWriter, r *http.Request, rt *funcRoute) {
+	request, err := rs.doHttpOp(rt, r)
+	if err != nil {
+		rs.writeError(w, r, err)
+		return
+	}
+
+	data, handleErr := rt.handle(requestContext(r), request)
+	if httpErr := rs.toHttpError(handleErr); httpErr != nil {
+		rs.writeError(w, r, httpErr)
+		return
+	}
+
+	rs.writeData(w, r, rt.status, data)
+}
@@ -0,0 +1,157 @@
+package procroute
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestRouteSet_funcRoutes(t *testing.T) {
+	rs := NewRouteSet("/api", &exampleParser{})
+	Get(rs, "/{id}", func(ctx context.Context, _ struct{}) (data, error) {
+		id := UrlParamsFromContext(ctx)["id"]
+		if id == "404" {
+			return data{}, &HttpError{Status: http.StatusNotFound, Message: "not found"}
+		}
+		return data{Name: id, Value: len(QueryParamsFromContext(ctx))}, nil
+	})
+	Post(rs, "", func(ctx context.Context, req data) (data, error) {
+		req.Value++
+		return req, nil
+	})
+	Put(rs, "/{id}", func(ctx context.Context, req *data) (*data, error) {
+		if req == nil {
+			return nil, errors.New("missing body")
+		}
+		req.Name = UrlParamsFromContext(ctx)["id"]
+		return req, nil
+	})
+	Patch(rs, "/{id}", func(ctx context.Context, req map[string]interface{}) (map[string]interface{}, error) {
+		return req, nil
+	})
+	Delete(rs, "/{id}", func(ctx context.Context, _ struct{}) (struct{}, error) {
+		return struct{}{}, nil
+	})
+
+	router := mux.NewRouter()
+	rs.withLogger(&exampleLogger{}).withRouter(router)
+	if err := rs.build(); err != nil {
+		t.Fatalf("RouteSet.build() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "get",
+			method:     "GET",
+			target:     "/api/example?a=1&b=2",
+			wantStatus: http.StatusOK,
+			wantBody:   `{"Name":"example","Value":2}`,
+		},
+		{
+			name:       "get_http_error",
+			method:     "GET",
+			target:     "/api/404",
+			wantStatus: http.StatusNotFound,
+			wantBody:   `{"Status":404,"ErrorCode":"","Message":"not found"}`,
+		},
+		{
+			name:       "post",
+			method:     "POST",
+			target:     "/api",
+			body:       `{"name": "sample", "value": 1}`,
+			wantStatus: http.StatusCreated,
+			wantBody:   `{"Name":"sample","Value":2}`,
+		},
+		{
+			name:       "post_body_does_not_fit",
+			method:     "POST",
+			target:     "/api",
+			body:       `{"name": 1}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "put",
+			method:     "PUT",
+			target:     "/api/example",
+			body:       `{"value": 3}`,
+			wantStatus: http.StatusOK,
+			wantBody:   `{"Name":"example","Value":3}`,
+		},
+		{
+			name:       "put_plain_error",
+			method:     "PUT",
+			target:     "/api/example",
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   `{"Status":500,"ErrorCode":"","Message":"Internal Server Error"}`,
+		},
+		{
+			name:       "patch",
+			method:     "PATCH",
+			target:     "/api/example",
+			body:       `{"name": "patched"}`,
+			wantStatus: http.StatusOK,
+			wantBody:   `{"name":"patched"}`,
+		},
+		{
+			name:       "delete",
+			method:     "DELETE",
+			target:     "/api/example",
+			wantStatus: http.StatusOK,
+			wantBody:   `{}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body)))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %v, want %v", w.Code, tt.wantStatus)
+			}
+			if tt.wantBody != "" && w.Body.String() != tt.wantBody {
+				t.Errorf("body = %v, want %v", w.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestRouteSet_registerFuncRoute(t *testing.T) {
+	tests := []struct {
+		name    string
+		rs      *RouteSet
+		wantErr bool
+	}{
+		{
+			name:    "nil_handler",
+			rs:      Get[struct{}, struct{}](NewRouteSet("/api", &exampleParser{}), "", nil),
+			wantErr: true,
+		},
+		{
+			name: "non_nil_handler",
+			rs: Get(NewRouteSet("/api", &exampleParser{}), "", func(ctx context.Context, _ struct{}) (struct{}, error) {
+				return struct{}{}, nil
+			}),
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.rs.withLogger(&exampleLogger{}).withRouter(mux.NewRouter())
+			if err := tt.rs.build(); (err != nil) != tt.wantErr {
+				t.Errorf("RouteSet.build() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gorilla/mux"
@@ -24,14 +25,20 @@ type RouteMachine struct {
 	router      *mux.Router
 	middlewares []mux.MiddlewareFunc
 
-	basePath string
-	logger   Loggable
+	basePath      string
+	logger        Loggable
+	defaultParser Parser
+	maxBodySize   int64
+	errorMappings errorRegistry
+	compression   *Compression
+
+	developmentMode bool
 }
 
 // NewRouteMachine is a constructor that creates a route machine based on the settings passed as parameters.
 // If the port or loggable is not set correctly, you will get errors during execution.
 func NewRouteMachine(addr string, port uint16, basePath string, loggable Loggable) *RouteMachine {
-	return &RouteMachine{
+	rm := &RouteMachine{
 		server: &http.Server{
 			Addr: fmt.Sprintf("%s:%d", addr, port),
 		},
@@ -39,6 +46,9 @@ func NewRouteMachine(addr string, port uint16, basePath string, loggable Loggabl
 		router:   mux.NewRouter(),
 		logger:   loggable,
 	}
+	rm.router.NotFoundHandler = http.HandlerFunc(rm.notFound)
+	rm.router.MethodNotAllowedHandler = http.HandlerFunc(rm.methodNotAllowed)
+	return rm
 }
 
 // AddRouteSet provides a method to register a new RouteSet within the route machine
@@ -47,7 +57,13 @@ func (rm *RouteMachine) AddRouteSet(routeSet *RouteSet) error {
 		return ErrNilRouteSetIsNotAllowed
 	}
 
-	routeSet.withLogger(rm.logger).withRouterBasePath(rm.basePath).withRouter(rm.router)
+	routeSet.withLogger(rm.logger).
+		withRouterBasePath(rm.basePath).
+		withRouter(rm.router).
+		withMaxBodySize(rm.maxBodySize).
+		withErrorMappings(&rm.errorMappings).
+		withDevelopmentMode(rm.developmentMode).
+		withCompression(rm.compression)
 
 	if err := routeSet.build(); err != nil {
 		return err
@@ -75,6 +91,75 @@ func (rm *RouteMachine) SetIdleTimeout(timeout time.Duration) *RouteMachine {
 	return rm
 }
 
+// SetDefaultParser provides a method that sets the parser used to answer requests that do not belong to any route set.
+// If no default parser is set, such requests are answered in plain text.
+func (rm *RouteMachine) SetDefaultParser(parser Parser) *RouteMachine {
+	rm.defaultParser = parser
+	return rm
+}
+
+// SetMaxBodySize provides a method that limits the size of request bodies for all route sets that do not define their own limit.
+// Requests exceeding the limit are answered with 413 Request Entity Too Large. A size less than or equal to zero disables the limit.
+func (rm *RouteMachine) SetMaxBodySize(size int64) *RouteMachine {
+	rm.maxBodySize = size
+	for _, routeSet := range rm.routeSets {
+		routeSet.withMaxBodySize(size)
+	}
+	return rm
+}
+
+// routeSetFor returns the route set whose base path is the closest match of the request path or nil, if there is none
+func (rm *RouteMachine) routeSetFor(r *http.Request) *RouteSet {
+	var closest *RouteSet
+	for _, routeSet := range rm.routeSets {
+		basePath := strings.TrimSuffix(routeSet.basePath, "/")
+		if r.URL.Path != basePath && !strings.HasPrefix(r.URL.Path, basePath+"/") {
+			continue
+		}
+		if closest == nil || len(routeSet.basePath) > len(closest.basePath) {
+			closest = routeSet
+		}
+	}
+	return closest
+}
+
+// writeError sends the error back to the client, encoded by the parser negotiated by the closest route set or the default parser
+func (rm *RouteMachine) writeError(w http.ResponseWriter, r *http.Request, httpErr *HttpError) {
+	if routeSet := rm.routeSetFor(r); routeSet != nil && routeSet.parser != nil {
+		routeSet.writeError(w, r, httpErr)
+		return
+	}
+
+	parser := rm.defaultParser
+	if parser == nil {
+		http.Error(w, httpErr.Message, httpErr.Status)
+		return
+	}
+
+	if err := httpErr.write(parser.MimeType(), parser, w); err != nil {
+		rm.logger.Error("failed to write error response: %v", err)
+	}
+}
+
+// notFound answers requests that do not match any route
+func (rm *RouteMachine) notFound(w http.ResponseWriter, r *http.Request) {
+	rm.writeError(w, r, &HttpError{
+		Status:    http.StatusNotFound,
+		ErrorCode: "",
+		Message:   fmt.Sprintf("no route found for path: %s", r.URL.Path),
+	})
+}
+
+// methodNotAllowed answers requests whose path matches a route, but not the http method
+func (rm *RouteMachine) methodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Allow", strings.Join(allowedMethods(rm.router, r), ", "))
+	rm.writeError(w, r, &HttpError{
+		Status:    http.StatusMethodNotAllowed,
+		ErrorCode: "",
+		Message:   fmt.Sprintf("method %s is not allowed for path: %s", r.Method, r.URL.Path),
+	})
+}
+
 // AddMiddleware injects a middleware just before an endpoint is touched.
 func (rm *RouteMachine) AddMiddleware(next Middleware) error {
 	if next == nil {
@@ -86,6 +171,7 @@ func (rm *RouteMachine) AddMiddleware(next Middleware) error {
 		lgg.WithLogger(rm.logger)
 	}
 	rm.middlewares = append(rm.middlewares, next.Middleware)
+	rm.router.Use(next.Middleware)
 	return nil
 }
 
@@ -108,16 +194,13 @@ func (rm *RouteMachine) Start() error {
 		return ErrRouteSetNotPresent
 	}
 
-	// initialize middlewares
-	rm.router.Use(rm.middlewares...)
-
 	// assign the router to each route set
 	for _, routeSet := range rm.routeSets {
 		routeSet.router = rm.router
 	}
 
-	// assign the router
-	rm.server.Handler = rm.router
+	// assign the route machine, which dispatches the requests to the router
+	rm.server.Handler = rm
 
 	afterCh := time.After(500 * time.Millisecond)
 	go func() {
@@ -134,6 +217,15 @@ func (rm *RouteMachine) Start() error {
 	return nil
 }
 
+// ServeHTTP provides a method that dispatches the request to the registered routes.
+// It allows to serve the route machine by a custom http server or to test routes with net/http/httptest without calling Start.
+// Panics that are not recovered by a route set, e.g. panics of middlewares, are answered with an internal server error.
+func (rm *RouteMachine) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	tw := &trackingResponseWriter{ResponseWriter: w}
+	defer rm.recoverPanic(tw, r)
+	rm.router.ServeHTTP(tw, r)
+}
+
 // Stop delegates the stop signal to http.server.Shutdown
 func (rm *RouteMachine) Stop() error {
 	return rm.server.Shutdown(context.Background())
@@ -1,7 +1,10 @@
 package procroute
 
 import (
+	"context"
+	"encoding/json"
 	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"testing"
 	"time"
@@ -355,6 +358,31 @@ func TestRouteMachine_AddMiddleware(t *testing.T) {
 	}
 }
 
+func TestRouteMachine_ServeHTTP(t *testing.T) {
+	rtm := NewRouteMachine("", 0, "/api", &exampleLogger{})
+	if err := rtm.AddRouteSet(NewRouteSet("/sample", &exampleParser{}).AddRoutes(&contextExample{})); err != nil {
+		t.Fatalf("RouteMachine.AddRouteSet() error = %v", err)
+	}
+
+	// values set by middlewares reach the context of context aware routes, even if the server is not started
+	if err := rtm.AddMiddleware(&funcMiddleware{fn: func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), testContextKey("value"), "from middleware")))
+		})
+	}}); err != nil {
+		t.Fatalf("RouteMachine.AddMiddleware() error = %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	rtm.ServeHTTP(w, httptest.NewRequest("GET", "/api/sample/1", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("status = %v, want %v", w.Code, http.StatusOK)
+	}
+	if got, want := w.Body.String(), `"from middleware"`; got != want {
+		t.Errorf("body = %v, want %v", got, want)
+	}
+}
+
 func TestRouteMachine_Start(t *testing.T) {
 	type fields struct {
 		addr     string
@@ -476,3 +504,123 @@ func TestRouteMachine_Stop(t *testing.T) {
 		})
 	}
 }
+
+func TestRouteMachine_notFoundAndMethodNotAllowed(t *testing.T) {
+	tests := []struct {
+		name            string
+		defaultParser   Parser
+		method          string
+		target          string
+		wantStatus      int
+		wantContentType string
+		wantAllow       string
+	}{
+		{
+			name:            "not_found_within_route_set",
+			method:          "GET",
+			target:          "/api/sample/1/unknown",
+			wantStatus:      http.StatusNotFound,
+			wantContentType: "application/json",
+		},
+		{
+			name:            "not_found_with_default_parser",
+			defaultParser:   &exampleParser{},
+			method:          "GET",
+			target:          "/unknown",
+			wantStatus:      http.StatusNotFound,
+			wantContentType: "application/json",
+		},
+		{
+			name:            "not_found_without_default_parser",
+			method:          "GET",
+			target:          "/unknown",
+			wantStatus:      http.StatusNotFound,
+			wantContentType: "text/plain; charset=utf-8",
+		},
+		{
+			name:            "method_not_allowed",
+			method:          "DELETE",
+			target:          "/api/sample",
+			wantStatus:      http.StatusMethodNotAllowed,
+			wantContentType: "application/json",
+			wantAllow:       "GET, HEAD, OPTIONS",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rm := NewRouteMachine("127.0.0.1", 7654, "/api", &exampleLogger{}).SetDefaultParser(tt.defaultParser)
+			if err := rm.AddRouteSet(NewRouteSet("/sample", &exampleParser{}).AddRoutes(&getAllExample{})); err != nil {
+				t.Fatalf("RouteMachine.AddRouteSet() error = %v", err)
+			}
+
+			w := httptest.NewRecorder()
+			rm.router.ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, nil))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %v, want %v", w.Code, tt.wantStatus)
+			}
+			if got := w.Header().Get("Content-Type"); got != tt.wantContentType {
+				t.Errorf("Content-Type = %v, want %v", got, tt.wantContentType)
+			}
+			if got := w.Header().Get("Allow"); got != tt.wantAllow {
+				t.Errorf("Allow = %v, want %v", got, tt.wantAllow)
+			}
+			if tt.wantContentType != "application/json" {
+				return
+			}
+
+			httpErr := &HttpError{}
+			if err := json.Unmarshal(w.Body.Bytes(), httpErr); err != nil {
+				t.Fatalf("invalid error response %s: %v", w.Body.String(), err)
+			}
+			if httpErr.Status != tt.wantStatus {
+				t.Errorf("HttpError.Status = %v, want %v", httpErr.Status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestRouteMachine_routeSetFor(t *testing.T) {
+	rm := NewRouteMachine("127.0.0.1", 7654, "/api", &exampleLogger{})
+	short := NewRouteSet("/users", &exampleParser{})
+	long := NewRouteSet("/users/admins", &exampleParser{})
+	for _, rs := range []*RouteSet{short, long} {
+		if err := rm.AddRouteSet(rs); err != nil {
+			t.Fatalf("RouteMachine.AddRouteSet() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		target string
+		want   *RouteSet
+	}{
+		{
+			name:   "exact_base_path",
+			target: "/api/users",
+			want:   short,
+		},
+		{
+			name:   "closest_base_path",
+			target: "/api/users/admins/1",
+			want:   long,
+		},
+		{
+			name:   "prefix_is_not_a_path_segment",
+			target: "/api/usersx",
+			want:   nil,
+		},
+		{
+			name:   "no_route_set",
+			target: "/other",
+			want:   nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rm.routeSetFor(httptest.NewRequest("GET", tt.target, nil)); got != tt.want {
+				t.Errorf("RouteMachine.routeSetFor() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,30 +1,76 @@
 package procroute
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"path"
+	"strings"
 
 	"github.com/gorilla/mux"
 )
 
 var (
-	ErrGetRouteIsNil    = errors.New("get route is nil")
-	ErrGetAllRouteIsNil = errors.New("get all route is nil")
-	ErrPostRouteIsNil   = errors.New("post route is nil")
-	ErrUpdateRouteIsNil = errors.New("update route is nil")
-	ErrDeleteRouteIsNil = errors.New("delete route is nil")
+	ErrGetRouteIsNil     = errors.New("get route is nil")
+	ErrGetAllRouteIsNil  = errors.New("get all route is nil")
+	ErrPostRouteIsNil    = errors.New("post route is nil")
+	ErrUpdateRouteIsNil  = errors.New("update route is nil")
+	ErrDeleteRouteIsNil  = errors.New("delete route is nil")
+	ErrPatchRouteIsNil   = errors.New("patch route is nil")
+	ErrRawRouteIsNil     = errors.New("raw route is nil")
+	ErrRouteFactoryIsNil = errors.New("route factory is nil")
 )
 
+// RouteFactory defines a constructor that returns a new route controller.
+// Routes registered by a factory get an isolated controller instance for each request,
+// so that state injected by UrlParams or QueryParams is not shared between concurrent requests.
+//
+// Example:
+//  rs.AddRouteFactories(func() interface{} {
+//  	return &MyType{}
+//  })
+type RouteFactory func() interface{}
+
 // RouteSet defines a structure that is used to create an endpoint set based on the base path
 type RouteSet struct {
 	parser   Parser
 	router   *mux.Router
 	basePath string
 
-	routeSet []interface{}
-	logger   Loggable
+	// parsers contains the additional parsers used for content negotiation
+	parsers []Parser
+	// maxBodySize is the maximum request body size of the route set, defaultMaxBodySize the one inherited from the route machine
+	maxBodySize        int64
+	defaultMaxBodySize int64
+	// errorMappings translates errors returned by the routes, defaultErrorMappings are the mappings of the route machine
+	errorMappings        errorRegistry
+	defaultErrorMappings *errorRegistry
+	// developmentMode includes panic values and stack traces in the response
+	developmentMode bool
+	// defaultPageSize and maxPageSize limit the number of items of paginated get all routes
+	defaultPageSize int
+	maxPageSize     int
+	// weakETags derives entity tags from the encoded body, preconditionRequired answers unconditional modifications with 428 Precondition Required
+	weakETags            bool
+	preconditionRequired bool
+	// cache stores the responses of get and get all routes
+	cache *responseCache
+	// compression compresses responses and decompresses request bodies, defaultCompression is the compression of the route machine
+	compression        *Compression
+	defaultCompression *Compression
+
+	routeSet       []interface{}
+	routeFactories []RouteFactory
+	logger         Loggable
+
+	// paths contains the registered paths in the order of registration, which are answered for OPTIONS requests
+	paths []string
+	// customOptionsPaths contains the paths of raw routes that handle OPTIONS requests themselves
+	customOptionsPaths map[string]bool
+	// acceptPatch contains the Accept-Patch header value of each patch route
+	acceptPatch map[*mux.Route]string
 }
 
 // NewRouteSet defines a new route set that is used to genereate http endpoints
@@ -100,6 +146,14 @@ func (rs *RouteSet) AddRoutes(routes ...interface{}) *RouteSet {
 	return rs
 }
 
+// AddRouteFactories provides a method that adds route factories to the route set.
+// In contrast to AddRoutes, the factory is called for every incoming request and the returned controller only serves that request.
+// The same rules regarding overlapping routes apply as for AddRoutes.
+func (rs *RouteSet) AddRouteFactories(factories ...RouteFactory) *RouteSet {
+	rs.routeFactories = append(rs.routeFactories, factories...)
+	return rs
+}
+
 // buildPath is used to combine the basePath plus the uriPath
 //
 // Example:
@@ -119,53 +173,111 @@ func (rs *RouteSet) build() error {
 			wl.WithLogger(rs.logger)
 		}
 
-		// check if the routeset implements the GetRoute interface and if so, register such route
-		if rts, ok := routeSet.(GetRoute); ok {
-			if err := rs.registerGetRoute(rts); err != nil {
-				return err
-			}
+		if err := rs.registerRoutes(routeSet, nil); err != nil {
+			return err
 		}
+	}
+
+	for _, factory := range rs.routeFactories {
+		if factory == nil {
+			return ErrRouteFactoryIsNil
+		}
+
+		// the prototype is only used to determine the implemented route interfaces and paths
+		if err := rs.registerRoutes(rs.newRoute(factory), factory); err != nil {
+			return err
+		}
+	}
+
+	rs.registerOptionsRoutes()
+	return nil
+}
+
+// registerRoutes registers all routes implemented by the route controller.
+// If factory is not nil, each request is served by a new controller created by the factory.
+func (rs *RouteSet) registerRoutes(routeSet interface{}, factory RouteFactory) error {
+	// routes registered by the functional route api are not controllers and therefore registered on their own
+	if rts, ok := routeSet.(*funcRoute); ok {
+		return rs.registerFuncRoute(rts)
+	}
+
+	// check if the routeset implements one of the get route interfaces and if so, register such route
+	switch routeSet.(type) {
+	case GetRouteWithContext, GetRoute:
+		if err := rs.registerGetRoute(routeSet, factory); err != nil {
+			return err
+		}
+	}
+
+	// check if the routeset implements one of the get all route interfaces and if so, register such route
+	switch routeSet.(type) {
+	case GetPageRoute, GetAllRouteStream, GetAllRouteWithResponse, GetAllRouteWithContext, GetAllRoute:
+		if err := rs.registerGetAllRoute(routeSet, factory); err != nil {
+			return err
+		}
+	}
 
-		// check if the routeset implements the GetAllRoute interface and if so, register such route
-		if rts, ok := routeSet.(GetAllRoute); ok {
-			if err := rs.registerGetAllRoute(rts); err != nil {
-				return err
-			}
+	// check if the routeset implements one of the post route interfaces and if so, register such route
+	switch routeSet.(type) {
+	case PostRouteWithResponse, PostRouteWithContext, PostRoute:
+		if err := rs.registerPostRoute(routeSet, factory); err != nil {
+			return err
 		}
+	}
 
-		// check if the routeset implements the PostRoute interface and if so, register such route
-		if rts, ok := routeSet.(PostRoute); ok {
-			if err := rs.registerPostRoute(rts); err != nil {
-				return err
-			}
+	// check if the routeset implements one of the update route interfaces and if so, register such route
+	switch routeSet.(type) {
+	case UpdateRouteWithResponse, UpdateRouteWithContext, UpdateRoute:
+		if err := rs.registerUpdateRoute(routeSet, factory); err != nil {
+			return err
 		}
+	}
 
-		// check if the routeset implements the UpdateRoute interface and if so, register such route
-		if rts, ok := routeSet.(UpdateRoute); ok {
-			if err := rs.registerUpdateRoute(rts); err != nil {
-				return err
-			}
+	// check if the routeset implements one of the delete route interfaces and if so, register such route
+	switch routeSet.(type) {
+	case DeleteRouteWithResponse, DeleteRouteWithContext, DeleteRoute:
+		if err := rs.registerDeleteRoute(routeSet, factory); err != nil {
+			return err
 		}
+	}
 
-		// check if the routeset implements the DeleteRoute interface and if so, register such route
-		if rts, ok := routeSet.(DeleteRoute); ok {
-			if err := rs.registerDeleteRoute(rts); err != nil {
-				return err
-			}
+	// check if the routeset implements one of the patch route interfaces and if so, register such route
+	switch routeSet.(type) {
+	case PatchRouteWithResponse, PatchRouteWithContext, PatchRoute:
+		if err := rs.registerPatchRoute(routeSet, factory); err != nil {
+			return err
 		}
+	}
 
-		// check if the routeset implements the RawRoute interface and if so, register such route
-		if rts, ok := routeSet.(RawRoute); ok {
-			if err := rs.registerRawRoute(rts); err != nil {
-				return err
-			}
+	// check if the routeset implements the RawRoute interface and if so, register such route
+	if rts, ok := routeSet.(RawRoute); ok {
+		if err := rs.registerRawRoute(rts, factory); err != nil {
+			return err
 		}
 	}
 	return nil
 }
 
+// newRoute creates a new route controller by calling the factory and injects the logger, if the WithLogger interface is implemented
+func (rs *RouteSet) newRoute(factory RouteFactory) interface{} {
+	route := factory()
+	if wl, ok := route.(WithLogger); ok {
+		wl.WithLogger(rs.logger)
+	}
+	return route
+}
+
+// resolveRoute returns the route controller that serves the current request.
+// Routes registered without a factory share the same controller across all requests.
+func (rs *RouteSet) resolveRoute(route interface{}, factory RouteFactory) interface{} {
+	if factory == nil {
+		return route
+	}
+	return rs.newRoute(factory)
+}
+
 // registerPostRoute creates a new post route
-func (rs *RouteSet) registerPostRoute(rt PostRoute) error {
+func (rs *RouteSet) registerPostRoute(rt interface{}, factory RouteFactory) error {
 	if rt == nil {
 		return ErrPostRouteIsNil
 	}
@@ -176,33 +288,43 @@ func (rs *RouteSet) registerPostRoute(rt PostRoute) error {
 	}
 	rs.logger.Info("registered post route at: %s", path)
 
-	rs.router.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
-		rs.definePostRoute(w, r, rt)
-	}).Methods("POST", "OPTIONS")
+	rs.handle(path, rs.withInvalidation(func(w http.ResponseWriter, r *http.Request) {
+		rs.definePostRoute(w, r, rs.resolveRoute(rt, factory))
+	}), "POST")
 
 	return nil
 }
 
 // definePostRoute defines the structure used for post routes
-func (rs *RouteSet) definePostRoute(w http.ResponseWriter, r *http.Request, rt PostRoute) {
+func (rs *RouteSet) definePostRoute(w http.ResponseWriter, r *http.Request, rt interface{}) {
 	request, err := rs.doHttpOp(rt, r)
 	if err != nil {
-		err.write(rs.parser.MimeType(), rs.parser, w)
+		rs.writeError(w, r, err)
 		return
 	}
 
-	httpErr := rt.Post(request)
+	var resp *Response
+	var httpErr *HttpError
+	switch route := rt.(type) {
+	case PostRouteWithResponse:
+		var err error
+		resp, err = route.PostWithResponse(requestContext(r), request)
+		httpErr = rs.toHttpError(err)
+	case PostRouteWithContext:
+		httpErr = rs.toHttpError(route.PostWithContext(requestContext(r), request))
+	case PostRoute:
+		httpErr = route.Post(request)
+	}
 	if httpErr != nil {
-		httpErr.write(rs.parser.MimeType(), rs.parser, w)
+		rs.writeError(w, r, httpErr)
 		return
 	}
 
-	w.Header().Add("Content-Type", rs.parser.MimeType())
-	w.WriteHeader(http.StatusCreated)
+	rs.writeResponse(w, r, http.StatusCreated, resp)
 }
 
 // registerGetRoute creates a new get route
-func (rs *RouteSet) registerGetRoute(rt GetRoute) error {
+func (rs *RouteSet) registerGetRoute(rt interface{}, factory RouteFactory) error {
 	if rt == nil {
 		return ErrGetRouteIsNil
 	}
@@ -213,40 +335,47 @@ func (rs *RouteSet) registerGetRoute(rt GetRoute) error {
 	}
 	rs.logger.Info("registered get route at: %s", path)
 
-	rs.router.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
-		rs.defineGetRoute(w, r, rt)
-	}).Methods("GET")
+	rs.handle(path, withHead(rs.withCache(rt, func(w http.ResponseWriter, r *http.Request) {
+		rs.defineGetRoute(w, r, rs.resolveRoute(rt, factory))
+	})), "GET", "HEAD")
 
 	return nil
 }
 
 // defineGetRoute defines the structure used for get routes
-func (rs *RouteSet) defineGetRoute(w http.ResponseWriter, r *http.Request, rt GetRoute) {
+func (rs *RouteSet) defineGetRoute(w http.ResponseWriter, r *http.Request, rt interface{}) {
 	request, err := rs.doHttpOp(rt, r)
 	if err != nil {
-		err.write(rs.parser.MimeType(), rs.parser, w)
+		rs.writeError(w, r, err)
 		return
 	}
 
-	data, httpErr := rt.Get(request)
+	var data interface{}
+	var httpErr *HttpError
+	switch route := rt.(type) {
+	case GetRouteWithContext:
+		var err error
+		data, err = route.GetWithContext(requestContext(r), request)
+		httpErr = rs.toHttpError(err)
+	case GetRoute:
+		data, httpErr = route.Get(request)
+	}
 	if httpErr != nil {
-		httpErr.write(rs.parser.MimeType(), rs.parser, w)
+		rs.writeError(w, r, httpErr)
 		return
 	}
 
-	bts, err := rs.marshal(data)
-	if err != nil {
-		err.write(rs.parser.MimeType(), rs.parser, w)
+	selected, httpErr := rs.selectFields(r, data)
+	if httpErr != nil {
+		rs.writeError(w, r, httpErr)
 		return
 	}
 
-	w.Header().Add("Content-Type", rs.parser.MimeType())
-	w.WriteHeader(http.StatusOK)
-	w.Write(bts)
+	rs.writeValidated(w, r, rt, data, selected)
 }
 
 // registerGetAllRoute creates a new get all route
-func (rs *RouteSet) registerGetAllRoute(rt GetAllRoute) error {
+func (rs *RouteSet) registerGetAllRoute(rt interface{}, factory RouteFactory) error {
 	if rt == nil {
 		return ErrGetAllRouteIsNil
 	}
@@ -257,40 +386,82 @@ func (rs *RouteSet) registerGetAllRoute(rt GetAllRoute) error {
 	}
 	rs.logger.Info("registered get all route at: %s", path)
 
-	rs.router.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
-		rs.defineGetAllRoute(w, r, rt)
-	}).Methods("GET")
+	rs.handle(path, withHead(rs.withCache(rt, func(w http.ResponseWriter, r *http.Request) {
+		rs.defineGetAllRoute(w, r, rs.resolveRoute(rt, factory))
+	})), "GET", "HEAD")
 
 	return nil
 }
 
 // defineGetAllRoute defines the structure used for get all routes
-func (rs *RouteSet) defineGetAllRoute(w http.ResponseWriter, r *http.Request, rt GetAllRoute) {
+func (rs *RouteSet) defineGetAllRoute(w http.ResponseWriter, r *http.Request, rt interface{}) {
+	if _, isPage := rt.(GetPageRoute); !isPage {
+		if route, ok := rt.(GetAllRouteStream); ok {
+			rs.defineGetAllStreamRoute(w, r, route)
+			return
+		}
+	}
+
 	request, err := rs.doHttpOp(rt, r)
 	if err != nil {
-		err.write(rs.parser.MimeType(), rs.parser, w)
+		rs.writeError(w, r, err)
 		return
 	}
 
-	data, httpErr := rt.GetAll(request)
+	var data interface{}
+	var httpErr *HttpError
+	switch route := rt.(type) {
+	case GetPageRoute:
+		page, err := rs.parsePage(r)
+		if err != nil {
+			rs.writeError(w, r, err)
+			return
+		}
+
+		result, routeErr := route.GetPage(requestContext(r), page, request)
+		if httpErr = rs.toHttpError(routeErr); httpErr != nil {
+			break
+		}
+		if result == nil {
+			result = &PageResult{}
+		}
+		items := result.Items
+		if items == nil {
+			items = []interface{}{}
+		}
+		data = &Response{Headers: pageHeaders(r, page, result), Body: items}
+	case GetAllRouteWithResponse:
+		resp, err := route.GetAllWithResponse(requestContext(r), request)
+		if resp == nil {
+			resp = &Response{}
+		}
+		data, httpErr = resp, rs.toHttpError(err)
+	case GetAllRouteWithContext:
+		var err error
+		var items []interface{}
+		items, err = route.GetAllWithContext(requestContext(r), request)
+		data, httpErr = items, rs.toHttpError(err)
+	case GetAllRoute:
+		var items []interface{}
+		items, httpErr = route.GetAll(request)
+		data = items
+	}
 	if httpErr != nil {
-		httpErr.write(rs.parser.MimeType(), rs.parser, w)
+		rs.writeError(w, r, httpErr)
 		return
 	}
 
-	bts, err := rs.marshal(data)
-	if err != nil {
-		err.write(rs.parser.MimeType(), rs.parser, w)
+	selected, httpErr := rs.selectFields(r, data)
+	if httpErr != nil {
+		rs.writeError(w, r, httpErr)
 		return
 	}
 
-	w.Header().Add("Content-Type", rs.parser.MimeType())
-	w.WriteHeader(http.StatusOK)
-	w.Write(bts)
+	rs.writeValidated(w, r, rt, data, selected)
 }
 
 // registerUpdateRoute creates a new update route
-func (rs *RouteSet) registerUpdateRoute(rt UpdateRoute) error {
+func (rs *RouteSet) registerUpdateRoute(rt interface{}, factory RouteFactory) error {
 	if rt == nil {
 		return ErrUpdateRouteIsNil
 	}
@@ -301,33 +472,48 @@ func (rs *RouteSet) registerUpdateRoute(rt UpdateRoute) error {
 	}
 	rs.logger.Info("registered update route at: %s", path)
 
-	rs.router.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
-		rs.defineUpdateRoute(w, r, rt)
-	}).Methods("PUT", "OPTIONS")
+	rs.handle(path, rs.withInvalidation(func(w http.ResponseWriter, r *http.Request) {
+		rs.defineUpdateRoute(w, r, rs.resolveRoute(rt, factory))
+	}), "PUT")
 
 	return nil
 }
 
 // defineUpdateRoute defines the structure used for update routes
-func (rs *RouteSet) defineUpdateRoute(w http.ResponseWriter, r *http.Request, rt UpdateRoute) {
+func (rs *RouteSet) defineUpdateRoute(w http.ResponseWriter, r *http.Request, rt interface{}) {
 	request, err := rs.doHttpOp(rt, r)
 	if err != nil {
-		err.write(rs.parser.MimeType(), rs.parser, w)
+		rs.writeError(w, r, err)
+		return
+	}
+
+	if httpErr := rs.checkPreconditions(r, rt); httpErr != nil {
+		rs.writeError(w, r, httpErr)
 		return
 	}
 
-	httpErr := rt.Update(request)
+	var resp *Response
+	var httpErr *HttpError
+	switch route := rt.(type) {
+	case UpdateRouteWithResponse:
+		var err error
+		resp, err = route.UpdateWithResponse(requestContext(r), request)
+		httpErr = rs.toHttpError(err)
+	case UpdateRouteWithContext:
+		httpErr = rs.toHttpError(route.UpdateWithContext(requestContext(r), request))
+	case UpdateRoute:
+		httpErr = route.Update(request)
+	}
 	if httpErr != nil {
-		httpErr.write(rs.parser.MimeType(), rs.parser, w)
+		rs.writeError(w, r, httpErr)
 		return
 	}
 
-	w.Header().Add("Content-Type", rs.parser.MimeType())
-	w.WriteHeader(http.StatusOK)
+	rs.writeResponse(w, r, http.StatusOK, resp)
 }
 
 // registerDeleteRoute creates a new delete route
-func (rs *RouteSet) registerDeleteRoute(rt DeleteRoute) error {
+func (rs *RouteSet) registerDeleteRoute(rt interface{}, factory RouteFactory) error {
 	if rt == nil {
 		return ErrDeleteRouteIsNil
 	}
@@ -338,55 +524,156 @@ func (rs *RouteSet) registerDeleteRoute(rt DeleteRoute) error {
 	}
 	rs.logger.Info("registered delete route at: %s", path)
 
-	rs.router.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
-		rs.defineDeleteRoute(w, r, rt)
-	}).Methods("DELETE", "OPTIONS")
+	rs.handle(path, rs.withInvalidation(func(w http.ResponseWriter, r *http.Request) {
+		rs.defineDeleteRoute(w, r, rs.resolveRoute(rt, factory))
+	}), "DELETE")
 
 	return nil
 }
 
 // defineUpdateRoute defines the structure used for update routes
-func (rs *RouteSet) defineDeleteRoute(w http.ResponseWriter, r *http.Request, rt DeleteRoute) {
+func (rs *RouteSet) defineDeleteRoute(w http.ResponseWriter, r *http.Request, rt interface{}) {
 	request, err := rs.doHttpOp(rt, r)
 	if err != nil {
-		err.write(rs.parser.MimeType(), rs.parser, w)
+		rs.writeError(w, r, err)
+		return
+	}
+
+	if httpErr := rs.checkPreconditions(r, rt); httpErr != nil {
+		rs.writeError(w, r, httpErr)
 		return
 	}
 
-	httpErr := rt.Delete(request)
+	var resp *Response
+	var httpErr *HttpError
+	switch route := rt.(type) {
+	case DeleteRouteWithResponse:
+		var err error
+		resp, err = route.DeleteWithResponse(requestContext(r), request)
+		httpErr = rs.toHttpError(err)
+	case DeleteRouteWithContext:
+		httpErr = rs.toHttpError(route.DeleteWithContext(requestContext(r), request))
+	case DeleteRoute:
+		httpErr = route.Delete(request)
+	}
 	if httpErr != nil {
-		httpErr.write(rs.parser.MimeType(), rs.parser, w)
+		rs.writeError(w, r, httpErr)
 		return
 	}
 
-	w.Header().Add("Content-Type", rs.parser.MimeType())
-	w.WriteHeader(http.StatusOK)
+	rs.writeResponse(w, r, http.StatusOK, resp)
 }
 
-// registerRawRoute creates a new raw route
-func (rs *RouteSet) registerRawRoute(rt RawRoute) error {
+// registerPatchRoute creates a new patch route
+func (rs *RouteSet) registerPatchRoute(rt interface{}, factory RouteFactory) error {
 	if rt == nil {
-		return ErrDeleteRouteIsNil
+		return ErrPatchRouteIsNil
 	}
 
 	path := rs.buildPath()
-	if subPath, ok := rt.(RawRouteRoutePath); ok {
-		path = rs.buildPath(subPath.RawRoutePath())
+	if subPath, ok := rt.(PatchRouteRoutePath); ok {
+		path = rs.buildPath(subPath.PatchRoutePath())
 	}
-	rs.logger.Info("registered raw route at: %s", path)
+	rs.logger.Info("registered patch route at: %s", path)
 
-	rs.router.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
-		rt.Raw(w, r)
-	}).Methods(rt.HttpMethods()...)
+	route := rs.handle(path, rs.withInvalidation(func(w http.ResponseWriter, r *http.Request) {
+		rs.definePatchRoute(w, r, rs.resolveRoute(rt, factory))
+	}), "PATCH")
+
+	if rs.acceptPatch == nil {
+		rs.acceptPatch = map[*mux.Route]string{}
+	}
+	rs.acceptPatch[route] = strings.Join(rs.acceptPatchTypes(rt), ", ")
 
 	return nil
 }
 
-// doHttpOp handles actions that must be called for each request
-func (rs *RouteSet) doHttpOp(routeController interface{}, r *http.Request) (interface{}, *HttpError) {
+// definePatchRoute defines the structure used for patch routes
+func (rs *RouteSet) definePatchRoute(w http.ResponseWriter, r *http.Request, rt interface{}) {
+	acceptPatch := rs.acceptPatchTypes(rt)
+	w.Header().Set("Accept-Patch", strings.Join(acceptPatch, ", "))
+
+	var request interface{}
+	var err *HttpError
+	switch contentType := mediaType(r.Header.Get("Content-Type")); {
+	case contentType == MergePatchMimeType || contentType == JsonPatchMimeType:
+		if !containsString(acceptPatch, contentType) {
+			err = unsupportedPatchFormat(contentType)
+			break
+		}
+		request, err = rs.doPatchOp(rt, r, contentType)
+	case contentType == "" || containsString(rs.mediaTypes(), contentType):
+		if request, err = rs.doHttpOp(rt, r); err == nil {
+			err = rs.checkPreconditions(r, rt)
+		}
+	default:
+		err = unsupportedPatchFormat(contentType)
+	}
+	if err != nil {
+		rs.writeError(w, r, err)
+		return
+	}
+
+	var resp *Response
+	var httpErr *HttpError
+	switch route := rt.(type) {
+	case PatchRouteWithResponse:
+		var err error
+		resp, err = route.PatchWithResponse(requestContext(r), request)
+		httpErr = rs.toHttpError(err)
+	case PatchRouteWithContext:
+		httpErr = rs.toHttpError(route.PatchWithContext(requestContext(r), request))
+	case PatchRoute:
+		httpErr = route.Patch(request)
+	}
+	if httpErr != nil {
+		rs.writeError(w, r, httpErr)
+		return
+	}
+
+	rs.writeResponse(w, r, http.StatusOK, resp)
+}
+
+// acceptPatchTypes returns the mime types accepted by the patch route.
+// Patch documents are only supported, if the current resource can be received by a get route.
+func (rs *RouteSet) acceptPatchTypes(rt interface{}) []string {
+	mimeTypes := rs.mimeTypes()
+	switch rt.(type) {
+	case GetRouteWithContext, GetRoute:
+		mimeTypes = append(mimeTypes, MergePatchMimeType, JsonPatchMimeType)
+	}
+	return mimeTypes
+}
+
+// doPatchOp receives the current resource by the get route of the controller, applies the patch document sent with the request
+// and decodes the patched resource into the type returned by the Typer interface
+func (rs *RouteSet) doPatchOp(rt interface{}, r *http.Request, contentType string) (interface{}, *HttpError) {
 	defer r.Body.Close()
 
-	bts, err := io.ReadAll(r.Body)
+	// the response format is negotiated before the patch is applied, so that the route is not called for unacceptable requests
+	if _, err := rs.encoderFor(r); err != nil {
+		return nil, err
+	}
+
+	patch, httpErr := rs.readBody(rt, r)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	// the get route might depend on the url params, so they must be set before receiving the current resource
+	rs.setRequestValues(rt, r)
+
+	current, httpErr := rs.currentResource(rt, r)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	// the patch must be applied to the version of the resource the client expects
+	if httpErr := rs.checkPreconditions(r, current, rt); httpErr != nil {
+		return nil, httpErr
+	}
+
+	document, err := json.Marshal(current)
 	if err != nil {
 		return nil, &HttpError{
 			Status:    http.StatusInternalServerError,
@@ -395,17 +682,165 @@ func (rs *RouteSet) doHttpOp(routeController interface{}, r *http.Request) (inte
 		}
 	}
 
+	patched, httpErr := applyPatch(contentType, document, patch)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
 	var data interface{}
+	ptr, value := interface{}(&data), func() interface{} { return data }
+	if typ := requestType(rt, r.Method); typ != nil {
+		ptr, value = newTypedValue(typ)
+	}
+	if err := json.Unmarshal(patched, ptr); err != nil {
+		// the patched resource does not fit the expected type
+		return nil, &HttpError{
+			Status:    http.StatusUnprocessableEntity,
+			ErrorCode: "",
+			Message:   err.Error(),
+		}
+	}
 
-	// request might be empty which is expected, so skip parsing and return nil instead
-	if len(bts) > 0 {
-		cdata, httpError := rs.unmarshal(bts)
-		if httpError != nil {
-			return nil, httpError
+	if httpErr := rs.validate(rt, value()); httpErr != nil {
+		return nil, httpErr
+	}
+	return value(), nil
+}
+
+// currentResource receives the resource that is going to be patched by calling the get route of the controller
+func (rs *RouteSet) currentResource(rt interface{}, r *http.Request) (interface{}, *HttpError) {
+	var data interface{}
+	var httpErr *HttpError
+	switch route := rt.(type) {
+	case GetRouteWithContext:
+		var err error
+		data, err = route.GetWithContext(requestContext(r), nil)
+		httpErr = rs.toHttpError(err)
+	case GetRoute:
+		data, httpErr = route.Get(nil)
+	}
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	if resp, ok := asResponse(data); ok {
+		return resp.Body, nil
+	}
+	return data, nil
+}
+
+// unsupportedPatchFormat returns the error sent to the client, if the patch format is not supported by the patch route
+func unsupportedPatchFormat(contentType string) *HttpError {
+	return &HttpError{
+		Status:    http.StatusUnsupportedMediaType,
+		ErrorCode: "",
+		Message:   fmt.Sprintf("unsupported patch format: %s", contentType),
+	}
+}
+
+// containsString checks whether the slice contains the value
+func containsString(slice []string, value string) bool {
+	for _, v := range slice {
+		if v == value {
+			return true
 		}
-		data = cdata
+	}
+	return false
+}
+
+// registerRawRoute creates a new raw route
+func (rs *RouteSet) registerRawRoute(rt RawRoute, factory RouteFactory) error {
+	if rt == nil {
+		return ErrRawRouteIsNil
 	}
 
+	path := rs.buildPath()
+	if subPath, ok := rt.(RawRouteRoutePath); ok {
+		path = rs.buildPath(subPath.RawRoutePath())
+	}
+	rs.logger.Info("registered raw route at: %s", path)
+
+	methods := rt.HttpMethods()
+	rs.handle(path, func(w http.ResponseWriter, r *http.Request) {
+		raw, ok := rs.resolveRoute(rt, factory).(RawRoute)
+		if !ok {
+			rs.writeError(w, r, InternalServerError("the route factory did not return a raw route"))
+			return
+		}
+		raw.Raw(w, r)
+	}, methods...)
+
+	if containsString(methods, http.MethodOptions) {
+		if rs.customOptionsPaths == nil {
+			rs.customOptionsPaths = map[string]bool{}
+		}
+		rs.customOptionsPaths[path] = true
+	}
+
+	return nil
+}
+
+// doHttpOp handles actions that must be called for each request.
+// The decoded request data is validated before it is returned.
+func (rs *RouteSet) doHttpOp(routeController interface{}, r *http.Request) (interface{}, *HttpError) {
+	// the response format is negotiated before the request is processed, so that the route is not called for unacceptable requests
+	if _, err := rs.encoderFor(r); err != nil {
+		r.Body.Close()
+		return nil, err
+	}
+	return rs.readRequest(routeController, r)
+}
+
+// readRequest reads, decodes and validates the request data and passes the request values to the route controller
+func (rs *RouteSet) readRequest(routeController interface{}, r *http.Request) (interface{}, *HttpError) {
+	defer r.Body.Close()
+
+	data, err := rs.readData(routeController, r)
+	if err != nil {
+		return nil, err
+	}
+
+	rs.setRequestValues(routeController, r)
+
+	if httpErr := rs.setQuery(routeController, r); httpErr != nil {
+		return nil, httpErr
+	}
+
+	if httpErr := rs.validate(routeController, data); httpErr != nil {
+		return nil, httpErr
+	}
+
+	return data, nil
+}
+
+// readData decodes the request body by the parser matching its Content-Type.
+// Stream parsers decode the body while it is read, other parsers decode it after it has been read completely.
+func (rs *RouteSet) readData(routeController interface{}, r *http.Request) (interface{}, *HttpError) {
+	if parser, err := rs.decoderFor(r); err == nil && bodyExpected(r.Method) {
+		if streamParser, ok := parser.(StreamParser); ok {
+			return rs.decodeBody(streamParser, routeController, r)
+		}
+	}
+
+	bts, err := rs.readBody(routeController, r)
+	if err != nil {
+		return nil, err
+	}
+
+	// request might be empty which is expected, so skip parsing and return nil instead
+	if len(bts) == 0 {
+		return nil, nil
+	}
+
+	parser, err := rs.decoderFor(r)
+	if err != nil {
+		return nil, err
+	}
+	return rs.unmarshal(parser, bts, requestType(routeController, r.Method))
+}
+
+// setRequestValues passes the url params, query params and request metadata to the route controller, if it implements the corresponding interfaces
+func (rs *RouteSet) setRequestValues(routeController interface{}, r *http.Request) {
 	if m, ok := routeController.(UrlParams); ok {
 		m.SetUrlParams(mux.Vars(r))
 	}
@@ -414,33 +849,44 @@ func (rs *RouteSet) doHttpOp(routeController interface{}, r *http.Request) (inte
 		m.SetQueryParams(r.URL.Query())
 	}
 
-	return data, nil
+	if m, ok := routeController.(RequestMetadata); ok {
+		m.SetRequestMeta(newRequestMeta(r))
+	}
 }
 
-// unmarshal unmarshals the byte slice into the provided Typer interface and writes an error back to the client, if the marshalling failed
-func (rs *RouteSet) unmarshal(bts []byte) (interface{}, *HttpError) {
-	var data interface{}
+// unmarshal unmarshals the byte slice by the passed parser into a new value of the type returned by the Typer interface and writes an error back to the client, if the marshalling failed.
+// If typ is nil, the byte slice is unmarshalled into an empty interface.
+func (rs *RouteSet) unmarshal(parser Parser, bts []byte, typ interface{}) (interface{}, *HttpError) {
+	ptr, value := decodeTarget(typ)
+	if err := parser.Unmarshal(bts, ptr); err != nil {
+		// the body is malformed or does not fit the expected type, which is a client error
+		return nil, BadRequest(err.Error()).WithCause(err)
+	}
+
+	return value(), nil
+}
 
-	if err := rs.parser.Unmarshal(bts, &data); err != nil {
+// marshal marshals the interface by the passed parser into a byte slice
+func (rs *RouteSet) marshal(parser Parser, data interface{}) ([]byte, *HttpError) {
+	bts, err := parser.Marshal(&data)
+	if err != nil {
 		return nil, &HttpError{
 			Status:    http.StatusInternalServerError,
 			ErrorCode: "",
 			Message:   err.Error(),
 		}
 	}
-
-	return data, nil
+	return bts, nil
 }
 
-// marshal marshals the interface into a byte slice
-func (rs *RouteSet) marshal(data interface{}) ([]byte, *HttpError) {
-	bts, err := rs.parser.Marshal(&data)
-	if err != nil {
-		return nil, &HttpError{
+// encode encodes the interface by the passed stream parser into the writer
+func (rs *RouteSet) encode(parser StreamParser, w io.Writer, data interface{}) *HttpError {
+	if err := parser.NewEncoder(w).Encode(&data); err != nil {
+		return &HttpError{
 			Status:    http.StatusInternalServerError,
 			ErrorCode: "",
 			Message:   err.Error(),
 		}
 	}
-	return bts, nil
+	return nil
 }
@@ -2,10 +2,15 @@ package procroute
 
 import (
 	"bytes"
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 
 	"github.com/gorilla/mux"
@@ -357,6 +362,103 @@ func TestRouteSet_AddRoutes(t *testing.T) {
 	}
 }
 
+func TestRouteSet_AddRouteFactories(t *testing.T) {
+	tests := []struct {
+		name      string
+		factories []RouteFactory
+		wantLen   int
+	}{
+		{
+			name:      "test_empty_data",
+			factories: nil,
+			wantLen:   0,
+		},
+		{
+			name: "test_non_empty_data",
+			factories: []RouteFactory{
+				func() interface{} { return &getExample{} },
+				func() interface{} { return &postExample{} },
+			},
+			wantLen: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rs := NewRouteSet("/api", &exampleParser{})
+			if got := rs.AddRouteFactories(tt.factories...); len(got.routeFactories) != tt.wantLen {
+				t.Errorf("RouteSet.AddRouteFactories() len = %v, want %v", len(got.routeFactories), tt.wantLen)
+			}
+		})
+	}
+}
+
+func TestRouteSet_buildRouteFactories(t *testing.T) {
+	tests := []struct {
+		name      string
+		factories []RouteFactory
+		wantErr   bool
+	}{
+		{
+			name:      "nil_factory",
+			factories: []RouteFactory{nil},
+			wantErr:   true,
+		},
+		{
+			name: "full_example",
+			factories: []RouteFactory{
+				func() interface{} { return &fullExample{} },
+			},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rs := NewRouteSet("/api", &exampleParser{}).AddRouteFactories(tt.factories...)
+			rs.withLogger(&exampleLogger{}).withRouter(mux.NewRouter())
+			if err := rs.build(); (err != nil) != tt.wantErr {
+				t.Errorf("RouteSet.build() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRouteSet_routeFactoryIsolation(t *testing.T) {
+	created := int32(0)
+	rs := NewRouteSet("/api", &exampleParser{}).AddRouteFactories(func() interface{} {
+		atomic.AddInt32(&created, 1)
+		return &urlParamsExample{}
+	})
+	rs.withLogger(&exampleLogger{}).withRouter(mux.NewRouter())
+	if err := rs.build(); err != nil {
+		t.Fatalf("RouteSet.build() error = %v", err)
+	}
+
+	const requests = 50
+	wg := sync.WaitGroup{}
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			w := httptest.NewRecorder()
+			rs.router.ServeHTTP(w, httptest.NewRequest("GET", fmt.Sprintf("/api/%d?id=%d", id, id), nil))
+
+			if w.Code != http.StatusOK {
+				t.Errorf("request %d status = %v, want %v", id, w.Code, http.StatusOK)
+				return
+			}
+			if got, want := w.Body.String(), fmt.Sprintf(`"%d"`, id); got != want {
+				t.Errorf("request %d body = %v, want %v", id, got, want)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// one additional instance is created as prototype during the build
+	if got := atomic.LoadInt32(&created); got != requests+1 {
+		t.Errorf("created controllers = %v, want %v", got, requests+1)
+	}
+}
+
 func TestRouteSet_buildPath(t *testing.T) {
 	type fields struct {
 		parser   Parser
@@ -760,7 +862,7 @@ func TestRouteSet_registerPostRoute(t *testing.T) {
 				routeSet: tt.fields.routeSet,
 				logger:   tt.fields.logger,
 			}
-			if err := rm.registerPostRoute(tt.args.rt); (err != nil) != tt.wantErr {
+			if err := rm.registerPostRoute(tt.args.rt, nil); (err != nil) != tt.wantErr {
 				t.Errorf("RouteSet.registerPostRoute() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
@@ -965,7 +1067,7 @@ func TestRouteSet_registerGetRoute(t *testing.T) {
 				routeSet: tt.fields.routeSet,
 				logger:   tt.fields.logger,
 			}
-			if err := rm.registerGetRoute(tt.args.rt); (err != nil) != tt.wantErr {
+			if err := rm.registerGetRoute(tt.args.rt, nil); (err != nil) != tt.wantErr {
 				t.Errorf("RouteSet.registerGetRoute() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
@@ -1165,7 +1267,7 @@ func TestRouteSet_registerGetAllRoute(t *testing.T) {
 				routeSet: tt.fields.routeSet,
 				logger:   tt.fields.logger,
 			}
-			if err := rm.registerGetAllRoute(tt.args.rt); (err != nil) != tt.wantErr {
+			if err := rm.registerGetAllRoute(tt.args.rt, nil); (err != nil) != tt.wantErr {
 				t.Errorf("RouteSet.registerGetAllRoute() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
@@ -1365,7 +1467,7 @@ func TestRouteSet_registerUpdateRoute(t *testing.T) {
 				routeSet: tt.fields.routeSet,
 				logger:   tt.fields.logger,
 			}
-			if err := rm.registerUpdateRoute(tt.args.rt); (err != nil) != tt.wantErr {
+			if err := rm.registerUpdateRoute(tt.args.rt, nil); (err != nil) != tt.wantErr {
 				t.Errorf("RouteSet.registerUpdateRoute() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
@@ -1577,7 +1679,7 @@ func TestRouteSet_registerDeleteRoute(t *testing.T) {
 				routeSet: tt.fields.routeSet,
 				logger:   tt.fields.logger,
 			}
-			if err := rm.registerDeleteRoute(tt.args.rt); (err != nil) != tt.wantErr {
+			if err := rm.registerDeleteRoute(tt.args.rt, nil); (err != nil) != tt.wantErr {
 				t.Errorf("RouteSet.registerDeleteRoute() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
@@ -1789,7 +1891,7 @@ func TestRouteSet_registerRawRoute(t *testing.T) {
 				routeSet: tt.fields.routeSet,
 				logger:   tt.fields.logger,
 			}
-			if err := rm.registerRawRoute(tt.args.rt); (err != nil) != tt.wantErr {
+			if err := rm.registerRawRoute(tt.args.rt, nil); (err != nil) != tt.wantErr {
 				t.Errorf("RouteSet.registerRawRoute() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
@@ -1802,6 +1904,7 @@ func TestRouteSet_unmarshal(t *testing.T) {
 	}
 	type args struct {
 		bts []byte
+		typ interface{}
 	}
 	tests := []struct {
 		name      string
@@ -1835,6 +1938,48 @@ func TestRouteSet_unmarshal(t *testing.T) {
 			},
 			wantError: false,
 		},
+		{
+			name: "with_pointer_type",
+			fields: fields{
+				parser: &exampleParser{},
+			},
+			args: args{
+				bts: []byte(`{"name": "sample2", "value": 2}`),
+				typ: &data{},
+			},
+			want: &data{
+				Name:  "sample2",
+				Value: 2,
+			},
+			wantError: false,
+		},
+		{
+			name: "with_value_type",
+			fields: fields{
+				parser: &exampleParser{},
+			},
+			args: args{
+				bts: []byte(`{"name": "sample2", "value": 2}`),
+				typ: data{},
+			},
+			want: data{
+				Name:  "sample2",
+				Value: 2,
+			},
+			wantError: false,
+		},
+		{
+			name: "with_mismatching_type",
+			fields: fields{
+				parser: &exampleParser{},
+			},
+			args: args{
+				bts: []byte(`{"name": "sample2", "value": "two"}`),
+				typ: &data{},
+			},
+			want:      nil,
+			wantError: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -1842,10 +1987,13 @@ func TestRouteSet_unmarshal(t *testing.T) {
 				parser: tt.fields.parser,
 			}
 
-			got, err := rm.unmarshal(tt.args.bts)
+			got, err := rm.unmarshal(tt.fields.parser, tt.args.bts, tt.args.typ)
 			if (err != nil) != tt.wantError {
 				t.Errorf("RouteSet.unmarshal() received error = %+#v, want error = %+#v", err, tt.wantError)
 			}
+			if err != nil && err.Status != http.StatusBadRequest {
+				t.Errorf("RouteSet.unmarshal() status = %v, want %v", err.Status, http.StatusBadRequest)
+			}
 
 			if !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("RouteSet.unmarshal() is not equal \ngot = %+#v\nwant = %+#v", got, tt.want)
@@ -1903,7 +2051,7 @@ func TestRouteSet_marshal(t *testing.T) {
 				parser: tt.fields.parser,
 			}
 
-			got, httperr := rm.marshal(tt.args.data)
+			got, httperr := rm.marshal(tt.fields.parser, tt.args.data)
 			if (httperr != nil) != tt.wantError {
 				t.Errorf("RouteSet.marshal() received error got = %v, want %v", httperr, tt.wantError)
 			}
@@ -2003,3 +2151,178 @@ func TestRouteSet_doHttpOp(t *testing.T) {
 		})
 	}
 }
+
+func TestRouteSet_contextRoutes(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		err        error
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "get_prefers_context",
+			method:     "GET",
+			target:     "/api/1",
+			wantStatus: http.StatusOK,
+			wantBody:   `"from context"`,
+		},
+		{
+			name:       "get_all",
+			method:     "GET",
+			target:     "/api",
+			wantStatus: http.StatusOK,
+			wantBody:   `["from context"]`,
+		},
+		{
+			name:       "post",
+			method:     "POST",
+			target:     "/api",
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:       "update",
+			method:     "PUT",
+			target:     "/api/1",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "delete",
+			method:     "DELETE",
+			target:     "/api/1",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "http_error",
+			method:     "PUT",
+			target:     "/api/1",
+			err:        &HttpError{Status: http.StatusNotFound, Message: "not found"},
+			wantStatus: http.StatusNotFound,
+			wantBody:   `{"Status":404,"ErrorCode":"","Message":"not found"}`,
+		},
+		{
+			name:       "wrapped_http_error",
+			method:     "DELETE",
+			target:     "/api/1",
+			err:        fmt.Errorf("wrapped: %w", &HttpError{Status: http.StatusConflict, Message: "conflict"}),
+			wantStatus: http.StatusConflict,
+			wantBody:   `{"Status":409,"ErrorCode":"","Message":"conflict"}`,
+		},
+		{
+			name:       "plain_error",
+			method:     "POST",
+			target:     "/api",
+			err:        errors.New("plain error"),
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   `{"Status":500,"ErrorCode":"","Message":"Internal Server Error"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := mux.NewRouter()
+			router.Use(func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), testContextKey("value"), "from context")))
+				})
+			})
+
+			rs := NewRouteSet("/api", &exampleParser{}).AddRoutes(&contextExample{err: tt.err})
+			rs.withLogger(&exampleLogger{}).withRouter(router)
+			if err := rs.build(); err != nil {
+				t.Fatalf("RouteSet.build() error = %v", err)
+			}
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, nil))
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %v, want %v", w.Code, tt.wantStatus)
+			}
+			if got := w.Body.String(); got != tt.wantBody {
+				t.Errorf("body = %v, want %v", got, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestRouteSet_doHttpOpTyper(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		body       string
+		want       interface{}
+		wantStatus int
+	}{
+		{
+			name:   "post_type",
+			method: "POST",
+			body:   `{"name": "sample", "value": 1}`,
+			want:   &data{Name: "sample", Value: 1},
+		},
+		{
+			name:   "update_type",
+			method: "PUT",
+			body:   `{"name": "sample", "value": 1}`,
+			want:   data{Name: "sample", Value: 1},
+		},
+		{
+			name:   "fallback_type",
+			method: "DELETE",
+			body:   `{"name": "sample", "value": 1}`,
+			want:   map[string]interface{}{"name": "sample", "value": float64(1)},
+		},
+		{
+			name:       "body_does_not_fit",
+			method:     "POST",
+			body:       `{"name": 1}`,
+			want:       nil,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rs := &RouteSet{
+				parser: &exampleParser{},
+			}
+
+			got, err := rs.doHttpOp(&typerExample{}, httptest.NewRequest(tt.method, "/", strings.NewReader(tt.body)))
+			if err != nil && err.Status != tt.wantStatus {
+				t.Errorf("RouteSet.doHttpOp() status = %v, want %v", err.Status, tt.wantStatus)
+			}
+			if err == nil && tt.wantStatus != 0 {
+				t.Errorf("RouteSet.doHttpOp() error = nil, want status %v", tt.wantStatus)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("RouteSet.doHttpOp() is not equal\ngot = %+#v\nwant %+#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRouteSet_registerRawRoute_factory(t *testing.T) {
+	rs := NewRouteSet("/api", &exampleParser{}).withLogger(&exampleLogger{}).withRouter(mux.NewRouter())
+	if err := rs.registerRawRoute(nil, nil); !errors.Is(err, ErrRawRouteIsNil) {
+		t.Errorf("RouteSet.registerRawRoute() error = %v, want %v", err, ErrRawRouteIsNil)
+	}
+
+	// the prototype is a raw route, but the controllers created for requests are not
+	created := 0
+	rs = NewRouteSet("/api", &exampleParser{}).AddRouteFactories(func() interface{} {
+		created++
+		if created == 1 {
+			return &rawExample{}
+		}
+		return &getExample{}
+	})
+	rs.withLogger(&exampleLogger{}).withRouter(mux.NewRouter())
+	if err := rs.build(); err != nil {
+		t.Fatalf("RouteSet.build() error = %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	rs.router.ServeHTTP(w, httptest.NewRequest("GET", "/api/raw", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("status = %v, want %v", w.Code, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,346 @@
+package procroute
+
+import (
+	"context"
+	"io"
+	"net/http"
+)
+
+// NdjsonMimeType is the mime type of newline delimited JSON, which is offered by streaming get all routes
+const NdjsonMimeType = "application/x-ndjson"
+
+// ItemIterator iterates over the items of a streamed collection.
+// If the iterator implements io.Closer, it is closed after the items have been written.
+type ItemIterator interface {
+	// Next advances the iterator to the next item. It returns false, if there are no more items or an error occurred.
+	Next() bool
+	// Item returns the current item
+	Item() interface{}
+	// Err returns the error that stopped the iteration, if any
+	Err() error
+}
+
+// GetAllRouteStream provides an alternative to the GetAllRoute interface that streams the resources to the client.
+// The items are written one by one, so that the collection does not have to be kept in memory.
+// If a route implements multiple get all interfaces, GetAllStream is preferred over all but the GetPageRoute interface.
+type GetAllRouteStream interface {
+	// GetAllStream represents the method that contains the business logic for receiving all resources.
+	// The returned iterator is consumed after GetAllStream returned, the context is canceled when the client disconnects.
+	// Errors returned by GetAllStream or by the iterator before the first item are answered like errors of context aware routes.
+	// Errors after the first item abort the response, since the status has already been sent.
+	//
+	// Example
+	//  func (m *MyType) GetAllStream(ctx context.Context, requestData interface{}) (procroute.ItemIterator, error) {
+	//      rows, err := m.db.QueryContext(ctx, "SELECT id, name FROM users")
+	//      if err != nil {
+	//          return nil, err
+	//      }
+	//      return &userIterator{rows: rows}, nil
+	//  }
+	GetAllStream(ctx context.Context, requestData interface{}) (ItemIterator, error)
+}
+
+// sliceIterator iterates over the items of a slice
+type sliceIterator struct {
+	items []interface{}
+	index int
+}
+
+// NewSliceIterator returns an ItemIterator over the passed items
+func NewSliceIterator(items []interface{}) ItemIterator {
+	return &sliceIterator{items: items, index: -1}
+}
+
+// Next advances the iterator to the next item
+func (s *sliceIterator) Next() bool {
+	if s.index+1 >= len(s.items) {
+		return false
+	}
+	s.index++
+	return true
+}
+
+// Item returns the current item
+func (s *sliceIterator) Item() interface{} {
+	return s.items[s.index]
+}
+
+// Err returns nil, since iterating a slice never fails
+func (s *sliceIterator) Err() error {
+	return nil
+}
+
+// channelIterator iterates over the items received from a channel
+type channelIterator struct {
+	ctx   context.Context
+	items <-chan interface{}
+	item  interface{}
+	err   error
+}
+
+// NewChannelIterator returns an ItemIterator over the items received from the channel until it is closed.
+// If an error is received, the iteration stops with that error. If the context is done, the iteration stops with the error of the context,
+// so the producer should stop sending items once the context passed to GetAllStream is done.
+//
+// Example:
+//  func (m *MyType) GetAllStream(ctx context.Context, requestData interface{}) (procroute.ItemIterator, error) {
+//  	items := make(chan interface{})
+//  	go func() {
+//  		defer close(items)
+//  		for _, user := range m.users {
+//  			select {
+//  			case items <- user:
+//  			case <-ctx.Done():
+//  				return
+//  			}
+//  		}
+//  	}()
+//  	return procroute.NewChannelIterator(ctx, items), nil
+//  }
+func NewChannelIterator(ctx context.Context, items <-chan interface{}) ItemIterator {
+	return &channelIterator{ctx: ctx, items: items}
+}
+
+// Next receives the next item from the channel
+func (c *channelIterator) Next() bool {
+	if c.err != nil {
+		return false
+	}
+
+	select {
+	case item, ok := <-c.items:
+		if !ok {
+			return false
+		}
+		if err, isErr := item.(error); isErr {
+			c.err = err
+			return false
+		}
+		c.item = item
+		return true
+	case <-c.ctx.Done():
+		c.err = c.ctx.Err()
+		return false
+	}
+}
+
+// Item returns the item received last
+func (c *channelIterator) Item() interface{} {
+	return c.item
+}
+
+// Err returns the error received from the channel or the error of the context
+func (c *channelIterator) Err() error {
+	return c.err
+}
+
+// streamEncoderFor returns the parser that matches the Accept header of the request best, including newline delimited JSON,
+// which is offered if the route set has a JSON parser. The bool reports whether newline delimited JSON was negotiated.
+func (rs *RouteSet) streamEncoderFor(r *http.Request) (Parser, bool, *HttpError) {
+	var jsonParser Parser
+	for _, parser := range rs.allParsers() {
+		if problemTypeOf(parser) == ProblemJsonMimeType {
+			jsonParser = parser
+			break
+		}
+	}
+
+	mediaTypes := rs.mediaTypes()
+	if jsonParser != nil {
+		mediaTypes = append(mediaTypes, NdjsonMimeType)
+	}
+
+	i, ok := negotiate(r, mediaTypes)
+	if !ok {
+		_, err := rs.encoderFor(r)
+		return nil, false, err
+	}
+	if mediaTypes[i] == NdjsonMimeType {
+		return jsonParser, true, nil
+	}
+	return rs.allParsers()[i], false, nil
+}
+
+// defineGetAllStreamRoute defines the structure used for streaming get all routes.
+// Unlike other routes, the request may accept newline delimited JSON in addition to the mime types of the parsers.
+func (rs *RouteSet) defineGetAllStreamRoute(w http.ResponseWriter, r *http.Request, route GetAllRouteStream) {
+	if _, _, err := rs.streamEncoderFor(r); err != nil {
+		r.Body.Close()
+		rs.writeError(w, r, err)
+		return
+	}
+
+	request, httpErr := rs.readRequest(route, r)
+	if httpErr != nil {
+		rs.writeError(w, r, httpErr)
+		return
+	}
+
+	items, err := route.GetAllStream(requestContext(r), request)
+	if httpErr := rs.toHttpError(err); httpErr != nil {
+		rs.writeError(w, r, httpErr)
+		return
+	}
+	if items == nil {
+		items = NewSliceIterator(nil)
+	}
+	rs.writeStream(w, r, items)
+}
+
+// writeStream writes the items of the iterator to the client as they are received.
+// JSON parsers write a JSON array element by element, newline delimited JSON writes an item per line.
+// Other parsers can not encode a collection incrementally, so the items are collected and written at once.
+func (rs *RouteSet) writeStream(w http.ResponseWriter, r *http.Request, items ItemIterator) {
+	if closer, ok := items.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	parser, ndjson, httpErr := rs.streamEncoderFor(r)
+	if httpErr != nil {
+		rs.writeError(w, r, httpErr)
+		return
+	}
+
+	selectFields, httpErr := rs.fieldSelector(r, parser)
+	if httpErr != nil {
+		rs.writeError(w, r, httpErr)
+		return
+	}
+
+	if !ndjson && problemTypeOf(parser) != ProblemJsonMimeType {
+		rs.writeCollected(w, r, items, selectFields)
+		return
+	}
+
+	contentType, separator, prefix, suffix := parser.MimeType(), ",", "[", "]"
+	if ndjson {
+		contentType, separator, prefix, suffix = NdjsonMimeType, "", "", ""
+	}
+
+	// the status is sent with the first item, so that errors occurring before can still be answered with an error response
+	started := false
+	start := func() {
+		varyAccept(w)
+		w.Header().Set("Content-Type", contentType)
+		w.WriteHeader(http.StatusOK)
+		started = true
+	}
+
+	// HEAD requests do not receive a body, so the items are not read at all
+	if r.Method == http.MethodHead {
+		if hw, ok := w.(*headResponseWriter); ok {
+			hw.unknownLength = true
+		}
+		start()
+		return
+	}
+
+	ctx := r.Context()
+	for items.Next() {
+		if ctx.Err() != nil {
+			break
+		}
+
+		bts, err := rs.encodeStreamItem(parser, items.Item(), selectFields)
+		if err != nil {
+			rs.abortStream(w, r, started, err)
+			return
+		}
+
+		chunk := make([]byte, 0, len(prefix)+len(bts)+1)
+		if !started {
+			start()
+			chunk = append(chunk, prefix...)
+		} else {
+			chunk = append(chunk, separator...)
+		}
+		chunk = append(chunk, bts...)
+		if ndjson {
+			chunk = append(chunk, '\n')
+		}
+		if err := writeFlush(w, chunk); err != nil {
+			rs.logger.Debug("failed to stream %s %s: %v", r.Method, r.URL.Path, err)
+			return
+		}
+	}
+
+	if ctx.Err() != nil {
+		rs.logger.Debug("client disconnected while streaming %s %s", r.Method, r.URL.Path)
+		return
+	}
+	if err := items.Err(); err != nil {
+		rs.abortStream(w, r, started, rs.toHttpError(err))
+		return
+	}
+
+	if !started {
+		start()
+		suffix = prefix + suffix
+	}
+	if err := writeFlush(w, []byte(suffix)); err != nil {
+		rs.logger.Debug("failed to stream %s %s: %v", r.Method, r.URL.Path, err)
+	}
+}
+
+// writeCollected collects the items of the iterator and writes them at once
+func (rs *RouteSet) writeCollected(w http.ResponseWriter, r *http.Request, items ItemIterator, selectFields func(value interface{}) (interface{}, error)) {
+	ctx := r.Context()
+	collected := []interface{}{}
+	for items.Next() {
+		if ctx.Err() != nil {
+			rs.logger.Debug("client disconnected while streaming %s %s", r.Method, r.URL.Path)
+			return
+		}
+
+		item := items.Item()
+		if selectFields != nil {
+			var err error
+			if item, err = selectFields(item); err != nil {
+				rs.writeError(w, r, BadRequest(err.Error()).WithCause(err))
+				return
+			}
+		}
+		collected = append(collected, item)
+	}
+	if err := items.Err(); err != nil {
+		rs.writeError(w, r, rs.toHttpError(err))
+		return
+	}
+
+	rs.writeData(w, r, http.StatusOK, collected)
+}
+
+// encodeStreamItem reduces the item to the selected fields and marshals it
+func (rs *RouteSet) encodeStreamItem(parser Parser, item interface{}, selectFields func(value interface{}) (interface{}, error)) ([]byte, *HttpError) {
+	if selectFields != nil {
+		projected, err := selectFields(item)
+		if err != nil {
+			return nil, BadRequest(err.Error()).WithCause(err)
+		}
+		item = projected
+	}
+	return rs.marshal(parser, item)
+}
+
+// abortStream answers the error with an error response, if the stream has not been started yet.
+// Otherwise the status has already been sent, so the connection is aborted to signal the client that the response is incomplete.
+func (rs *RouteSet) abortStream(w http.ResponseWriter, r *http.Request, started bool, httpErr *HttpError) {
+	if !started {
+		rs.writeError(w, r, httpErr)
+		return
+	}
+
+	rs.logger.Error("aborted streaming %s %s: %v", r.Method, r.URL.Path, httpErr)
+	panic(http.ErrAbortHandler)
+}
+
+// writeFlush writes the bytes to the response writer and flushes them to the client, if the response writer supports it
+func writeFlush(w http.ResponseWriter, b []byte) error {
+	if _, err := w.Write(b); err != nil {
+		return err
+	}
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return nil
+}
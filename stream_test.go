@@ -0,0 +1,266 @@
+package procroute
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestRouteSet_getAllStreamRoute(t *testing.T) {
+	tests := []struct {
+		name            string
+		route           *streamExample
+		method          string
+		accept          string
+		query           string
+		wantStatus      int
+		wantContentType string
+		wantBody        string
+		wantFlushed     bool
+	}{
+		{
+			name:            "json_array",
+			route:           &streamExample{items: []interface{}{1, "two", map[string]int{"three": 3}}},
+			wantStatus:      http.StatusOK,
+			wantContentType: "application/json",
+			wantBody:        `[1,"two",{"three":3}]`,
+			wantFlushed:     true,
+		},
+		{
+			name:            "empty_json_array",
+			route:           &streamExample{},
+			wantStatus:      http.StatusOK,
+			wantContentType: "application/json",
+			wantBody:        `[]`,
+			wantFlushed:     true,
+		},
+		{
+			name:            "ndjson",
+			route:           &streamExample{items: []interface{}{1, "two"}},
+			accept:          NdjsonMimeType,
+			wantStatus:      http.StatusOK,
+			wantContentType: NdjsonMimeType,
+			wantBody:        "1\n\"two\"\n",
+			wantFlushed:     true,
+		},
+		{
+			name:            "xml_collected",
+			route:           &streamExample{items: []interface{}{1, 2}},
+			accept:          "application/xml",
+			wantStatus:      http.StatusOK,
+			wantContentType: "application/xml",
+			wantBody:        `<int>1</int><int>2</int>`,
+		},
+		{
+			name:            "selected_fields",
+			route:           &streamExample{items: []interface{}{fieldsetOwner{Name: "a", Email: "a@example.com"}}},
+			query:           "?fields=name",
+			wantStatus:      http.StatusOK,
+			wantContentType: "application/json",
+			wantBody:        `[{"name":"a"}]`,
+			wantFlushed:     true,
+		},
+		{
+			name:            "head",
+			route:           &streamExample{items: []interface{}{1, 2}},
+			method:          http.MethodHead,
+			wantStatus:      http.StatusOK,
+			wantContentType: "application/json",
+		},
+		{
+			name:       "route_error",
+			route:      &streamExample{err: NotFound("no items")},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "error_before_first_item",
+			route:      &streamExample{iterErr: errors.New("query failed")},
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name:       "not_acceptable",
+			route:      &streamExample{},
+			accept:     "text/csv",
+			wantStatus: http.StatusNotAcceptable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rm := NewRouteMachine("", 0, "/api", &exampleLogger{})
+			if err := rm.AddRouteSet(NewRouteSet("/items", &exampleParser{}).AddParsers(&exampleXmlParser{}).AddRoutes(tt.route)); err != nil {
+				t.Fatalf("RouteMachine.AddRouteSet() error = %v", err)
+			}
+
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			r := httptest.NewRequest(method, "/api/items"+tt.query, nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			rm.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %v, want %v, body = %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.route.iterator != nil && !tt.route.iterator.closed {
+				t.Errorf("iterator was not closed")
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			if got := w.Header().Get("Content-Type"); got != tt.wantContentType {
+				t.Errorf("Content-Type = %v, want %v", got, tt.wantContentType)
+			}
+			if got := w.Body.String(); got != tt.wantBody {
+				t.Errorf("body = %s, want %s", got, tt.wantBody)
+			}
+			if w.Flushed != tt.wantFlushed {
+				t.Errorf("flushed = %v, want %v", w.Flushed, tt.wantFlushed)
+			}
+		})
+	}
+}
+
+func TestRouteSet_writeStream_abort(t *testing.T) {
+	tests := []struct {
+		name      string
+		route     *streamExample
+		cancel    bool
+		wantPanic bool
+		wantBody  string
+	}{
+		{
+			name:      "error_after_first_item",
+			route:     &streamExample{items: []interface{}{1}, iterErr: errors.New("connection lost")},
+			wantPanic: true,
+			wantBody:  `[1`,
+		},
+		{
+			name:     "client_disconnected",
+			route:    &streamExample{items: []interface{}{1, 2}},
+			cancel:   true,
+			wantBody: ``,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rs := NewRouteSet("/items", &exampleParser{}).withLogger(&exampleLogger{})
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancel {
+				cancel()
+			}
+
+			items, _ := tt.route.GetAllStream(ctx, nil)
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/items", nil).WithContext(ctx)
+
+			var recovered interface{}
+			func() {
+				defer func() { recovered = recover() }()
+				rs.writeStream(w, r, items)
+			}()
+
+			if gotPanic := recovered != nil; gotPanic != tt.wantPanic {
+				t.Fatalf("panic = %v, want %v", recovered, tt.wantPanic)
+			}
+			if tt.wantPanic && recovered != http.ErrAbortHandler {
+				t.Errorf("panic = %v, want %v", recovered, http.ErrAbortHandler)
+			}
+			if got := w.Body.String(); got != tt.wantBody {
+				t.Errorf("body = %s, want %s", got, tt.wantBody)
+			}
+			if !tt.route.iterator.closed {
+				t.Errorf("iterator was not closed")
+			}
+		})
+	}
+}
+
+func TestNewChannelIterator(t *testing.T) {
+	errQuery := errors.New("query failed")
+	tests := []struct {
+		name      string
+		items     []interface{}
+		close     bool
+		cancel    bool
+		wantItems []interface{}
+		wantErr   error
+	}{
+		{
+			name:      "closed_channel",
+			items:     []interface{}{1, "two"},
+			close:     true,
+			wantItems: []interface{}{1, "two"},
+		},
+		{
+			name:      "error_item",
+			items:     []interface{}{1, errQuery, 2},
+			close:     true,
+			wantItems: []interface{}{1},
+			wantErr:   errQuery,
+		},
+		{
+			name:      "canceled_context",
+			items:     []interface{}{1},
+			cancel:    true,
+			wantItems: []interface{}{1},
+			wantErr:   context.Canceled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			ch := make(chan interface{}, len(tt.items))
+			for _, item := range tt.items {
+				ch <- item
+			}
+			if tt.close {
+				close(ch)
+			}
+
+			it := NewChannelIterator(ctx, ch)
+			got := []interface{}{}
+			for len(got) < len(tt.wantItems) && it.Next() {
+				got = append(got, it.Item())
+			}
+			if tt.cancel {
+				cancel()
+			}
+			for it.Next() {
+				got = append(got, it.Item())
+			}
+
+			if !reflect.DeepEqual(got, tt.wantItems) {
+				t.Errorf("items = %v, want %v", got, tt.wantItems)
+			}
+			if !errors.Is(it.Err(), tt.wantErr) {
+				t.Errorf("Err() = %v, want %v", it.Err(), tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewSliceIterator(t *testing.T) {
+	items := []interface{}{1, "two", nil}
+	it := NewSliceIterator(items)
+
+	got := []interface{}{}
+	for it.Next() {
+		got = append(got, it.Item())
+	}
+	if !reflect.DeepEqual(got, items) {
+		t.Errorf("items = %v, want %v", got, items)
+	}
+	if it.Next() || it.Err() != nil {
+		t.Errorf("Next() after the last item = true or Err() = %v", it.Err())
+	}
+}
@@ -0,0 +1,133 @@
+package procroute
+
+import (
+	"net/http"
+	"reflect"
+)
+
+// Typer defines an optional interface that is used to decode the request body into a concrete type instead of an empty interface.
+type Typer interface {
+	// Type returns a value of the type the request body should be decoded into.
+	// The returned value is only used to determine the type, the body is always decoded into a new value.
+	// If Type returns a pointer, the request data passed to the route is a pointer as well.
+	//
+	// Example:
+	//  type Model struct {
+	//  	Name string `json:"name,omitempty"`
+	//  	URL  string `json:"url,omitempty"`
+	//  }
+	//
+	//  type MyType struct {}
+	//
+	//  func (m *MyType) Type() interface{} {
+	//  	return &Model{}
+	//  }
+	//
+	//  func (m *MyType) Post(requestData interface{}) *HttpError {
+	//  	model := requestData.(*Model)
+	//  	// do something
+	//  	return nil
+	//  }
+	Type() interface{}
+}
+
+// PostTyper defines an optional interface that overrides the Typer interface for post routes.
+type PostTyper interface {
+	// PostType returns a value of the type the request body of post requests should be decoded into.
+	//
+	// Example:
+	//  func (m *MyType) PostType() interface{} {
+	//  	return &CreateModel{}
+	//  }
+	PostType() interface{}
+}
+
+// UpdateTyper defines an optional interface that overrides the Typer interface for update routes.
+type UpdateTyper interface {
+	// UpdateType returns a value of the type the request body of update requests should be decoded into.
+	//
+	// Example:
+	//  func (m *MyType) UpdateType() interface{} {
+	//  	return &UpdateModel{}
+	//  }
+	UpdateType() interface{}
+}
+
+// PatchTyper defines an optional interface that overrides the Typer interface for patch routes.
+type PatchTyper interface {
+	// PatchType returns a value of the type the request body of patch requests should be decoded into.
+	// For JSON Merge Patch and JSON Patch requests, the patched resource is decoded into this type.
+	//
+	// Example:
+	//  func (m *MyType) PatchType() interface{} {
+	//  	return &Model{}
+	//  }
+	PatchType() interface{}
+}
+
+// DeleteTyper defines an optional interface that overrides the Typer interface for delete routes.
+type DeleteTyper interface {
+	// DeleteType returns a value of the type the request body of delete requests should be decoded into.
+	//
+	// Example:
+	//  func (m *MyType) DeleteType() interface{} {
+	//  	return &DeleteModel{}
+	//  }
+	DeleteType() interface{}
+}
+
+// requestType returns the type sample the request body of the passed http method should be decoded into.
+// The method specific typer is preferred over the Typer interface. If none is implemented, nil is returned.
+func requestType(routeController interface{}, method string) interface{} {
+	switch method {
+	case http.MethodPost:
+		if t, ok := routeController.(PostTyper); ok {
+			return t.PostType()
+		}
+	case http.MethodPut:
+		if t, ok := routeController.(UpdateTyper); ok {
+			return t.UpdateType()
+		}
+	case http.MethodPatch:
+		if t, ok := routeController.(PatchTyper); ok {
+			return t.PatchType()
+		}
+	case http.MethodDelete:
+		if t, ok := routeController.(DeleteTyper); ok {
+			return t.DeleteType()
+		}
+	}
+
+	if t, ok := routeController.(Typer); ok {
+		return t.Type()
+	}
+	return nil
+}
+
+// newTypedValue creates a pointer to a new zero value of the sample type.
+// The returned function converts the pointer back into the kind of the sample, e.g. the value itself for non pointer samples.
+func newTypedValue(sample interface{}) (interface{}, func() interface{}) {
+	rt := reflect.TypeOf(sample)
+	isPtr := rt.Kind() == reflect.Ptr
+	if isPtr {
+		rt = rt.Elem()
+	}
+
+	value := reflect.New(rt)
+	return value.Interface(), func() interface{} {
+		if isPtr {
+			return value.Interface()
+		}
+		return value.Elem().Interface()
+	}
+}
+
+// decodeTarget returns a pointer to a new value of the type of the sample and a function that returns the decoded value.
+// If the sample is nil, the pointer refers to an empty interface.
+func decodeTarget(sample interface{}) (interface{}, func() interface{}) {
+	if sample == nil {
+		var data interface{}
+		return &data, func() interface{} { return data }
+	}
+	return newTypedValue(sample)
+}
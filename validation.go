@@ -0,0 +1,385 @@
+package procroute
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+var (
+	ErrInvalidValidationTag = errors.New("invalid validation tag")
+)
+
+// Validator defines an optional interface of the request data, which is called after the struct tag rules have been validated successfully.
+type Validator interface {
+	// Validate validates the request data. Returning ValidationErrors reports errors of single fields,
+	// a HttpError is sent to the client as it is and any other error is answered with 422 Unprocessable Entity.
+	//
+	// Example:
+	//  type Model struct {
+	//  	Start time.Time `json:"start"`
+	//  	End   time.Time `json:"end"`
+	//  }
+	//
+	//  func (m *Model) Validate() error {
+	//  	if m.End.Before(m.Start) {
+	//  		return procroute.ValidationErrors{{Field: "end", Code: "after_start", Message: "must be after start"}}
+	//  	}
+	//  	return nil
+	//  }
+	Validate() error
+}
+
+// RequestValidator defines an optional interface of the route controller, which validates the request data after the Validator interface of the request data.
+// The url params, query params and request metadata are already set, when ValidateRequest is called.
+type RequestValidator interface {
+	// ValidateRequest validates the request data. The returned error is handled like the error of the Validator interface.
+	//
+	// Example:
+	//  func (m *MyType) ValidateRequest(requestData interface{}) error {
+	//  	if requestData.(*Model).ID != m.urlParams["id"] {
+	//  		return procroute.ValidationErrors{{Field: "id", Code: "mismatch", Message: "must match the id of the url"}}
+	//  	}
+	//  	return nil
+	//  }
+	ValidateRequest(requestData interface{}) error
+}
+
+// ValidationErrors contains the errors of single fields of the request data.
+// It is returned by the Validator and RequestValidator interfaces to report multiple failing fields.
+type ValidationErrors []FieldError
+
+// Error implements the error interface
+func (v ValidationErrors) Error() string {
+	msgs := make([]string, 0, len(v))
+	for _, fieldErr := range v {
+		msgs = append(msgs, strings.TrimSpace(fieldErr.Field+" "+fieldErr.Message))
+	}
+	return strings.Join(msgs, ", ")
+}
+
+// validationRule represents a single rule of a validate struct tag
+type validationRule struct {
+	name   string
+	param  string
+	number float64
+	regexp *regexp.Regexp
+}
+
+// validationRules caches the parsed rules by the validate struct tag
+var validationRules sync.Map
+
+// validate validates the request data by the validate struct tags, the Validator interface of the request data
+// and the RequestValidator interface of the route controller, in that order. The first failing step is answered with 422 Unprocessable Entity.
+func (rs *RouteSet) validate(routeController interface{}, data interface{}) *HttpError {
+	if data == nil {
+		return nil
+	}
+
+	fieldErrs, err := validateValue(reflect.ValueOf(data), "")
+	if err != nil {
+		return InternalServerError(err.Error()).WithCause(err)
+	}
+	if len(fieldErrs) > 0 {
+		return validationFailed(fieldErrs)
+	}
+
+	if v, ok := data.(Validator); ok {
+		if httpErr := toValidationError(v.Validate()); httpErr != nil {
+			return httpErr
+		}
+	}
+
+	if v, ok := routeController.(RequestValidator); ok {
+		return toValidationError(v.ValidateRequest(data))
+	}
+	return nil
+}
+
+// toValidationError converts the error returned by a validator into the HttpError sent to the client
+func toValidationError(err error) *HttpError {
+	if err == nil {
+		return nil
+	}
+
+	var httpErr *HttpError
+	if errors.As(err, &httpErr) {
+		return httpErr
+	}
+
+	var fieldErrs ValidationErrors
+	if errors.As(err, &fieldErrs) {
+		return validationFailed(fieldErrs).WithCause(err)
+	}
+	return UnprocessableEntity(err.Error()).WithCause(err)
+}
+
+// validationFailed returns the error sent to the client, if fields of the request data are invalid
+func validationFailed(fieldErrs []FieldError) *HttpError {
+	return UnprocessableEntity("validation failed").WithFieldErrors(fieldErrs...)
+}
+
+// validateValue validates the struct tag rules of the value and all nested structs, slices, arrays and maps.
+// The returned error is only set, if a validate struct tag is invalid.
+func validateValue(v reflect.Value, path string) ([]FieldError, error) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+
+	fieldErrs := []FieldError{}
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			if sf.PkgPath != "" && !sf.Anonymous {
+				continue
+			}
+
+			name, promoted := validationFieldName(sf)
+			fieldPath := path
+			if !promoted {
+				fieldPath = joinFieldPath(path, name)
+			}
+
+			errs, err := validateField(v.Field(i), fieldPath, sf.Tag.Get("validate"))
+			if errors.Is(err, ErrInvalidValidationTag) {
+				return nil, err
+			}
+			if err != nil {
+				return nil, fmt.Errorf("%w of field %s.%s: %v", ErrInvalidValidationTag, t.Name(), sf.Name, err)
+			}
+			fieldErrs = append(fieldErrs, errs...)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			errs, err := validateValue(v.Index(i), fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return nil, err
+			}
+			fieldErrs = append(fieldErrs, errs...)
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			errs, err := validateValue(iter.Value(), fmt.Sprintf("%s[%v]", path, iter.Key()))
+			if err != nil {
+				return nil, err
+			}
+			fieldErrs = append(fieldErrs, errs...)
+		}
+	}
+	return fieldErrs, nil
+}
+
+// validateField validates the rules of the struct tag against the field value and continues with the nested values.
+// Nested values are only validated, if the field itself is valid.
+func validateField(v reflect.Value, path string, tag string) ([]FieldError, error) {
+	rules, err := parseValidationTag(tag)
+	if err != nil {
+		return nil, err
+	}
+
+	// all rules except required are skipped for nil pointers and for empty values of omitempty fields
+	empty := isEmptyValue(v)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			break
+		}
+		v = v.Elem()
+	}
+	skip := v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface
+	for _, rule := range rules {
+		skip = skip || (rule.name == "omitempty" && empty)
+	}
+
+	for _, rule := range rules {
+		switch rule.name {
+		case "omitempty":
+			continue
+		case "required":
+			if empty {
+				return []FieldError{{Field: path, Code: rule.name, Message: "is required"}}, nil
+			}
+			continue
+		}
+		if skip {
+			continue
+		}
+
+		fieldErr, err := rule.check(v)
+		if err != nil {
+			return nil, err
+		}
+		if fieldErr != nil {
+			fieldErr.Field = path
+			return []FieldError{*fieldErr}, nil
+		}
+	}
+
+	if skip {
+		return nil, nil
+	}
+	return validateValue(v, path)
+}
+
+// check validates the rule against the value and returns a FieldError without field path, if the value is invalid
+func (vr validationRule) check(v reflect.Value) (*FieldError, error) {
+	switch vr.name {
+	case "min", "max", "len":
+		value, isLength, ok := measure(v)
+		if !ok {
+			return nil, fmt.Errorf("rule %s is not supported for kind %s", vr.name, v.Kind())
+		}
+		if vr.name == "len" && !isLength {
+			return nil, fmt.Errorf("rule len is not supported for kind %s", v.Kind())
+		}
+
+		unit := ""
+		if isLength {
+			unit = " in length"
+		}
+		switch {
+		case vr.name == "min" && value < vr.number:
+			return &FieldError{Code: vr.name, Message: "must be at least " + vr.param + unit}, nil
+		case vr.name == "max" && value > vr.number:
+			return &FieldError{Code: vr.name, Message: "must be at most " + vr.param + unit}, nil
+		case vr.name == "len" && value != vr.number:
+			return &FieldError{Code: vr.name, Message: "must be exactly " + vr.param + unit}, nil
+		}
+	case "regexp":
+		if v.Kind() != reflect.String {
+			return nil, fmt.Errorf("rule regexp is not supported for kind %s", v.Kind())
+		}
+		if !vr.regexp.MatchString(v.String()) {
+			return &FieldError{Code: vr.name, Message: "must match " + vr.param}, nil
+		}
+	case "oneof":
+		value := fmt.Sprint(v)
+		for _, option := range strings.Fields(vr.param) {
+			if option == value {
+				return nil, nil
+			}
+		}
+		return &FieldError{Code: vr.name, Message: "must be one of " + strings.Join(strings.Fields(vr.param), ", ")}, nil
+	case "email":
+		if v.Kind() != reflect.String {
+			return nil, fmt.Errorf("rule email is not supported for kind %s", v.Kind())
+		}
+		if addr, err := mail.ParseAddress(v.String()); err != nil || addr.Address != v.String() {
+			return &FieldError{Code: vr.name, Message: "must be a valid email address"}, nil
+		}
+	}
+	return nil, nil
+}
+
+// parseValidationTag parses the rules of a validate struct tag, e.g. "required,min=3,oneof=red green".
+// Since regular expressions may contain commas, a regexp rule must be the last rule of the tag.
+func parseValidationTag(tag string) ([]validationRule, error) {
+	if tag == "" {
+		return nil, nil
+	}
+	if cached, ok := validationRules.Load(tag); ok {
+		return cached.([]validationRule), nil
+	}
+
+	rules := []validationRule{}
+	parts := strings.Split(tag, ",")
+	for i := 0; i < len(parts); i++ {
+		name, param, _ := strings.Cut(strings.TrimSpace(parts[i]), "=")
+		rule := validationRule{name: name, param: param}
+		switch name {
+		case "":
+			continue
+		case "required", "omitempty", "email":
+		case "min", "max", "len":
+			number, err := strconv.ParseFloat(param, 64)
+			if err != nil {
+				return nil, fmt.Errorf("rule %s requires a number: %v", name, err)
+			}
+			rule.number = number
+		case "oneof":
+			if strings.TrimSpace(param) == "" {
+				return nil, fmt.Errorf("rule oneof requires at least one value")
+			}
+		case "regexp":
+			rule.param = strings.Join(append([]string{param}, parts[i+1:]...), ",")
+			re, err := regexp.Compile(rule.param)
+			if err != nil {
+				return nil, fmt.Errorf("rule regexp requires a valid regular expression: %v", err)
+			}
+			rule.regexp = re
+			i = len(parts)
+		default:
+			return nil, fmt.Errorf("unknown rule %s", name)
+		}
+		rules = append(rules, rule)
+	}
+
+	validationRules.Store(tag, rules)
+	return rules, nil
+}
+
+// measure returns the value of numbers or the length of strings, slices, arrays and maps, which is compared by the min, max and len rules.
+// The first bool reports whether the returned value is a length, the second one whether the kind can be measured at all.
+func measure(v reflect.Value) (float64, bool, bool) {
+	switch v.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(v.String())), true, true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(v.Len()), true, true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), false, true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(v.Uint()), false, true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), false, true
+	}
+	return 0, false, false
+}
+
+// isEmptyValue reports whether the value is nil, the zero value or an empty string, slice or map
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	case reflect.Invalid:
+		return true
+	}
+	return v.IsZero()
+}
+
+// validationFieldName returns the name of the field within field paths, which is the name of the json struct tag or the field name.
+// The bool is true for embedded structs without name, whose fields are promoted to the parent.
+func validationFieldName(sf reflect.StructField) (string, bool) {
+	name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+	if name == "-" {
+		name = ""
+	}
+	if name != "" {
+		return name, false
+	}
+
+	t := sf.Type
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return sf.Name, sf.Anonymous && t.Kind() == reflect.Struct
+}
+
+// joinFieldPath appends the field name to the path of the parent
+func joinFieldPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
@@ -0,0 +1,164 @@
+package procroute
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRouteSet_validate(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		err        error
+		wantStatus int
+		wantErrors []FieldError
+	}{
+		{
+			name:       "valid",
+			body:       `{"id":1,"name":"sample","color":"red","address":{"street":"main","zip":"12345"},"items":[{"street":"side"}]}`,
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:       "struct_tags",
+			body:       `{"id":0,"name":"ab","email":"no-mail","color":"blue","address":{"zip":"12a45"},"items":[{"street":"a"},{"zip":"1"}]}`,
+			wantStatus: http.StatusUnprocessableEntity,
+			wantErrors: []FieldError{
+				{Field: "id", Code: "min", Message: "must be at least 1"},
+				{Field: "name", Code: "min", Message: "must be at least 3 in length"},
+				{Field: "email", Code: "email", Message: "must be a valid email address"},
+				{Field: "color", Code: "oneof", Message: "must be one of red, green"},
+				{Field: "address.street", Code: "required", Message: "is required"},
+				{Field: "address.zip", Code: "regexp", Message: "must match ^[0-9]+$"},
+				{Field: "items[1].street", Code: "required", Message: "is required"},
+				{Field: "items[1].zip", Code: "len", Message: "must be exactly 5 in length"},
+			},
+		},
+		{
+			name:       "required_and_max",
+			body:       `{"id":1,"color":"green","items":[{"street":"a"},{"street":"b"},{"street":"c"}]}`,
+			wantStatus: http.StatusUnprocessableEntity,
+			wantErrors: []FieldError{
+				{Field: "name", Code: "required", Message: "is required"},
+				{Field: "items", Code: "max", Message: "must be at most 2 in length"},
+			},
+		},
+		{
+			name:       "model_validator",
+			body:       `{"id":1,"name":"invalid","color":"red"}`,
+			wantStatus: http.StatusUnprocessableEntity,
+			wantErrors: []FieldError{{Field: "name", Code: "reserved", Message: "is reserved"}},
+		},
+		{
+			name:       "request_validator_error",
+			body:       `{"id":1,"name":"sample","color":"red"}`,
+			err:        errors.New("name already taken"),
+			wantStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:       "request_validator_http_error",
+			body:       `{"id":1,"name":"sample","color":"red"}`,
+			err:        Conflict("name already taken"),
+			wantStatus: http.StatusConflict,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			route := &validationExample{err: tt.err}
+			rs := NewRouteSet("/api", &exampleParser{})
+
+			w := httptest.NewRecorder()
+			rs.definePostRoute(w, httptest.NewRequest("POST", "/api", strings.NewReader(tt.body)), route)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %v, want %v, body = %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if route.called != (tt.wantStatus == http.StatusCreated) {
+				t.Errorf("route called = %v, want %v", route.called, !route.called)
+			}
+			if tt.wantErrors == nil {
+				return
+			}
+
+			got := HttpError{}
+			if err := (&exampleParser{}).Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if !reflect.DeepEqual(got.Errors, tt.wantErrors) {
+				t.Errorf("errors = %+v, want %+v", got.Errors, tt.wantErrors)
+			}
+		})
+	}
+}
+
+func TestParseValidationTag(t *testing.T) {
+	tests := []struct {
+		name    string
+		tag     string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "rules",
+			tag:  "required,min=3,oneof=a b",
+			want: []string{"required", "min", "oneof"},
+		},
+		{
+			name: "regexp_with_comma",
+			tag:  "omitempty,regexp=^[a-z]{1,3}$",
+			want: []string{"omitempty", "regexp"},
+		},
+		{
+			name:    "unknown_rule",
+			tag:     "required,unique",
+			wantErr: true,
+		},
+		{
+			name:    "invalid_number",
+			tag:     "min=three",
+			wantErr: true,
+		},
+		{
+			name:    "invalid_regexp",
+			tag:     "regexp=[",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := parseValidationTag(tt.tag)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseValidationTag() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			got := []string{}
+			for _, rule := range rules {
+				got = append(got, rule.name)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseValidationTag() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	rules, _ := parseValidationTag("regexp=^[a-z]{1,3}$")
+	if rules[0].param != "^[a-z]{1,3}$" {
+		t.Errorf("regexp param = %v, want ^[a-z]{1,3}$", rules[0].param)
+	}
+}
+
+func TestValidateValue_invalidTag(t *testing.T) {
+	type invalid struct {
+		Flag bool `validate:"min=1"`
+	}
+	type outer struct {
+		Inner invalid
+	}
+
+	_, err := validateValue(reflect.ValueOf(&outer{Inner: invalid{Flag: true}}), "")
+	if !errors.Is(err, ErrInvalidValidationTag) || strings.Count(err.Error(), ErrInvalidValidationTag.Error()) != 1 {
+		t.Errorf("validateValue() error = %v, want %v", err, ErrInvalidValidationTag)
+	}
+}